@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
 )
@@ -13,13 +14,18 @@ import (
 type ApplyCommand struct {
 	Meta
 	backendConfig []string
+	lock          bool
+	lockTimeout   time.Duration
 }
 
 // Run runs the procedure of this command.
 func (c *ApplyCommand) Run(args []string) int {
 	cmdFlags := flag.NewFlagSet("apply", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
 	cmdFlags.StringArrayVar(&c.backendConfig, "backend-config", nil, "A backend configuration for remote state")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage during apply")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
@@ -31,6 +37,10 @@ func (c *ApplyCommand) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("failed to load config file: %s", err))
 		return 1
 	}
+	if c.config, err = c.config.WithNamespace(c.namespace); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	log.Printf("[DEBUG] [command] config: %#v\n", c.config)
 
 	c.Option = newOption(c.config)
@@ -95,6 +105,9 @@ func (c *ApplyCommand) applyWithHistory(filename string) error {
 	if err != nil {
 		return err
 	}
+	hr.version = c.Version
+	hr.lock = c.lock
+	hr.lockTimeout = c.lockTimeout
 
 	return hr.Apply(ctx)
 }
@@ -113,9 +126,12 @@ Arguments
 
 Options:
   --config                 A path to tfmigrate config file
+  --namespace              A namespace of migrations
   --backend-config=path    A backend configuration, a path to backend configuration file or
                            key=value format backend configuraion.
                            This option is passed to terraform init when switching backend to remote.
+  --lock=true              Lock the history storage during apply in history mode.
+  --lock-timeout=0s        A duration to retry acquiring a lock.
 `
 	return strings.TrimSpace(helpText)
 }
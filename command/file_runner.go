@@ -20,6 +20,8 @@ type FileRunner struct {
 	mc *tfmigrate.MigrationConfig
 	// A migrator instance to be run.
 	m tfmigrate.Migrator
+	// An option to build another migrator for rollback.
+	option *tfmigrate.MigratorOption
 }
 
 // NewFileRunner returns a new FileRunner instance.
@@ -50,6 +52,7 @@ func NewFileRunner(filename string, config *config.TfmigrateConfig, option *tfmi
 		config:   config,
 		mc:       mc,
 		m:        m,
+		option:   option,
 	}
 
 	return r, nil
@@ -80,6 +83,35 @@ func (r *FileRunner) Apply(ctx context.Context) error {
 	return r.m.Apply(ctx)
 }
 
+// PlanRollback plans a rollback of a single migration.
+func (r *FileRunner) PlanRollback(ctx context.Context) error {
+	m, err := r.mc.Migrator.NewRollbackMigrator(r.option)
+	if err != nil {
+		return err
+	}
+
+	return m.Plan(ctx)
+}
+
+// Rollback reverts a single migration.
+func (r *FileRunner) Rollback(ctx context.Context) error {
+	m, err := r.mc.Migrator.NewRollbackMigrator(r.option)
+	if err != nil {
+		return err
+	}
+
+	return m.Apply(ctx)
+}
+
+// Report returns a detailed result of the last plan or apply.
+// It returns nil if the migrator doesn't support reporting.
+func (r *FileRunner) Report() *tfmigrate.Report {
+	if reporter, ok := r.m.(tfmigrate.Reporter); ok {
+		return reporter.Report()
+	}
+	return nil
+}
+
 // MigrationConfig returns an instance of migration.
 // This is required for metadata stored in history
 func (r *FileRunner) MigrationConfig() *tfmigrate.MigrationConfig {
@@ -92,5 +124,5 @@ func resolveMigrationFile(migrationDir string, filename string) string {
 	if filepath.IsAbs(filename) {
 		return filename
 	}
-	return filepath.Join(migrationDir, filename)
+	return filepath.Join(migrationDir, filepath.FromSlash(filename))
 }
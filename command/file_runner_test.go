@@ -165,3 +165,63 @@ migration "mock" "test" {
 		})
 	}
 }
+
+func TestFileRunnerRollback(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		ok     bool
+	}{
+		{
+			desc: "no error",
+			source: `
+migration "mock" "test" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+			ok: true,
+		},
+		{
+			desc: "rollback error",
+			source: `
+migration "mock" "test" {
+	plan_error     = false
+	apply_error    = false
+	rollback_error = true
+}
+`,
+			ok: false,
+		},
+		{
+			desc: "apply error",
+			source: `
+migration "mock" "test" {
+	plan_error  = false
+	apply_error = true
+}
+`,
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			path := setupMigrationFile(t, tc.source)
+
+			config := config.NewDefaultConfig()
+			r, err := NewFileRunner(path, config, nil)
+			if err != nil {
+				t.Fatalf("failed to new file runner: %s", err)
+			}
+
+			err = r.Rollback(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minamijoyo/tfmigrate/config"
+	"github.com/minamijoyo/tfmigrate/history"
+	flag "github.com/spf13/pflag"
+)
+
+// ForceUnlockCommand is a command which releases a stale lock of the history storage.
+type ForceUnlockCommand struct {
+	Meta
+}
+
+// Run runs the procedure of this command.
+func (c *ForceUnlockCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("force-unlock", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if len(cmdFlags.Args()) != 1 {
+		c.UI.Error(fmt.Sprintf("The command expects 1 argument, but got %d", len(cmdFlags.Args())))
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	out, err := forceUnlock(context.Background(), c.config, cmdFlags.Arg(0))
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// forceUnlock releases a lock of the history storage with a given lock ID.
+func forceUnlock(ctx context.Context, config *config.TfmigrateConfig, id string) (string, error) {
+	if err := history.ForceUnlock(ctx, config.History, id); err != nil {
+		return "", fmt.Errorf("failed to unlock: %v", err)
+	}
+
+	return fmt.Sprintf("unlock %s", id), nil
+}
+
+// Help returns long-form help text.
+func (c *ForceUnlockCommand) Help() string {
+	helpText := `
+Usage: tfmigrate force-unlock [options] LOCK_ID
+
+Manually release a lock of the history storage.
+This is intended to recover from a stale lock left by a crashed process.
+The lock ID is shown in the error message when failing to acquire a lock.
+Be careful not to release a lock held by a running apply.
+
+Arguments:
+  LOCK_ID            An ID of the lock to release
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *ForceUnlockCommand) Synopsis() string {
+	return "Release a stale lock of the history storage"
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/config"
+	"github.com/minamijoyo/tfmigrate/history"
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/mock"
+)
+
+func TestForceUnlock(t *testing.T) {
+	migrationDir := setupMigrationDir(t, map[string]string{})
+	mockConfig := &mock.Config{
+		Data: `{"version": 1, "records": {}}`,
+	}
+	config := &config.TfmigrateConfig{
+		MigrationDir: migrationDir,
+		History: &history.Config{
+			Storage: mockConfig,
+		},
+	}
+
+	if _, err := forceUnlock(context.Background(), config, "foo"); err == nil {
+		t.Fatal("expected to fail to unlock when not locked, but no error")
+	}
+
+	if _, err := history.Lock(context.Background(), config.History, "apply", 0); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	info, err := storage.ParseLockInfo(mockConfig.LockData())
+	if err != nil {
+		t.Fatalf("failed to parse lock info: %s", err)
+	}
+
+	if _, err := forceUnlock(context.Background(), config, "foo"); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	got, err := forceUnlock(context.Background(), config, info.ID)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	want := "unlock " + info.ID
+	if got != want {
+		t.Errorf("got: %s, want: %s", got, want)
+	}
+	if mockConfig.LockData() != nil {
+		t.Errorf("expected to release a lock, but got: %s", string(mockConfig.LockData()))
+	}
+}
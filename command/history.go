@@ -0,0 +1,828 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/config"
+	"github.com/minamijoyo/tfmigrate/history"
+	"github.com/mitchellh/cli"
+	flag "github.com/spf13/pflag"
+)
+
+// HistoryCommand is a parent command of history sub commands.
+type HistoryCommand struct {
+	Meta
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// Help returns long-form help text.
+func (c *HistoryCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history <subcommand> [options] [args]
+
+Inspect and fix migration history.
+This command requires a history block in the tfmigrate config file.
+
+Subcommands:
+  show            Show records in history
+  mark-applied    Add a record for a migration without applying it
+  unmark          Remove a record for a migration from history
+  prune           Remove records whose migration files no longer exist
+  repair          Accept new checksums of changed migration files
+  export          Export history to stdout
+  import          Import records from another history
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryCommand) Synopsis() string {
+	return "Inspect and fix migration history"
+}
+
+// loadHistoryConfig is a helper function which loads a config file for
+// history sub commands. It returns an error in non-history mode.
+func (c *Meta) loadHistoryConfig() error {
+	var err error
+	if c.config, err = newConfig(c.configFile); err != nil {
+		return fmt.Errorf("failed to load config file: %s", err)
+	}
+	if c.config, err = c.config.WithNamespace(c.namespace); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] [command] config: %#v\n", c.config)
+
+	c.Option = newOption(c.config)
+	// The option may contains sensitive values such as environment variables.
+	// So logging the option set log level to DEBUG instead of INFO.
+	log.Printf("[DEBUG] [command] option: %#v\n", c.Option)
+
+	if c.config.History == nil {
+		// non-history mode
+		return fmt.Errorf("no history setting")
+	}
+
+	return nil
+}
+
+// withHistoryLock runs a given function which updates history while holding
+// a lock of the history storage. The function is expected to load history
+// after the lock is acquired. In dry-run mode, history is never written and
+// the lock is not required. If lock is false, it runs the function without a
+// lock.
+func withHistoryLock(ctx context.Context, config *config.TfmigrateConfig, operation string, dryRun bool, lock bool, timeout time.Duration, f func() (string, error)) (out string, err error) {
+	if dryRun {
+		return f()
+	}
+	if !lock {
+		log.Print("[WARN] [command] locking is disabled\n")
+		return f()
+	}
+
+	unlock, err := history.Lock(ctx, config.History, operation, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = errors.Join(err, unlock(ctx))
+	}()
+
+	return f()
+}
+
+// dryRunPrefix returns a prefix of output message for dry-run mode.
+func dryRunPrefix(dryRun bool) string {
+	if dryRun {
+		return "(dry-run) "
+	}
+	return ""
+}
+
+// HistoryShowCommand is a command which shows records in history.
+type HistoryShowCommand struct {
+	Meta
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryShowCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history show", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	out, err := showHistory(context.Background(), c.config)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// showHistory returns records in history as a table sorted by filename.
+func showHistory(ctx context.Context, config *config.TfmigrateConfig) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	records := hc.Records()
+	filenames := make([]string, 0, len(records))
+	for filename := range records {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILENAME\tTYPE\tNAME\tAPPLIED_AT")
+	for _, filename := range filenames {
+		r := records[filename]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", filename, r.Type, r.Name, r.AppliedAt.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryShowCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history show
+
+Show records in history with type, name and applied_at.
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryShowCommand) Synopsis() string {
+	return "Show records in history"
+}
+
+// HistoryMarkAppliedCommand is a command which adds a record for a migration
+// without applying it.
+type HistoryMarkAppliedCommand struct {
+	Meta
+	dryRun      bool
+	lock        bool
+	lockTimeout time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryMarkAppliedCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history mark-applied", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Show what would be changed without updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage while updating history")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if len(cmdFlags.Args()) != 1 {
+		c.UI.Error(fmt.Sprintf("The command expects 1 argument, but got %d", len(cmdFlags.Args())))
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	ctx := context.Background()
+	out, err := withHistoryLock(ctx, c.config, "mark-applied", c.dryRun, c.lock, c.lockTimeout, func() (string, error) {
+		return markApplied(ctx, c.config, cmdFlags.Arg(0), c.Version, c.dryRun)
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// markApplied adds a record for a given migration file to history and saves it.
+// The type and name of the record are read from the migration file.
+// The record has minimal metadata without a report, because the migration
+// is not applied by tfmigrate.
+func markApplied(ctx context.Context, config *config.TfmigrateConfig, filename string, version string, dryRun bool) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	if !contains(hc.Migrations(), filename) {
+		return "", fmt.Errorf("migration file not found in migration dir: %s", filename)
+	}
+
+	if hc.AlreadyApplied(filename) {
+		return "", fmt.Errorf("a migration has already been applied: %s", filename)
+	}
+
+	mc, err := loadMigrationFile(resolveMigrationFile(config.MigrationDir, filename))
+	if err != nil {
+		return "", err
+	}
+
+	out := fmt.Sprintf("%smark %s as applied", dryRunPrefix(dryRun), filename)
+	if dryRun {
+		return out, nil
+	}
+
+	metadata := newHistoryMetadata(ctx, nil, config.MigrationDir, version, 0)
+	hc.AddRecordWithMetadata(filename, mc.Type, mc.Name, nil, metadata)
+	if err := hc.Save(ctx); err != nil {
+		return "", fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return out, nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryMarkAppliedCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history mark-applied [PATH]
+
+Add a record for a migration to history without applying it.
+This is useful when a migration has already been done by hand.
+
+Arguments:
+  PATH               A name of migration file in the migration dir
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --dry-run          Show what would be changed without updating history
+  --lock=true        Lock the history storage while updating history
+  --lock-timeout=0s  A duration to retry acquiring a lock
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryMarkAppliedCommand) Synopsis() string {
+	return "Add a record for a migration without applying it"
+}
+
+// HistoryUnmarkCommand is a command which removes a record for a migration
+// from history.
+type HistoryUnmarkCommand struct {
+	Meta
+	dryRun      bool
+	lock        bool
+	lockTimeout time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryUnmarkCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history unmark", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Show what would be changed without updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage while updating history")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if len(cmdFlags.Args()) != 1 {
+		c.UI.Error(fmt.Sprintf("The command expects 1 argument, but got %d", len(cmdFlags.Args())))
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	ctx := context.Background()
+	out, err := withHistoryLock(ctx, c.config, "unmark", c.dryRun, c.lock, c.lockTimeout, func() (string, error) {
+		return unmarkApplied(ctx, c.config, cmdFlags.Arg(0), c.dryRun)
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// unmarkApplied removes a record for a given migration file from history and saves it.
+// Note that the migration file doesn't need to exist in the migration dir.
+func unmarkApplied(ctx context.Context, config *config.TfmigrateConfig, filename string, dryRun bool) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	if !hc.AlreadyApplied(filename) {
+		return "", fmt.Errorf("no record found in history: %s", filename)
+	}
+
+	out := fmt.Sprintf("%sunmark %s", dryRunPrefix(dryRun), filename)
+	if dryRun {
+		return out, nil
+	}
+
+	hc.DeleteRecord(filename)
+	if err := hc.Save(ctx); err != nil {
+		return "", fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return out, nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryUnmarkCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history unmark [PATH]
+
+Remove a record for a migration from history without reverting it.
+The migration will be treated as unapplied.
+
+Arguments:
+  PATH               A name of migration file recorded in history
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --dry-run          Show what would be changed without updating history
+  --lock=true        Lock the history storage while updating history
+  --lock-timeout=0s  A duration to retry acquiring a lock
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryUnmarkCommand) Synopsis() string {
+	return "Remove a record for a migration from history"
+}
+
+// HistoryPruneCommand is a command which removes records whose migration files
+// no longer exist.
+type HistoryPruneCommand struct {
+	Meta
+	dryRun      bool
+	lock        bool
+	lockTimeout time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryPruneCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history prune", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Show what would be changed without updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage while updating history")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	ctx := context.Background()
+	out, err := withHistoryLock(ctx, c.config, "prune", c.dryRun, c.lock, c.lockTimeout, func() (string, error) {
+		return pruneHistory(ctx, c.config, c.dryRun)
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// pruneHistory removes records whose migration files don't exist in the
+// migration dir from history and saves it.
+func pruneHistory(ctx context.Context, config *config.TfmigrateConfig, dryRun bool) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	missing := hc.MissingMigrations()
+	if len(missing) == 0 {
+		return "no records to prune", nil
+	}
+
+	lines := []string{}
+	for _, filename := range missing {
+		lines = append(lines, fmt.Sprintf("%sprune %s", dryRunPrefix(dryRun), filename))
+	}
+	out := strings.Join(lines, "\n")
+	if dryRun {
+		return out, nil
+	}
+
+	for _, filename := range missing {
+		hc.DeleteRecord(filename)
+	}
+	if err := hc.Save(ctx); err != nil {
+		return "", fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return out, nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryPruneCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history prune
+
+Remove records from history whose migration files no longer exist
+in the migration dir.
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --dry-run          Show what would be changed without updating history
+  --lock=true        Lock the history storage while updating history
+  --lock-timeout=0s  A duration to retry acquiring a lock
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryPruneCommand) Synopsis() string {
+	return "Remove records whose migration files no longer exist"
+}
+
+// HistoryRepairCommand is a command which accepts new checksums of changed
+// migration files.
+type HistoryRepairCommand struct {
+	Meta
+	dryRun      bool
+	lock        bool
+	lockTimeout time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryRepairCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history repair", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Show what would be changed without updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage while updating history")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	ctx := context.Background()
+	out, err := withHistoryLock(ctx, c.config, "repair", c.dryRun, c.lock, c.lockTimeout, func() (string, error) {
+		return repairHistory(ctx, c.config, c.dryRun)
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// repairHistory updates checksums of applied migrations to match the current
+// migration files and saves history. Records without checksum are also filled.
+// Records whose migration files don't exist are left as they are.
+func repairHistory(ctx context.Context, config *config.TfmigrateConfig, dryRun bool) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	records := hc.Records()
+	checksums := make(map[string]string)
+	lines := []string{}
+	for _, filename := range hc.Migrations() {
+		r, ok := records[filename]
+		if !ok {
+			continue
+		}
+
+		source, err := os.ReadFile(resolveMigrationFile(config.MigrationDir, filename))
+		if err != nil {
+			return "", err
+		}
+
+		checksum := history.Checksum(source)
+		if r.Checksum == checksum {
+			continue
+		}
+		checksums[filename] = checksum
+		lines = append(lines, fmt.Sprintf("%srepair %s", dryRunPrefix(dryRun), filename))
+	}
+
+	if len(checksums) == 0 {
+		return "no records to repair", nil
+	}
+
+	out := strings.Join(lines, "\n")
+	if dryRun {
+		return out, nil
+	}
+
+	for filename, checksum := range checksums {
+		hc.SetChecksum(filename, checksum)
+	}
+	if err := hc.Save(ctx); err != nil {
+		return "", fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return out, nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryRepairCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history repair
+
+Accept new checksums of applied migration files which have been changed.
+A checksum of migration file is recorded in history when applied,
+and plan or apply fails if an applied migration file has been changed.
+Records without checksum are also filled.
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --dry-run          Show what would be changed without updating history
+  --lock=true        Lock the history storage while updating history
+  --lock-timeout=0s  A duration to retry acquiring a lock
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryRepairCommand) Synopsis() string {
+	return "Accept new checksums of changed migration files"
+}
+
+// HistoryExportCommand is a command which exports history to stdout.
+type HistoryExportCommand struct {
+	Meta
+	fileVersion int
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryExportCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history export", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.IntVar(&c.fileVersion, "file-version", 2, "A format version of history file")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	out, err := exportHistory(context.Background(), c.config, c.fileVersion)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// exportHistory returns history as a history file in a given format version.
+// Note that it is always plaintext even if the encryption is enabled.
+func exportHistory(ctx context.Context, config *config.TfmigrateConfig, fileVersion int) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := hc.Export(fileVersion)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryExportCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history export
+
+Export history as a history file to stdout.
+The output is always plaintext even if the encryption is enabled.
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --file-version     A format version of history file (default: 2)
+                     The version 1 doesn't have checksums and metadata.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryExportCommand) Synopsis() string {
+	return "Export history to stdout"
+}
+
+// HistoryImportCommand is a command which imports records from another
+// history into the current history.
+type HistoryImportCommand struct {
+	Meta
+	fromConfig  string
+	fromFile    string
+	dryRun      bool
+	lock        bool
+	lockTimeout time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *HistoryImportCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("history import", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.StringVar(&c.fromConfig, "from-config", "", "A path to tfmigrate config file of the source history")
+	cmdFlags.StringVar(&c.fromFile, "from-file", "", "A path to a history file of the source history")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Show what would be changed without updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage while updating history")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	if (c.fromConfig == "") == (c.fromFile == "") {
+		c.UI.Error("either --from-config or --from-file is required")
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := c.loadHistoryConfig(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	ctx := context.Background()
+	src, err := loadSourceHistory(ctx, c.fromConfig, c.fromFile, c.namespace)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	out, err := withHistoryLock(ctx, c.config, "import", c.dryRun, c.lock, c.lockTimeout, func() (string, error) {
+		return importHistory(ctx, c.config, src, c.dryRun)
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.UI.Output(out)
+	return 0
+}
+
+// loadSourceHistory loads a history to import from either a storage of
+// another config file or a history file. A file name "-" means stdin.
+// The namespace is also selected in another config file, so that history is
+// moved between the same namespaces.
+func loadSourceHistory(ctx context.Context, fromConfig string, fromFile string, namespace string) (*history.History, error) {
+	if fromConfig != "" {
+		src, err := config.LoadConfigurationFile(fromConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file: %s", err)
+		}
+		if src, err = src.WithNamespace(namespace); err != nil {
+			return nil, fmt.Errorf("failed to select namespace in %s: %s", fromConfig, err)
+		}
+		if src.History == nil {
+			return nil, fmt.Errorf("no history setting in %s", fromConfig)
+		}
+		return history.LoadHistory(ctx, src.History.Storage)
+	}
+
+	var b []byte
+	var err error
+	if fromFile == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(fromFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %s", err)
+	}
+	return history.ParseHistoryFile(b)
+}
+
+// importHistory merges records of a given history into the current history
+// and saves it. If the same migration is recorded with a different name or
+// applied_at in both, it returns an error without updating history.
+func importHistory(ctx context.Context, config *config.TfmigrateConfig, src *history.History, dryRun bool) (string, error) {
+	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
+	if err != nil {
+		return "", err
+	}
+
+	imported, err := hc.Import(src)
+	if err != nil {
+		return "", err
+	}
+
+	if len(imported) == 0 {
+		return "no records to import", nil
+	}
+
+	lines := []string{}
+	for _, filename := range imported {
+		lines = append(lines, fmt.Sprintf("%simport %s", dryRunPrefix(dryRun), filename))
+	}
+	out := strings.Join(lines, "\n")
+	if dryRun {
+		return out, nil
+	}
+
+	if err := hc.Save(ctx); err != nil {
+		return "", fmt.Errorf("failed to save history: %v", err)
+	}
+
+	return out, nil
+}
+
+// Help returns long-form help text.
+func (c *HistoryImportCommand) Help() string {
+	helpText := `
+Usage: tfmigrate history import (--from-config PATH | --from-file PATH)
+
+Import records from another history into the current history.
+It is useful for moving history between storages.
+Records which already exist are skipped. If the same migration is recorded
+with a different name or applied_at, it fails without updating history.
+
+Options:
+  --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
+  --from-config      A path to tfmigrate config file of the source history,
+                     whose namespace is also selected by --namespace.
+  --from-file        A path to a history file of the source history,
+                     such as an output of tfmigrate history export.
+                     Use - to read from stdin.
+  --dry-run          Show what would be changed without updating history
+  --lock=true        Lock the history storage while updating history
+  --lock-timeout=0s  A duration to retry acquiring a lock
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *HistoryImportCommand) Synopsis() string {
+	return "Import records from another history"
+}
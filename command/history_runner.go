@@ -2,11 +2,13 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/minamijoyo/tfmigrate/config"
 	"github.com/minamijoyo/tfmigrate/history"
@@ -26,6 +28,12 @@ type HistoryRunner struct {
 	option *tfmigrate.MigratorOption
 	// A controller which manages history.
 	hc *history.Controller
+	// A version of tfmigrate recorded in history.
+	version string
+	// A flag to lock the history storage while updating history.
+	lock bool
+	// A duration to wait for acquiring a lock.
+	lockTimeout time.Duration
 }
 
 // NewHistoryRunner returns a new HistoryRunner instance.
@@ -40,11 +48,36 @@ func NewHistoryRunner(ctx context.Context, filename string, config *config.Tfmig
 		config:   config,
 		option:   option,
 		hc:       hc,
+		lock:     true,
 	}
 
 	return r, nil
 }
 
+// acquireLock acquires a lock of the history storage for a given operation
+// and reloads history, because it may have been updated while waiting for
+// the lock. It returns a function to release the lock.
+// If locking is disabled, it returns a no-op function.
+func (r *HistoryRunner) acquireLock(ctx context.Context, operation string) (func(context.Context) error, error) {
+	if !r.lock {
+		log.Print("[WARN] [runner] locking is disabled\n")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	unlock, err := history.Lock(ctx, r.config.History, operation, r.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	hc, err := history.NewController(ctx, r.config.MigrationDir, r.config.History)
+	if err != nil {
+		return nil, errors.Join(err, unlock(ctx))
+	}
+	r.hc = hc
+
+	return unlock, nil
+}
+
 // Plan plans migrations with history-aware mode.
 // If a filename is set, run a single migration.
 // If not set, run all unapplied migrations.
@@ -103,6 +136,16 @@ func (r *HistoryRunner) planDir(ctx context.Context) error {
 // If a filename is set, run a single migration.
 // If not set, run all unapplied migrations.
 func (r *HistoryRunner) Apply(ctx context.Context) (err error) {
+	// hold a lock during apply to prevent concurrent updates of history
+	unlock, err := r.acquireLock(ctx, "apply")
+	if err != nil {
+		return err
+	}
+	// deferred functions run in reverse order, so the lock is released after saving history.
+	defer func() {
+		err = errors.Join(err, unlock(ctx))
+	}()
+
 	// save history on exit
 	beforeLen := r.hc.HistoryLength()
 	defer func() {
@@ -159,15 +202,18 @@ func (r *HistoryRunner) applyFile(ctx context.Context, filename string) error {
 		return err
 	}
 
+	start := time.Now()
 	err = fr.Apply(ctx)
 	if err != nil {
 		log.Printf("[ERROR] [runner] failed to apply: %s\n", filename)
 		return err
 	}
+	duration := time.Since(start)
 
 	mc := fr.MigrationConfig()
+	metadata := newHistoryMetadata(ctx, fr.Report(), r.config.MigrationDir, r.version, duration)
 	log.Printf("[INFO] [runner] add a record to history: %s\n", filename)
-	r.hc.AddRecord(filename, mc.Type, mc.Name, nil)
+	r.hc.AddRecordWithMetadata(filename, mc.Type, mc.Name, nil, metadata)
 
 	return nil
 }
@@ -192,14 +238,104 @@ func (r *HistoryRunner) applyDir(ctx context.Context) (err error) {
 	return nil
 }
 
+// PlanRollback plans a rollback of a migration with history-aware mode.
+// If a filename is set, plan a rollback of the given migration.
+// If not set, plan a rollback of the most recently applied migration.
+func (r *HistoryRunner) PlanRollback(ctx context.Context) error {
+	filename, err := r.rollbackTarget()
+	if err != nil {
+		return err
+	}
+
+	fr, err := NewFileRunner(filename, r.config, r.option)
+	if err != nil {
+		log.Printf("[ERROR] [runner] failed to plan rollback: %s\n", filename)
+		return err
+	}
+
+	log.Printf("[INFO] [runner] plan rollback: %s\n", filename)
+	return fr.PlanRollback(ctx)
+}
+
+// Rollback reverts a migration and removes it from history.
+// If a filename is set, revert the given migration.
+// If not set, revert the most recently applied migration.
+func (r *HistoryRunner) Rollback(ctx context.Context) (err error) {
+	// hold a lock during rollback to prevent concurrent updates of history
+	unlock, err := r.acquireLock(ctx, "rollback")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, unlock(ctx))
+	}()
+
+	filename, err := r.rollbackTarget()
+	if err != nil {
+		return err
+	}
+
+	fr, err := NewFileRunner(filename, r.config, r.option)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] [runner] rollback: %s\n", filename)
+	err = fr.Rollback(ctx)
+	if err != nil {
+		log.Printf("[ERROR] [runner] failed to rollback: %s\n", filename)
+		return err
+	}
+
+	log.Printf("[INFO] [runner] delete a record from history: %s\n", filename)
+	r.hc.DeleteRecord(filename)
+
+	log.Print("[INFO] [runner] save history\n")
+	err = r.hc.Save(ctx)
+	if err != nil {
+		log.Printf("[ERROR] [runner] failed to save history. The history may be inconsistent\n")
+		return fmt.Errorf("rollback succeed, but failed to save history: %v", err)
+	}
+	log.Print("[INFO] [runner] history saved\n")
+
+	return nil
+}
+
+// rollbackTarget returns a migration file name to be reverted.
+func (r *HistoryRunner) rollbackTarget() (string, error) {
+	filename := r.filename
+	if len(filename) == 0 {
+		latest, ok := r.hc.LatestAppliedMigration()
+		if !ok {
+			return "", fmt.Errorf("no applied migrations to rollback")
+		}
+		filename = latest
+	}
+
+	if !r.hc.AlreadyApplied(filename) {
+		return "", fmt.Errorf("a migration has not been applied yet: %s", filename)
+	}
+
+	if !contains(r.hc.Migrations(), filename) {
+		return "", fmt.Errorf("a migration file to rollback is not found in migration dir: %s", filename)
+	}
+
+	return filename, nil
+}
+
 // validateNoDuplicates validates that there are no duplicate migrations by name.
 // It checks for duplicates in:
 // 1. Local migration files (same migration name in different files)
 // 2. Remote state vs local migrations (migration name already exists in history)
 // Returns an error if duplicates are found.
+// Since it reads all migration files anyway, it also verifies checksums of
+// applied migration files in the same scan. See also verifyChecksums.
 func (r *HistoryRunner) validateNoDuplicates(ctx context.Context) error {
 	// Load all migration files and extract their names
 	localMigrationNames := make(map[string][]string) // migration name -> list of files containing it
+	// A list of applied migration files whose checksum has changed
+	var checksumMismatches []string
+	historyRecords := r.hc.Records()
 
 	for _, filename := range r.hc.Migrations() {
 		path := filepath.Join(r.config.MigrationDir, filename)
@@ -210,6 +346,11 @@ func (r *HistoryRunner) validateNoDuplicates(ctx context.Context) error {
 			return fmt.Errorf("failed to read migration file %s: %v", filename, err)
 		}
 
+		// Records without checksum such as ones upgraded from the history file v1 are skipped.
+		if record, ok := historyRecords[filename]; ok && record.Checksum != "" && record.Checksum != history.Checksum(source) {
+			checksumMismatches = append(checksumMismatches, filename)
+		}
+
 		mc, err := config.ParseMigrationFile(filename, source)
 		if err != nil {
 			return fmt.Errorf("failed to parse migration file %s: %v", filename, err)
@@ -233,7 +374,6 @@ func (r *HistoryRunner) validateNoDuplicates(ctx context.Context) error {
 
 	// Check for remote duplicates (migration name already exists in history)
 	var remoteDuplicates []string
-	historyRecords := r.hc.Records()
 	for migrationName, files := range localMigrationNames {
 		// Check if any record in history has the same migration name
 		for historyFilename, record := range historyRecords {
@@ -247,7 +387,26 @@ func (r *HistoryRunner) validateNoDuplicates(ctx context.Context) error {
 		return fmt.Errorf("duplicate migration names found in remote state:\n  %s", strings.Join(remoteDuplicates, "\n  "))
 	}
 
-	return nil
+	return r.verifyChecksums(checksumMismatches)
+}
+
+// verifyChecksums reports applied migration files whose checksum has changed.
+// It returns an error by default, or just logs a warning if the history
+// config allows checksum mismatches.
+func (r *HistoryRunner) verifyChecksums(mismatches []string) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	if r.config.History.ChecksumMismatch == history.ChecksumMismatchWarn {
+		for _, filename := range mismatches {
+			log.Printf("[WARN] [runner] an applied migration file has been changed: %s\n", filename)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("applied migration files have been changed:\n  %s\n"+
+		"If the changes are intended, run `tfmigrate history repair` to accept the new checksums", strings.Join(mismatches, "\n  "))
 }
 
 // contains checks if a slice contains a specific string
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -242,7 +243,7 @@ migration "mock" "test4" {
 				t.Fatalf("failed to parse history file (got): %s", err)
 			}
 			recordObj := history.Record{}
-			if diff := cmp.Diff(*got, *want, cmp.AllowUnexported(*got), cmpopts.IgnoreFields(recordObj, "AppliedAt")); diff != "" {
+			if diff := cmp.Diff(*got, *want, cmp.AllowUnexported(*got), cmpopts.IgnoreFields(recordObj, "AppliedAt", "Checksum", "Metadata")); diff != "" {
 				t.Errorf("got = %#v, want = %#v, diff = %s", got, want, diff)
 			}
 		})
@@ -747,13 +748,120 @@ migration "mock" "test4" {
 				t.Fatalf("failed to parse history file (got): %s", err)
 			}
 			recordObj := history.Record{}
-			if diff := cmp.Diff(*got, *want, cmp.AllowUnexported(*got), cmpopts.IgnoreFields(recordObj, "AppliedAt")); diff != "" {
+			if diff := cmp.Diff(*got, *want, cmp.AllowUnexported(*got), cmpopts.IgnoreFields(recordObj, "AppliedAt", "Checksum", "Metadata")); diff != "" {
 				t.Errorf("got = %#v, want = %#v, diff = %s", got, want, diff)
 			}
 		})
 	}
 }
 
+func TestHistoryRunnerApplyRecordsMetadata(t *testing.T) {
+	t.Setenv("TFMIGRATE_APPLIED_BY", "ci-job-123")
+
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+	migrationDir := setupMigrationDir(t, migrations)
+	mockConfig := &mock.Config{
+		Data: `{"version": 1, "records": {}}`,
+	}
+	config := &config.TfmigrateConfig{
+		MigrationDir: migrationDir,
+		History: &history.Config{
+			Storage: mockConfig,
+		},
+	}
+	r, err := NewHistoryRunner(context.Background(), "", config, nil)
+	if err != nil {
+		t.Fatalf("failed to new history runner: %s", err)
+	}
+	r.version = "0.4.2"
+
+	err = r.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	data := mockConfig.Storage().Data()
+	if !strings.Contains(data, `"version": 2`) {
+		t.Errorf("expected history file to be upgraded to v2, got: %s", data)
+	}
+	hc, err := history.NewController(context.Background(), migrationDir, &history.Config{Storage: &mock.Config{Data: data}})
+	if err != nil {
+		t.Fatalf("failed to load history: %s", err)
+	}
+	metadata := hc.Records()["20201109000001_test1.hcl"].Metadata
+	if metadata == nil {
+		t.Fatalf("expected metadata to be recorded, got: %#v", hc.Records())
+	}
+	if metadata.AppliedBy != "ci-job-123" {
+		t.Errorf("got applied_by = %s, want = %s", metadata.AppliedBy, "ci-job-123")
+	}
+	if metadata.TfmigrateVersion != "0.4.2" {
+		t.Errorf("got tfmigrate_version = %s, want = %s", metadata.TfmigrateVersion, "0.4.2")
+	}
+}
+
+func TestHistoryRunnerApplyLocked(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+	migrationDir := setupMigrationDir(t, migrations)
+	mockConfig := &mock.Config{
+		Data: `{"version": 1, "records": {}}`,
+	}
+	config := &config.TfmigrateConfig{
+		MigrationDir: migrationDir,
+		History: &history.Config{
+			Storage: mockConfig,
+		},
+	}
+
+	// simulate a concurrent apply holding the lock
+	unlock, err := history.Lock(context.Background(), config.History, "apply", 0)
+	if err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	r, err := NewHistoryRunner(context.Background(), "", config, nil)
+	if err != nil {
+		t.Fatalf("failed to new history runner: %s", err)
+	}
+
+	err = r.Apply(context.Background())
+	if err == nil {
+		t.Fatal("expected to fail to apply while locked, but no error")
+	}
+	if r.hc.AlreadyApplied("20201109000001_test1.hcl") {
+		t.Error("expected not to apply a migration while locked")
+	}
+
+	if err := unlock(context.Background()); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+
+	err = r.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !r.hc.AlreadyApplied("20201109000001_test1.hcl") {
+		t.Error("expected to apply a migration after unlock")
+	}
+	if mockConfig.LockData() != nil {
+		t.Errorf("expected to release a lock after apply, but got: %s", string(mockConfig.LockData()))
+	}
+}
+
 func TestHistoryRunnerValidateDuplicateMigrations(t *testing.T) {
 	cases := []struct {
 		desc        string
@@ -1120,3 +1228,306 @@ migration "mock" "test1" {
 		})
 	}
 }
+
+func TestHistoryRunnerRollback(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+		"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+		"20201109000003_test3.hcl": `
+migration "mock" "test3" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+	historyFile := `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`
+
+	cases := []struct {
+		desc        string
+		migrations  map[string]string
+		historyFile string
+		filename    string
+		dryRun      bool
+		writeError  bool
+		want        string
+		ok          bool
+	}{
+		{
+			desc:        "no args",
+			migrations:  migrations,
+			historyFile: historyFile,
+			filename:    "",
+			want: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`,
+			ok: true,
+		},
+		{
+			desc:        "a filename is given",
+			migrations:  migrations,
+			historyFile: historyFile,
+			filename:    "20201109000001_test1.hcl",
+			want: `{
+    "version": 1,
+    "records": {
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`,
+			ok: true,
+		},
+		{
+			desc:        "dry run",
+			migrations:  migrations,
+			historyFile: historyFile,
+			filename:    "",
+			dryRun:      true,
+			want:        historyFile,
+			ok:          true,
+		},
+		{
+			desc:        "a migration has not been applied yet",
+			migrations:  migrations,
+			historyFile: historyFile,
+			filename:    "20201109000003_test3.hcl",
+			want:        historyFile,
+			ok:          false,
+		},
+		{
+			desc:        "no applied migrations",
+			migrations:  migrations,
+			historyFile: `{"version": 1, "records": {}}`,
+			filename:    "",
+			want:        `{"version": 1, "records": {}}`,
+			ok:          false,
+		},
+		{
+			desc: "a migration file is not found",
+			migrations: map[string]string{
+				"20201109000001_test1.hcl": migrations["20201109000001_test1.hcl"],
+			},
+			historyFile: historyFile,
+			filename:    "",
+			want:        historyFile,
+			ok:          false,
+		},
+		{
+			desc: "not invertible",
+			migrations: map[string]string{
+				"20201109000001_test1.hcl": migrations["20201109000001_test1.hcl"],
+				"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error     = false
+	apply_error    = false
+	rollback_error = true
+}
+`,
+			},
+			historyFile: historyFile,
+			filename:    "",
+			want:        historyFile,
+			ok:          false,
+		},
+		{
+			desc: "apply error",
+			migrations: map[string]string{
+				"20201109000001_test1.hcl": migrations["20201109000001_test1.hcl"],
+				"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = true
+}
+`,
+			},
+			historyFile: historyFile,
+			filename:    "",
+			want:        historyFile,
+			ok:          false,
+		},
+		{
+			desc:        "write error",
+			migrations:  migrations,
+			historyFile: historyFile,
+			filename:    "",
+			writeError:  true,
+			want:        historyFile,
+			ok:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, tc.migrations)
+			mockConfig := &mock.Config{
+				Data:       tc.historyFile,
+				WriteError: tc.writeError,
+				ReadError:  false,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: mockConfig,
+				},
+			}
+			r, err := NewHistoryRunner(context.Background(), tc.filename, config, nil)
+			if err != nil {
+				t.Fatalf("failed to new history runner: %s", err)
+			}
+
+			if tc.dryRun {
+				err = r.PlanRollback(context.Background())
+			} else {
+				err = r.Rollback(context.Background())
+			}
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			want, err := history.ParseHistoryFile([]byte(tc.want))
+			if err != nil {
+				t.Fatalf("failed to parse history file (want): %s", err)
+			}
+			data := mockConfig.Storage().Data()
+			got, err := history.ParseHistoryFile([]byte(data))
+			if err != nil {
+				t.Fatalf("failed to parse history file (got): %s", err)
+			}
+			if diff := cmp.Diff(*got, *want, cmp.AllowUnexported(*got)); diff != "" {
+				t.Errorf("got = %#v, want = %#v, diff = %s", got, want, diff)
+			}
+		})
+	}
+}
+
+func TestHistoryRunnerPlanChecksumMismatch(t *testing.T) {
+	source1 := `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": source1,
+		"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+
+	cases := []struct {
+		desc             string
+		checksum         string
+		checksumMismatch string
+		ok               bool
+	}{
+		{
+			desc:             "match",
+			checksum:         history.Checksum([]byte(source1)),
+			checksumMismatch: "",
+			ok:               true,
+		},
+		{
+			desc:             "no checksum",
+			checksum:         "",
+			checksumMismatch: "",
+			ok:               true,
+		},
+		{
+			desc:             "mismatch (default)",
+			checksum:         "sha256:changed",
+			checksumMismatch: "",
+			ok:               false,
+		},
+		{
+			desc:             "mismatch (error)",
+			checksum:         "sha256:changed",
+			checksumMismatch: history.ChecksumMismatchError,
+			ok:               false,
+		},
+		{
+			desc:             "mismatch (warn)",
+			checksum:         "sha256:changed",
+			checksumMismatch: history.ChecksumMismatchWarn,
+			ok:               true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, migrations)
+			historyFile := fmt.Sprintf(`{
+    "version": 2,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z",
+            "checksum": "%s"
+        }
+    }
+}`, tc.checksum)
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage:          &mock.Config{Data: historyFile},
+					ChecksumMismatch: tc.checksumMismatch,
+				},
+			}
+			r, err := NewHistoryRunner(context.Background(), "", config, nil)
+			if err != nil {
+				t.Fatalf("failed to new history runner: %s", err)
+			}
+
+			err = r.Plan(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok {
+				if err == nil {
+					t.Fatal("expected to return an error, but no error")
+				}
+				if !strings.Contains(err.Error(), "20201109000001_test1.hcl") {
+					t.Errorf("expected error to contain a changed file, but got: %s", err)
+				}
+			}
+		})
+	}
+}
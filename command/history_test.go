@@ -0,0 +1,1008 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/config"
+	"github.com/minamijoyo/tfmigrate/history"
+	"github.com/minamijoyo/tfmigrate/storage/mock"
+)
+
+func TestShowHistory(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+
+	cases := []struct {
+		desc        string
+		migrations  map[string]string
+		historyFile string
+		want        string
+		ok          bool
+	}{
+		{
+			desc:       "simple",
+			migrations: migrations,
+			historyFile: `{
+    "version": 1,
+    "records": {
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        },
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`,
+			want: `FILENAME                  TYPE  NAME   APPLIED_AT
+20201109000001_test1.hcl  mock  test1  2020-11-10T00:00:01Z
+20201109000002_test2.hcl  mock  test2  2020-11-10T00:00:02Z`,
+			ok: true,
+		},
+		{
+			desc:        "empty",
+			migrations:  migrations,
+			historyFile: "",
+			want:        `FILENAME  TYPE  NAME  APPLIED_AT`,
+			ok:          true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, tc.migrations)
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: &mock.Config{
+						Data: tc.historyFile,
+					},
+				},
+			}
+			got, err := showHistory(context.Background(), config)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMarkApplied(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+		"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+	historyFile := `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`
+
+	cases := []struct {
+		desc        string
+		filename    string
+		dryRun      bool
+		writeError  bool
+		want        string
+		wantApplied []string
+		ok          bool
+	}{
+		{
+			desc:        "simple",
+			filename:    "20201109000002_test2.hcl",
+			dryRun:      false,
+			want:        "mark 20201109000002_test2.hcl as applied",
+			wantApplied: []string{"20201109000001_test1.hcl", "20201109000002_test2.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "dry-run",
+			filename:    "20201109000002_test2.hcl",
+			dryRun:      true,
+			want:        "(dry-run) mark 20201109000002_test2.hcl as applied",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "already applied",
+			filename:    "20201109000001_test1.hcl",
+			dryRun:      false,
+			want:        "",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          false,
+		},
+		{
+			desc:        "not found",
+			filename:    "20201109000003_test3.hcl",
+			dryRun:      false,
+			want:        "",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          false,
+		},
+		{
+			desc:        "write error",
+			filename:    "20201109000002_test2.hcl",
+			dryRun:      false,
+			writeError:  true,
+			want:        "",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, migrations)
+			storage := &mock.Config{
+				Data:       historyFile,
+				WriteError: tc.writeError,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: storage,
+				},
+			}
+			got, err := markApplied(context.Background(), config, tc.filename, "0.0.1", tc.dryRun)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+			assertAppliedMigrations(t, migrationDir, storage, tc.wantApplied)
+			if tc.ok && !tc.dryRun {
+				hc, err := history.NewController(context.Background(), migrationDir, &history.Config{
+					Storage: &mock.Config{Data: storage.Storage().Data()},
+				})
+				if err != nil {
+					t.Fatalf("failed to load history: %s", err)
+				}
+				metadata := hc.Records()[tc.filename].Metadata
+				if metadata == nil {
+					t.Fatalf("expected to record metadata, but got nil")
+				}
+				if metadata.TfmigrateVersion != "0.0.1" {
+					t.Errorf("got tfmigrate version = %s, want = %s", metadata.TfmigrateVersion, "0.0.1")
+				}
+				if metadata.AppliedBy == "" {
+					t.Errorf("expected to record applied by, but got empty")
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarkApplied(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+	historyFile := `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`
+
+	cases := []struct {
+		desc        string
+		filename    string
+		dryRun      bool
+		want        string
+		wantApplied []string
+		ok          bool
+	}{
+		{
+			desc:        "simple",
+			filename:    "20201109000001_test1.hcl",
+			dryRun:      false,
+			want:        "unmark 20201109000001_test1.hcl",
+			wantApplied: []string{"20201109000002_test2.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "missing migration file",
+			filename:    "20201109000002_test2.hcl",
+			dryRun:      false,
+			want:        "unmark 20201109000002_test2.hcl",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "dry-run",
+			filename:    "20201109000001_test1.hcl",
+			dryRun:      true,
+			want:        "(dry-run) unmark 20201109000001_test1.hcl",
+			wantApplied: []string{"20201109000001_test1.hcl", "20201109000002_test2.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "not recorded",
+			filename:    "20201109000003_test3.hcl",
+			dryRun:      false,
+			want:        "",
+			wantApplied: []string{"20201109000001_test1.hcl", "20201109000002_test2.hcl"},
+			ok:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, migrations)
+			storage := &mock.Config{
+				Data: historyFile,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: storage,
+				},
+			}
+			got, err := unmarkApplied(context.Background(), config, tc.filename, tc.dryRun)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+			assertAppliedMigrations(t, migrationDir, storage, tc.wantApplied)
+		})
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	migrations := map[string]string{
+		"20201109000002_test2.hcl": `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = false
+}
+`,
+	}
+
+	cases := []struct {
+		desc        string
+		historyFile string
+		dryRun      bool
+		want        string
+		wantApplied []string
+		ok          bool
+	}{
+		{
+			desc: "simple",
+			historyFile: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        },
+        "20201109000003_test3.hcl": {
+            "type": "mock",
+            "name": "test3",
+            "applied_at": "2020-11-10T00:00:03Z"
+        }
+    }
+}`,
+			dryRun: false,
+			want: `prune 20201109000001_test1.hcl
+prune 20201109000003_test3.hcl`,
+			wantApplied: []string{"20201109000002_test2.hcl"},
+			ok:          true,
+		},
+		{
+			desc: "dry-run",
+			historyFile: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`,
+			dryRun:      true,
+			want:        `(dry-run) prune 20201109000001_test1.hcl`,
+			wantApplied: []string{"20201109000001_test1.hcl", "20201109000002_test2.hcl"},
+			ok:          true,
+		},
+		{
+			desc: "nothing to prune",
+			historyFile: `{
+    "version": 1,
+    "records": {
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`,
+			dryRun:      false,
+			want:        `no records to prune`,
+			wantApplied: []string{"20201109000002_test2.hcl"},
+			ok:          true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, migrations)
+			storage := &mock.Config{
+				Data: tc.historyFile,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: storage,
+				},
+			}
+			got, err := pruneHistory(context.Background(), config, tc.dryRun)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+			assertAppliedMigrations(t, migrationDir, storage, tc.wantApplied)
+		})
+	}
+}
+
+// assertAppliedMigrations is a test helper which checks a list of applied
+// migrations stored in a mock storage.
+func assertAppliedMigrations(t *testing.T, migrationDir string, storage *mock.Config, want []string) {
+	t.Helper()
+	// Read the history from the storage which was written by the command.
+	s := storage
+	if s.Storage() != nil {
+		s = &mock.Config{Data: s.Storage().Data()}
+	}
+	hc, err := history.NewController(context.Background(), migrationDir, &history.Config{Storage: s})
+	if err != nil {
+		t.Fatalf("failed to load history: %s", err)
+	}
+	got := []string{}
+	for filename := range hc.Records() {
+		got = append(got, filename)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got applied = %#v, want = %#v", got, want)
+	}
+}
+
+func TestWithHistoryLock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		locked bool
+		dryRun bool
+		lock   bool
+		want   bool
+		ok     bool
+	}{
+		{
+			desc:   "unlocked",
+			locked: false,
+			lock:   true,
+			want:   true,
+			ok:     true,
+		},
+		{
+			desc:   "locked by someone else",
+			locked: true,
+			lock:   true,
+			want:   false,
+			ok:     false,
+		},
+		{
+			desc:   "dry-run",
+			locked: true,
+			dryRun: true,
+			lock:   true,
+			want:   true,
+			ok:     true,
+		},
+		{
+			desc:   "locking is disabled",
+			locked: true,
+			lock:   false,
+			want:   true,
+			ok:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &config.TfmigrateConfig{
+				History: &history.Config{
+					Storage: &mock.Config{},
+				},
+			}
+			ctx := context.Background()
+			if tc.locked {
+				unlock, err := history.Lock(ctx, config.History, "apply", 0)
+				if err != nil {
+					t.Fatalf("failed to acquire a lock: %s", err)
+				}
+				defer unlock(ctx) // nolint: errcheck
+			}
+
+			called := false
+			_, err := withHistoryLock(ctx, config, "prune", tc.dryRun, tc.lock, 0, func() (string, error) {
+				called = true
+				return "", nil
+			})
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if called != tc.want {
+				t.Errorf("called = %t, want = %t", called, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepairHistory(t *testing.T) {
+	source1 := `
+migration "mock" "test1" {
+	plan_error  = false
+	apply_error = false
+}
+`
+	source2 := `
+migration "mock" "test2" {
+	plan_error  = false
+	apply_error = false
+}
+`
+	migrations := map[string]string{
+		"20201109000001_test1.hcl": source1,
+		"20201109000002_test2.hcl": source2,
+	}
+
+	cases := []struct {
+		desc          string
+		historyFile   string
+		dryRun        bool
+		want          string
+		wantChecksums map[string]string
+		ok            bool
+	}{
+		{
+			desc: "simple",
+			historyFile: fmt.Sprintf(`{
+    "version": 2,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z",
+            "checksum": "%s"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z",
+            "checksum": "sha256:changed"
+        },
+        "20201109000003_test3.hcl": {
+            "type": "mock",
+            "name": "test3",
+            "applied_at": "2020-11-10T00:00:03Z",
+            "checksum": "sha256:missing"
+        }
+    }
+}`, history.Checksum([]byte(source1))),
+			dryRun: false,
+			want:   `repair 20201109000002_test2.hcl`,
+			wantChecksums: map[string]string{
+				"20201109000001_test1.hcl": history.Checksum([]byte(source1)),
+				"20201109000002_test2.hcl": history.Checksum([]byte(source2)),
+				"20201109000003_test3.hcl": "sha256:missing",
+			},
+			ok: true,
+		},
+		{
+			desc: "fill checksums upgraded from v1",
+			historyFile: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`,
+			dryRun: false,
+			want:   `repair 20201109000001_test1.hcl`,
+			wantChecksums: map[string]string{
+				"20201109000001_test1.hcl": history.Checksum([]byte(source1)),
+			},
+			ok: true,
+		},
+		{
+			desc: "dry-run",
+			historyFile: `{
+    "version": 2,
+    "records": {
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z",
+            "checksum": "sha256:changed"
+        }
+    }
+}`,
+			dryRun: true,
+			want:   `(dry-run) repair 20201109000002_test2.hcl`,
+			wantChecksums: map[string]string{
+				"20201109000002_test2.hcl": "sha256:changed",
+			},
+			ok: true,
+		},
+		{
+			desc: "nothing to repair",
+			historyFile: fmt.Sprintf(`{
+    "version": 2,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z",
+            "checksum": "%s"
+        }
+    }
+}`, history.Checksum([]byte(source1))),
+			dryRun: false,
+			want:   `no records to repair`,
+			wantChecksums: map[string]string{
+				"20201109000001_test1.hcl": history.Checksum([]byte(source1)),
+			},
+			ok: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, migrations)
+			storage := &mock.Config{
+				Data: tc.historyFile,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: storage,
+				},
+			}
+			got, err := repairHistory(context.Background(), config, tc.dryRun)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+
+			hc, err := history.NewController(context.Background(), migrationDir, &history.Config{Storage: &mock.Config{Data: storage.Storage().Data()}})
+			if err != nil {
+				t.Fatalf("failed to load history: %s", err)
+			}
+			gotChecksums := make(map[string]string)
+			for filename, r := range hc.Records() {
+				gotChecksums[filename] = r.Checksum
+			}
+			if !reflect.DeepEqual(gotChecksums, tc.wantChecksums) {
+				t.Errorf("got checksums = %#v, want = %#v", gotChecksums, tc.wantChecksums)
+			}
+		})
+	}
+}
+
+func TestExportHistory(t *testing.T) {
+	historyFile := `{
+    "version": 2,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z",
+            "checksum": "sha256:test1"
+        }
+    }
+}`
+
+	cases := []struct {
+		desc        string
+		fileVersion int
+		want        string
+		ok          bool
+	}{
+		{
+			desc:        "v2",
+			fileVersion: 2,
+			want:        historyFile,
+			ok:          true,
+		},
+		{
+			desc:        "v1",
+			fileVersion: 1,
+			want: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`,
+			ok: true,
+		},
+		{
+			desc:        "unknown version",
+			fileVersion: 3,
+			want:        "",
+			ok:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, map[string]string{})
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: &mock.Config{
+						Data: historyFile,
+					},
+				},
+			}
+			got, err := exportHistory(context.Background(), config, tc.fileVersion)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestImportHistory(t *testing.T) {
+	historyFile := `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`
+
+	cases := []struct {
+		desc        string
+		srcFile     string
+		dryRun      bool
+		want        string
+		wantApplied []string
+		ok          bool
+	}{
+		{
+			desc: "simple",
+			srcFile: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        },
+        "20201109000003_test3.hcl": {
+            "type": "mock",
+            "name": "test3",
+            "applied_at": "2020-11-10T00:00:03Z"
+        }
+    }
+}`,
+			dryRun: false,
+			want: `import 20201109000002_test2.hcl
+import 20201109000003_test3.hcl`,
+			wantApplied: []string{"20201109000001_test1.hcl", "20201109000002_test2.hcl", "20201109000003_test3.hcl"},
+			ok:          true,
+		},
+		{
+			desc: "dry-run",
+			srcFile: `{
+    "version": 1,
+    "records": {
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`,
+			dryRun:      true,
+			want:        `(dry-run) import 20201109000002_test2.hcl`,
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          true,
+		},
+		{
+			desc:        "nothing to import",
+			srcFile:     historyFile,
+			dryRun:      false,
+			want:        `no records to import`,
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          true,
+		},
+		{
+			desc: "conflict",
+			srcFile: `{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-11T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`,
+			dryRun:      false,
+			want:        "",
+			wantApplied: []string{"20201109000001_test1.hcl"},
+			ok:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := setupMigrationDir(t, map[string]string{})
+			storage := &mock.Config{
+				Data: historyFile,
+			}
+			config := &config.TfmigrateConfig{
+				MigrationDir: migrationDir,
+				History: &history.Config{
+					Storage: storage,
+				},
+			}
+			src, err := history.ParseHistoryFile([]byte(tc.srcFile))
+			if err != nil {
+				t.Fatalf("failed to parse history file: %s", err)
+			}
+			got, err := importHistory(context.Background(), config, src, tc.dryRun)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if got != tc.want {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+			assertAppliedMigrations(t, migrationDir, storage, tc.wantApplied)
+		})
+	}
+}
+
+func TestLoadSourceHistory(t *testing.T) {
+	dir := t.TempDir()
+	historyFile := filepath.Join(dir, "history.json")
+	if err := os.WriteFile(historyFile, []byte(`{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`), 0600); err != nil {
+		t.Fatalf("failed to write history file: %s", err)
+	}
+	configFile := filepath.Join(dir, "old.hcl")
+	if err := os.WriteFile(configFile, []byte(fmt.Sprintf(`
+tfmigrate {
+  history {
+    storage "local" {
+      path = "%s"
+    }
+  }
+}
+`, historyFile)), 0600); err != nil {
+		t.Fatalf("failed to write config file: %s", err)
+	}
+	nsHistoryFile := filepath.Join(dir, "team-a.json")
+	if err := os.WriteFile(nsHistoryFile, []byte(`{
+    "version": 1,
+    "records": {
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        },
+        "20201109000002_test2.hcl": {
+            "type": "mock",
+            "name": "test2",
+            "applied_at": "2020-11-10T00:00:02Z"
+        }
+    }
+}`), 0600); err != nil {
+		t.Fatalf("failed to write history file: %s", err)
+	}
+	nsConfigFile := filepath.Join(dir, "ns.hcl")
+	if err := os.WriteFile(nsConfigFile, []byte(fmt.Sprintf(`
+tfmigrate {
+  migration_dir = "."
+  history {
+    storage "local" {
+      path = "%s/${namespace}.json"
+    }
+  }
+  namespace "team-a" {
+    migration_dir = "team-a"
+  }
+}
+`, dir)), 0600); err != nil {
+		t.Fatalf("failed to write config file: %s", err)
+	}
+	noHistoryConfigFile := filepath.Join(dir, "no_history.hcl")
+	if err := os.WriteFile(noHistoryConfigFile, []byte(`
+tfmigrate {
+}
+`), 0600); err != nil {
+		t.Fatalf("failed to write config file: %s", err)
+	}
+
+	cases := []struct {
+		desc       string
+		fromConfig string
+		fromFile   string
+		namespace  string
+		want       int
+		ok         bool
+	}{
+		{
+			desc:       "from config",
+			fromConfig: configFile,
+			want:       1,
+			ok:         true,
+		},
+		{
+			desc:       "from config with namespace",
+			fromConfig: nsConfigFile,
+			namespace:  "team-a",
+			want:       2,
+			ok:         true,
+		},
+		{
+			desc:       "from config with unknown namespace",
+			fromConfig: nsConfigFile,
+			namespace:  "team-b",
+			ok:         false,
+		},
+		{
+			desc:     "from file",
+			fromFile: historyFile,
+			want:     1,
+			ok:       true,
+		},
+		{
+			desc:       "no history setting",
+			fromConfig: noHistoryConfigFile,
+			ok:         false,
+		},
+		{
+			desc:     "file not found",
+			fromFile: filepath.Join(dir, "not_exist.json"),
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := loadSourceHistory(context.Background(), tc.fromConfig, tc.fromFile, tc.namespace)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if tc.ok && got.Length() != tc.want {
+				t.Errorf("got = %d, want = %d", got.Length(), tc.want)
+			}
+		})
+	}
+}
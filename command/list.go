@@ -1,10 +1,15 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/minamijoyo/tfmigrate/config"
 	"github.com/minamijoyo/tfmigrate/history"
@@ -15,13 +20,16 @@ import (
 type ListCommand struct {
 	Meta
 	status string
+	format string
 }
 
 // Run runs the procedure of this command.
 func (c *ListCommand) Run(args []string) int {
 	cmdFlags := flag.NewFlagSet("list", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
 	cmdFlags.StringVar(&c.status, "status", "all", "A filter for migration status")
+	cmdFlags.StringVar(&c.format, "format", "plain", "An output format")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
@@ -33,6 +41,10 @@ func (c *ListCommand) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("failed to load config file: %s", err))
 		return 1
 	}
+	if c.config, err = c.config.WithNamespace(c.namespace); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	log.Printf("[DEBUG] [command] config: %#v\n", c.config)
 
 	c.Option = newOption(c.config)
@@ -48,7 +60,7 @@ func (c *ListCommand) Run(args []string) int {
 
 	// history mode
 	ctx := context.Background()
-	out, err := listMigrations(ctx, c.config, c.status)
+	out, err := listMigrations(ctx, c.config, c.status, c.format)
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 1
@@ -57,8 +69,25 @@ func (c *ListCommand) Run(args []string) int {
 	return 0
 }
 
+// listEntry is a migration entry of the list command.
+type listEntry struct {
+	// Filename is a migration file name.
+	Filename string `json:"filename"`
+	// Status is one of applied, unapplied or missing.
+	// The missing means that the migration is recorded in history but its
+	// migration file doesn't exist in the migration dir.
+	Status string `json:"status"`
+	// Type is a migration type. It's empty if not applied yet.
+	Type string `json:"type,omitempty"`
+	// Name is a migration name. It's empty if not applied yet.
+	Name string `json:"name,omitempty"`
+	// AppliedAt is a timestamp when the migration was applied.
+	// It's nil if not applied yet.
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
 // listMigrations lists migrations.
-func listMigrations(ctx context.Context, config *config.TfmigrateConfig, status string) (string, error) {
+func listMigrations(ctx context.Context, config *config.TfmigrateConfig, status string, format string) (string, error) {
 	hc, err := history.NewController(ctx, config.MigrationDir, config.History)
 	if err != nil {
 		return "", err
@@ -68,16 +97,97 @@ func listMigrations(ctx context.Context, config *config.TfmigrateConfig, status
 	switch status {
 	case "all":
 		migrations = hc.Migrations()
+		// The plain format lists only local migration files as before, so
+		// that its output can be passed to other commands as it is.
+		if format != "plain" {
+			migrations = append(append([]string{}, migrations...), hc.MissingMigrations()...)
+			sort.Strings(migrations)
+		}
 
 	case "unapplied":
 		migrations = hc.UnappliedMigrations()
 
+	case "applied":
+		migrations = []string{}
+		for _, m := range hc.Migrations() {
+			if hc.AlreadyApplied(m) {
+				migrations = append(migrations, m)
+			}
+		}
+
+	case "missing":
+		migrations = hc.MissingMigrations()
+
 	default:
 		return "", fmt.Errorf("unknown filter for status: %s", status)
 	}
 
-	out := strings.Join(migrations, "\n")
-	return out, nil
+	local := make(map[string]bool)
+	for _, m := range hc.Migrations() {
+		local[m] = true
+	}
+
+	records := hc.Records()
+	entries := []listEntry{}
+	for _, m := range migrations {
+		e := listEntry{
+			Filename: m,
+			Status:   "unapplied",
+		}
+		if r, ok := records[m]; ok {
+			e.Status = "applied"
+			if !local[m] {
+				e.Status = "missing"
+			}
+			e.Type = r.Type
+			e.Name = r.Name
+			appliedAt := r.AppliedAt
+			e.AppliedAt = &appliedAt
+		}
+		entries = append(entries, e)
+	}
+
+	return formatListEntries(entries, format)
+}
+
+// formatListEntries renders entries in a given format.
+func formatListEntries(entries []listEntry, format string) (string, error) {
+	switch format {
+	case "plain":
+		filenames := []string{}
+		for _, e := range entries {
+			filenames = append(filenames, e.Filename)
+		}
+		return strings.Join(filenames, "\n"), nil
+
+	case "table":
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "FILENAME\tSTATUS\tTYPE\tNAME\tAPPLIED_AT")
+		for _, e := range entries {
+			typ, name, appliedAt := "-", "-", "-"
+			if e.AppliedAt != nil {
+				typ = e.Type
+				name = e.Name
+				appliedAt = e.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Filename, e.Status, typ, name, appliedAt)
+		}
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(buf.String(), "\n"), nil
+
+	case "json":
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+
+	default:
+		return "", fmt.Errorf("unknown output format: %s", format)
+	}
 }
 
 // Help returns long-form help text.
@@ -86,13 +196,25 @@ func (c *ListCommand) Help() string {
 Usage: tfmigrate list
 
 List migrations.
+The table and json formats also show a status, type, name and
+applied_at of each migration recorded in history.
 
 Options:
   --config           A path to tfmigrate config file
+  --namespace        A namespace of migrations
   --status           A filter for migration status
                      Valid values are as follows:
-                       - all (default)
+                       - all (default, the table and json formats
+                         also include missing migrations)
                        - unapplied
+                       - applied
+                       - missing (recorded in history but the
+                         migration file doesn't exist)
+  --format           An output format
+                     Valid values are as follows:
+                       - plain (default)
+                       - table
+                       - json
 `
 	return strings.TrimSpace(helpText)
 }
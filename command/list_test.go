@@ -52,9 +52,26 @@ migration "mock" "test4" {
     }
 }`
 
+	historyFileWithMissing := `{
+    "version": 1,
+    "records": {
+        "20201109000000_test0.hcl": {
+            "type": "mock",
+            "name": "test0",
+            "applied_at": "2020-11-10T00:00:00Z"
+        },
+        "20201109000001_test1.hcl": {
+            "type": "mock",
+            "name": "test1",
+            "applied_at": "2020-11-10T00:00:01Z"
+        }
+    }
+}`
+
 	cases := []struct {
 		desc        string
 		status      string
+		format      string
 		migrations  map[string]string
 		historyFile string
 		want        string
@@ -62,6 +79,7 @@ migration "mock" "test4" {
 	}{
 		{
 			desc:        "all",
+			format:      "plain",
 			status:      "all",
 			migrations:  migrations,
 			historyFile: historyFile,
@@ -73,6 +91,7 @@ migration "mock" "test4" {
 		},
 		{
 			desc:        "unapplied",
+			format:      "plain",
 			status:      "unapplied",
 			migrations:  migrations,
 			historyFile: historyFile,
@@ -80,8 +99,163 @@ migration "mock" "test4" {
 20201109000004_test4.hcl`,
 			ok: true,
 		},
+		{
+			desc:        "applied",
+			format:      "plain",
+			status:      "applied",
+			migrations:  migrations,
+			historyFile: historyFile,
+			want: `20201109000001_test1.hcl
+20201109000002_test2.hcl`,
+			ok: true,
+		},
+		{
+			desc:        "missing",
+			format:      "plain",
+			status:      "missing",
+			migrations:  migrations,
+			historyFile: historyFileWithMissing,
+			want:        `20201109000000_test0.hcl`,
+			ok:          true,
+		},
+		{
+			desc:        "table",
+			format:      "table",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFile,
+			want: `FILENAME                  STATUS     TYPE  NAME   APPLIED_AT
+20201109000001_test1.hcl  applied    mock  test1  2020-11-10T00:00:01Z
+20201109000002_test2.hcl  applied    mock  test2  2020-11-10T00:00:02Z
+20201109000003_test3.hcl  unapplied  -     -      -
+20201109000004_test4.hcl  unapplied  -     -      -`,
+			ok: true,
+		},
+		{
+			desc:        "table missing",
+			format:      "table",
+			status:      "missing",
+			migrations:  migrations,
+			historyFile: historyFileWithMissing,
+			want: `FILENAME                  STATUS   TYPE  NAME   APPLIED_AT
+20201109000000_test0.hcl  missing  mock  test0  2020-11-10T00:00:00Z`,
+			ok: true,
+		},
+		{
+			desc:        "json",
+			format:      "json",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFile,
+			want: `[
+  {
+    "filename": "20201109000001_test1.hcl",
+    "status": "applied",
+    "type": "mock",
+    "name": "test1",
+    "applied_at": "2020-11-10T00:00:01Z"
+  },
+  {
+    "filename": "20201109000002_test2.hcl",
+    "status": "applied",
+    "type": "mock",
+    "name": "test2",
+    "applied_at": "2020-11-10T00:00:02Z"
+  },
+  {
+    "filename": "20201109000003_test3.hcl",
+    "status": "unapplied"
+  },
+  {
+    "filename": "20201109000004_test4.hcl",
+    "status": "unapplied"
+  }
+]`,
+			ok: true,
+		},
+		{
+			desc:        "plain all with missing",
+			format:      "plain",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFileWithMissing,
+			want: `20201109000001_test1.hcl
+20201109000002_test2.hcl
+20201109000003_test3.hcl
+20201109000004_test4.hcl`,
+			ok: true,
+		},
+		{
+			desc:        "table all with missing",
+			format:      "table",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFileWithMissing,
+			want: `FILENAME                  STATUS     TYPE  NAME   APPLIED_AT
+20201109000000_test0.hcl  missing    mock  test0  2020-11-10T00:00:00Z
+20201109000001_test1.hcl  applied    mock  test1  2020-11-10T00:00:01Z
+20201109000002_test2.hcl  unapplied  -     -      -
+20201109000003_test3.hcl  unapplied  -     -      -
+20201109000004_test4.hcl  unapplied  -     -      -`,
+			ok: true,
+		},
+		{
+			desc:        "json all with missing",
+			format:      "json",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFileWithMissing,
+			want: `[
+  {
+    "filename": "20201109000000_test0.hcl",
+    "status": "missing",
+    "type": "mock",
+    "name": "test0",
+    "applied_at": "2020-11-10T00:00:00Z"
+  },
+  {
+    "filename": "20201109000001_test1.hcl",
+    "status": "applied",
+    "type": "mock",
+    "name": "test1",
+    "applied_at": "2020-11-10T00:00:01Z"
+  },
+  {
+    "filename": "20201109000002_test2.hcl",
+    "status": "unapplied"
+  },
+  {
+    "filename": "20201109000003_test3.hcl",
+    "status": "unapplied"
+  },
+  {
+    "filename": "20201109000004_test4.hcl",
+    "status": "unapplied"
+  }
+]`,
+			ok: true,
+		},
+		{
+			desc:        "json empty",
+			format:      "json",
+			status:      "missing",
+			migrations:  migrations,
+			historyFile: historyFile,
+			want:        `[]`,
+			ok:          true,
+		},
+		{
+			desc:        "unknown format",
+			format:      "foo",
+			status:      "all",
+			migrations:  migrations,
+			historyFile: historyFile,
+			want:        "",
+			ok:          false,
+		},
 		{
 			desc:        "unknown status",
+			format:      "plain",
 			status:      "foo",
 			migrations:  migrations,
 			historyFile: historyFile,
@@ -104,7 +278,7 @@ migration "mock" "test4" {
 					Storage: storage,
 				},
 			}
-			got, err := listMigrations(context.Background(), config, tc.status)
+			got, err := listMigrations(context.Background(), config, tc.status, tc.format)
 			if tc.ok && err != nil {
 				t.Fatalf("unexpected err: %s", err)
 			}
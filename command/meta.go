@@ -17,9 +17,16 @@ type Meta struct {
 	// UI is a user interface representing input and output.
 	UI cli.Ui
 
+	// Version is a version of tfmigrate.
+	// It is recorded in history as an audit log.
+	Version string
+
 	// A path to tfmigrate config file.
 	configFile string
 
+	// A name of namespace to select a set of migrations in config.
+	namespace string
+
 	// a global configuration for tfmigrate.
 	config *config.TfmigrateConfig
 
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/history"
+	"github.com/minamijoyo/tfmigrate/tfmigrate"
+)
+
+// newHistoryMetadata builds an audit log of an applied migration.
+// The report is optional. If nil, details of the migration are not recorded.
+func newHistoryMetadata(ctx context.Context, report *tfmigrate.Report, migrationDir string, version string, duration time.Duration) *history.Metadata {
+	m := &history.Metadata{
+		AppliedBy:        appliedBy(),
+		TfmigrateVersion: version,
+		GitCommit:        gitCommit(ctx, migrationDir),
+		Duration:         duration,
+	}
+
+	if report != nil {
+		m.TerraformVersion = report.TerraformVersion
+		m.Actions = report.Actions
+		for _, s := range report.States {
+			m.States = append(m.States, history.StateMetadata(s))
+		}
+	}
+
+	return m
+}
+
+// appliedBy returns an identity of the user or CI who applies a migration.
+// The TFMIGRATE_APPLIED_BY environment variable takes precedence over the
+// current OS user, which is useful to record a CI job identity.
+func appliedBy() string {
+	if v := os.Getenv("TFMIGRATE_APPLIED_BY"); v != "" {
+		return v
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		log.Printf("[DEBUG] [runner] failed to get current user: %s\n", err)
+		return os.Getenv("USER")
+	}
+	return u.Username
+}
+
+// gitCommit returns a commit hash of HEAD in a given dir.
+// If the dir is not in a git repository or git command is not available,
+// returns an empty string.
+func gitCommit(ctx context.Context, dir string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		log.Printf("[DEBUG] [runner] failed to get git commit of %s: %s\n", dir, err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
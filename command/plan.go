@@ -21,6 +21,7 @@ type PlanCommand struct {
 func (c *PlanCommand) Run(args []string) int {
 	cmdFlags := flag.NewFlagSet("plan", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
 	cmdFlags.StringArrayVar(&c.backendConfig, "backend-config", nil, "A backend configuration for remote state")
 	cmdFlags.StringVar(&c.out, "out", "", "Save a plan file after dry-run migration to the given path")
 
@@ -34,6 +35,10 @@ func (c *PlanCommand) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("failed to load config file: %s", err))
 		return 1
 	}
+	if c.config, err = c.config.WithNamespace(c.namespace); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	log.Printf("[DEBUG] [command] config: %#v\n", c.config)
 
 	c.Option = newOption(c.config)
@@ -117,6 +122,7 @@ Arguments:
 
 Options:
   --config                 A path to tfmigrate config file
+  --namespace              A namespace of migrations
   --backend-config=path    A backend configuration, a path to backend configuration file or
                            key=value format backend configuraion.
                            This option is passed to terraform init when switching backend to remote.
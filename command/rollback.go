@@ -0,0 +1,156 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// RollbackCommand is a command which reverts an applied migration.
+type RollbackCommand struct {
+	Meta
+	backendConfig []string
+	dryRun        bool
+	lock          bool
+	lockTimeout   time.Duration
+}
+
+// Run runs the procedure of this command.
+func (c *RollbackCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.configFile, "config", defaultConfigFile, "A path to tfmigrate config file")
+	cmdFlags.StringVar(&c.namespace, "namespace", "", "A namespace of migrations")
+	cmdFlags.StringArrayVar(&c.backendConfig, "backend-config", nil, "A backend configuration for remote state")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "Plan a rollback without pushing states and updating history")
+	cmdFlags.BoolVar(&c.lock, "lock", true, "Lock the history storage during rollback")
+	cmdFlags.DurationVar(&c.lockTimeout, "lock-timeout", 0, "A duration to retry acquiring a lock")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to parse arguments: %s", err))
+		return 1
+	}
+
+	var err error
+	if c.config, err = newConfig(c.configFile); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to load config file: %s", err))
+		return 1
+	}
+	if c.config, err = c.config.WithNamespace(c.namespace); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	log.Printf("[DEBUG] [command] config: %#v\n", c.config)
+
+	c.Option = newOption(c.config)
+	c.Option.BackendConfig = c.backendConfig
+	// The option may contain sensitive values such as environment variables.
+	// So logging the option set log level to DEBUG instead of INFO.
+	log.Printf("[DEBUG] [command] option: %#v\n", c.Option)
+
+	if c.config.History == nil {
+		// non-history mode
+		if len(cmdFlags.Args()) != 1 {
+			c.UI.Error(fmt.Sprintf("The command expects 1 argument, but got %d", len(cmdFlags.Args())))
+			c.UI.Error(c.Help())
+			return 1
+		}
+
+		migrationFile := cmdFlags.Arg(0)
+		if err = c.rollbackWithoutHistory(migrationFile); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		return 0
+	}
+
+	// history mode
+	if len(cmdFlags.Args()) > 1 {
+		c.UI.Error(fmt.Sprintf("The command expects 0 or 1 argument, but got %d", len(cmdFlags.Args())))
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	migrationFile := ""
+	if len(cmdFlags.Args()) == 1 {
+		// Rollback a given single migration file and remove it from history.
+		migrationFile = cmdFlags.Arg(0)
+	}
+
+	// Rollback the most recently applied migration and remove it from history.
+	if err = c.rollbackWithHistory(migrationFile); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// rollbackWithoutHistory is a helper function which reverts a given migration file without history.
+func (c *RollbackCommand) rollbackWithoutHistory(filename string) error {
+	fr, err := NewFileRunner(filename, c.config, c.Option)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		return fr.PlanRollback(context.Background())
+	}
+	return fr.Rollback(context.Background())
+}
+
+// rollbackWithHistory is a helper function which reverts an applied migration and removes it from history.
+func (c *RollbackCommand) rollbackWithHistory(filename string) error {
+	ctx := context.Background()
+	hr, err := NewHistoryRunner(ctx, filename, c.config, c.Option)
+	if err != nil {
+		return err
+	}
+
+	hr.lock = c.lock
+	hr.lockTimeout = c.lockTimeout
+
+	if c.dryRun {
+		return hr.PlanRollback(ctx)
+	}
+	return hr.Rollback(ctx)
+}
+
+// Help returns long-form help text.
+func (c *RollbackCommand) Help() string {
+	helpText := `
+Usage: tfmigrate rollback [PATH]
+
+Rollback reverts an applied migration by applying its inverse.
+Invertible actions such as mv and replace-provider are inverted automatically.
+A migration file can also define down actions explicitly.
+It will fail if terraform plan detects any diffs with the new state.
+In history mode, the reverted migration is removed from history.
+
+Arguments
+  PATH                     A path of migration file
+                           Required in non-history mode. Optional in history-mode.
+                           If omitted in history mode, the most recently applied
+                           migration is reverted.
+
+Options:
+  --config                 A path to tfmigrate config file
+  --namespace              A namespace of migrations
+  --backend-config=path    A backend configuration, a path to backend configuration file or
+                           key=value format backend configuraion.
+                           This option is passed to terraform init when switching backend to remote.
+  --dry-run                Plan a rollback without pushing states and updating history.
+  --lock=true              Lock the history storage during rollback in history mode.
+  --lock-timeout=0s        A duration to retry acquiring a lock.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis returns one-line help text.
+func (c *RollbackCommand) Synopsis() string {
+	return "Revert an applied migration"
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/encryption"
+)
+
+// EncryptionBlock represents a block for client-side encryption of migration
+// history in HCL.
+type EncryptionBlock struct {
+	// KeyProviders is a list of blocks for key providers.
+	KeyProviders []KeyProviderBlock `hcl:"key_provider,block"`
+}
+
+// KeyProviderBlock represents a block for a key provider in HCL.
+type KeyProviderBlock struct {
+	// Type is a type for key provider.
+	// Valid values are as follows:
+	// - static
+	// - age
+	// - aws_kms
+	Type string `hcl:"type,label"`
+	// Remain is a body of key_provider block.
+	// We first decode only a block header and then decode schema depending on
+	// its type label.
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// parseEncryptionBlock parses an encryption block and returns a
+// storage.Config which wraps a given storage.Config.
+func parseEncryptionBlock(b EncryptionBlock, s storage.Config, ctx *hcl.EvalContext) (storage.Config, error) {
+	if len(b.KeyProviders) == 0 {
+		return nil, fmt.Errorf("at least one key_provider block is required in encryption block")
+	}
+
+	config := &encryption.Config{
+		Storage: s,
+	}
+	for _, kb := range b.KeyProviders {
+		kp, err := parseKeyProviderBlock(kb, ctx)
+		if err != nil {
+			return nil, err
+		}
+		config.KeyProviders = append(config.KeyProviders, kp)
+	}
+
+	return config, nil
+}
+
+// parseKeyProviderBlock parses a key_provider block and returns a
+// encryption.KeyProviderConfig.
+func parseKeyProviderBlock(b KeyProviderBlock, ctx *hcl.EvalContext) (encryption.KeyProviderConfig, error) {
+	var config encryption.KeyProviderConfig
+	switch b.Type {
+	case "static":
+		config = &encryption.StaticConfig{}
+
+	case "age":
+		config = &encryption.AgeConfig{}
+
+	case "aws_kms":
+		config = &encryption.AWSKMSConfig{}
+
+	default:
+		return nil, fmt.Errorf("unknown key provider type: %s", b.Type)
+	}
+
+	diags := gohcl.DecodeBody(b.Remain, ctx, config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return config, nil
+}
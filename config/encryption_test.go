@@ -0,0 +1,134 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/encryption"
+	"github.com/minamijoyo/tfmigrate/storage/local"
+)
+
+func TestParseEncryptionBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+    encryption {
+      key_provider "static" {
+        env = "TFMIGRATE_HISTORY_KEY"
+      }
+      key_provider "age" {
+        recipients    = ["age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"]
+        identity_file = "key.txt"
+      }
+      key_provider "aws_kms" {
+        key_id = "alias/tfmigrate"
+        region = "ap-northeast-1"
+      }
+    }
+  }
+}
+`,
+			want: &encryption.Config{
+				Storage: &local.Config{
+					Path: "tmp/history.json",
+				},
+				KeyProviders: []encryption.KeyProviderConfig{
+					&encryption.StaticConfig{
+						Env: "TFMIGRATE_HISTORY_KEY",
+					},
+					&encryption.AgeConfig{
+						Recipients:   []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+						IdentityFile: "key.txt",
+					},
+					&encryption.AWSKMSConfig{
+						KeyID:  "alias/tfmigrate",
+						Region: "ap-northeast-1",
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "no key providers",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+    encryption {
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown key provider type",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+    encryption {
+      key_provider "foo" {
+      }
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "missing required attribute (key_id)",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+    encryption {
+      key_provider "aws_kms" {
+      }
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
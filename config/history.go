@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/minamijoyo/tfmigrate/history"
 )
@@ -9,6 +12,16 @@ import (
 type HistoryBlock struct {
 	// Storage is a block for migration history data store.
 	Storage StorageBlock `hcl:"storage,block"`
+	// ChecksumMismatch is a behavior when a checksum of an applied migration
+	// file doesn't match the one recorded in history.
+	// Valid values are "error" or "warn". Default to "error".
+	ChecksumMismatch string `hcl:"checksum_mismatch,optional"`
+	// LockTTL is a time-to-live of a lock of the history storage such as "2h".
+	// A lock older than it is considered stale and can be taken over.
+	// Default to 1h.
+	LockTTL string `hcl:"lock_ttl,optional"`
+	// Encryption is an optional block for client-side encryption of history.
+	Encryption *EncryptionBlock `hcl:"encryption,block"`
 }
 
 // parseHistoryBlock parses a history block and returns a *history.Config.
@@ -18,8 +31,34 @@ func parseHistoryBlock(b HistoryBlock, ctx *hcl.EvalContext) (*history.Config, e
 		return nil, err
 	}
 
+	switch b.ChecksumMismatch {
+	case "", history.ChecksumMismatchError, history.ChecksumMismatchWarn:
+	default:
+		return nil, fmt.Errorf("unknown value for checksum_mismatch: %s", b.ChecksumMismatch)
+	}
+
+	var lockTTL time.Duration
+	if b.LockTTL != "" {
+		lockTTL, err = time.ParseDuration(b.LockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse lock_ttl: %w", err)
+		}
+		if lockTTL <= 0 {
+			return nil, fmt.Errorf("lock_ttl must be positive: %s", b.LockTTL)
+		}
+	}
+
+	if b.Encryption != nil {
+		storage, err = parseEncryptionBlock(*b.Encryption, storage, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	history := &history.Config{
-		Storage: storage,
+		Storage:          storage,
+		ChecksumMismatch: b.ChecksumMismatch,
+		LockTTL:          lockTTL,
 	}
 
 	return history, nil
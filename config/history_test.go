@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/minamijoyo/tfmigrate/history"
 	"github.com/minamijoyo/tfmigrate/storage/local"
@@ -34,6 +35,96 @@ tfmigrate {
 			},
 			ok: true,
 		},
+		{
+			desc: "checksum_mismatch",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    checksum_mismatch = "warn"
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: &history.Config{
+				Storage: &local.Config{
+					Path: "tmp/history.json",
+				},
+				ChecksumMismatch: "warn",
+			},
+			ok: true,
+		},
+		{
+			desc: "unknown checksum_mismatch",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    checksum_mismatch = "foo"
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "lock_ttl",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    lock_ttl = "2h"
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: &history.Config{
+				Storage: &local.Config{
+					Path: "tmp/history.json",
+				},
+				LockTTL: 2 * time.Hour,
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid lock_ttl",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    lock_ttl = "foo"
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "non-positive lock_ttl",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    lock_ttl = "0s"
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
 		{
 			desc: "missing block (storage)",
 			source: `
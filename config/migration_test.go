@@ -124,6 +124,27 @@ migration "state" "test" {
 			},
 			ok: true,
 		},
+		{
+			desc: "state with a regex action",
+			source: `
+migration "state" "test" {
+	actions = [
+		"rmv 'null_resource\\.(?P<name>\\w+)' 'null_resource.$${name}2'",
+	]
+}
+`,
+			want: &tfmigrate.MigrationConfig{
+				Type: "state",
+				Name: "test",
+				Migrator: &tfmigrate.StateMigratorConfig{
+					Dir: "",
+					Actions: []string{
+						`rmv 'null_resource\.(?P<name>\w+)' 'null_resource.${name}2'`,
+					},
+				},
+			},
+			ok: true,
+		},
 		{
 			desc: "state without actions",
 			source: `
@@ -309,6 +330,116 @@ migration "multi_state" "mv_dir1_dir2" {
 			},
 			ok: true,
 		},
+		{
+			desc: "state with down",
+			source: `
+migration "state" "test" {
+	dir = "dir1"
+	actions = [
+		"mv null_resource.foo null_resource.foo2",
+		"rm time_static.baz",
+	]
+	down = [
+		"import time_static.baz 2006-01-02T15:04:05Z",
+		"mv null_resource.foo2 null_resource.foo",
+	]
+}
+`,
+			want: &tfmigrate.MigrationConfig{
+				Type: "state",
+				Name: "test",
+				Migrator: &tfmigrate.StateMigratorConfig{
+					Dir: "dir1",
+					Actions: []string{
+						"mv null_resource.foo null_resource.foo2",
+						"rm time_static.baz",
+					},
+					Down: []string{
+						"import time_static.baz 2006-01-02T15:04:05Z",
+						"mv null_resource.foo2 null_resource.foo",
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "multi state with down",
+			source: `
+migration "multi_state" "mv_dir1_dir2" {
+	from_dir = "dir1"
+	to_dir   = "dir2"
+	actions = [
+		"xmv null_resource.* null_resource.$${1}2",
+	]
+	down = [
+		"xmv null_resource.*2 null_resource.$1",
+	]
+}
+`,
+			want: &tfmigrate.MigrationConfig{
+				Type: "multi_state",
+				Name: "mv_dir1_dir2",
+				Migrator: &tfmigrate.MultiStateMigratorConfig{
+					FromDir: "dir1",
+					ToDir:   "dir2",
+					Actions: []string{
+						"xmv null_resource.* null_resource.${1}2",
+					},
+					Down: []string{
+						"xmv null_resource.*2 null_resource.$1",
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "state with idempotent",
+			source: `
+migration "state" "test" {
+	actions = [
+		"mv null_resource.foo null_resource.foo2",
+	]
+	idempotent = true
+}
+`,
+			want: &tfmigrate.MigrationConfig{
+				Type: "state",
+				Name: "test",
+				Migrator: &tfmigrate.StateMigratorConfig{
+					Actions: []string{
+						"mv null_resource.foo null_resource.foo2",
+					},
+					Idempotent: true,
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "multi state with idempotent",
+			source: `
+migration "multi_state" "mv_dir1_dir2" {
+	from_dir = "dir1"
+	to_dir   = "dir2"
+	actions = [
+		"mv null_resource.foo null_resource.foo2",
+	]
+	idempotent = true
+}
+`,
+			want: &tfmigrate.MigrationConfig{
+				Type: "multi_state",
+				Name: "mv_dir1_dir2",
+				Migrator: &tfmigrate.MultiStateMigratorConfig{
+					FromDir: "dir1",
+					ToDir:   "dir2",
+					Actions: []string{
+						"mv null_resource.foo null_resource.foo2",
+					},
+					Idempotent: true,
+				},
+			},
+			ok: true,
+		},
 		{
 			desc: "unknown migration type",
 			source: `
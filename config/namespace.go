@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/minamijoyo/tfmigrate/history"
+)
+
+// DefaultNamespace is a name of the namespace for top-level settings.
+// It is used when no namespace is selected.
+const DefaultNamespace = "default"
+
+// NamespaceBlock represents a block for a set of migrations which shares a
+// history block with others in HCL.
+type NamespaceBlock struct {
+	// Name is a name of namespace.
+	// It can be referenced as `${namespace}` in a history block.
+	Name string `hcl:"name,label"`
+	// MigrationDir is a path to directory where migration files are stored.
+	MigrationDir string `hcl:"migration_dir"`
+}
+
+// NamespaceConfig is a config for a namespace.
+type NamespaceConfig struct {
+	// MigrationDir is a path to directory where migration files are stored.
+	MigrationDir string
+	// History is a config for migration history management of the namespace.
+	History *history.Config
+}
+
+// namespaceEvalContext returns a child context of a given context, which
+// defines a namespace variable.
+func namespaceEvalContext(ctx *hcl.EvalContext, name string) *hcl.EvalContext {
+	child := ctx.NewChild()
+	child.Variables = map[string]cty.Value{
+		"namespace": cty.StringVal(name),
+	}
+	return child
+}
+
+// parseNamespaceBlocks parses namespace blocks and returns a map of
+// namespace names to configs. A history block is evaluated for each
+// namespace, so that a storage key can depend on the namespace.
+// The defaultHistory is a history config of the default namespace, which is
+// used to check that each namespace has its own history storage.
+func parseNamespaceBlocks(bs []NamespaceBlock, hb *HistoryBlock, defaultHistory *history.Config, ctx *hcl.EvalContext) (map[string]*NamespaceConfig, error) {
+	namespaces := make(map[string]*NamespaceConfig)
+	// names of namespaces in order of declaration for deterministic errors.
+	names := []string{}
+	for _, b := range bs {
+		if b.Name == DefaultNamespace {
+			return nil, fmt.Errorf("namespace %q is reserved for top-level settings", DefaultNamespace)
+		}
+		if _, ok := namespaces[b.Name]; ok {
+			return nil, fmt.Errorf("duplicated namespace: %s", b.Name)
+		}
+
+		ns := &NamespaceConfig{
+			MigrationDir: b.MigrationDir,
+		}
+		if hb != nil {
+			h, err := parseHistoryBlock(*hb, namespaceEvalContext(ctx, b.Name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse history block for namespace %s: %w", b.Name, err)
+			}
+			// Namespaces sharing the same storage would silently share
+			// history and lock, which is unlikely what the user intended.
+			// This typically happens when the history block doesn't refer to
+			// `${namespace}`.
+			if defaultHistory != nil && reflect.DeepEqual(h.Storage, defaultHistory.Storage) {
+				return nil, fmt.Errorf("namespace %s resolves to the same history storage as the %s namespace, refer to ${namespace} in the history block", b.Name, DefaultNamespace)
+			}
+			for _, other := range names {
+				if reflect.DeepEqual(h.Storage, namespaces[other].History.Storage) {
+					return nil, fmt.Errorf("namespace %s resolves to the same history storage as the %s namespace, refer to ${namespace} in the history block", b.Name, other)
+				}
+			}
+			ns.History = h
+		}
+		namespaces[b.Name] = ns
+		names = append(names, b.Name)
+	}
+
+	return namespaces, nil
+}
+
+// WithNamespace returns a copy of the config whose migration dir and history
+// are replaced with the ones of a given namespace.
+// If the name is empty or the default namespace, it returns the config as it is.
+func (c *TfmigrateConfig) WithNamespace(name string) (*TfmigrateConfig, error) {
+	if name == "" || name == DefaultNamespace {
+		return c, nil
+	}
+
+	ns, ok := c.Namespaces[name]
+	if !ok {
+		names := make([]string, 0, len(c.Namespaces))
+		for n := range c.Namespaces {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown namespace: %s, available namespaces: [%s]", name, strings.Join(names, ", "))
+	}
+
+	config := *c
+	config.MigrationDir = ns.MigrationDir
+	config.History = ns.History
+	return &config, nil
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/history"
+	"github.com/minamijoyo/tfmigrate/storage/local"
+)
+
+func TestParseNamespaceBlocks(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   *TfmigrateConfig
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  history {
+    storage "local" {
+      path = "tmp/${namespace}/history.json"
+    }
+  }
+  namespace "team-a" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+  namespace "team-b" {
+    migration_dir = "teams/b/tfmigrate"
+  }
+}
+`,
+			want: &TfmigrateConfig{
+				ExecPath:     "terraform",
+				MigrationDir: "tfmigrate",
+				History: &history.Config{
+					Storage: &local.Config{
+						Path: "tmp/default/history.json",
+					},
+				},
+				Namespaces: map[string]*NamespaceConfig{
+					"team-a": {
+						MigrationDir: "teams/a/tfmigrate",
+						History: &history.Config{
+							Storage: &local.Config{
+								Path: "tmp/team-a/history.json",
+							},
+						},
+					},
+					"team-b": {
+						MigrationDir: "teams/b/tfmigrate",
+						History: &history.Config{
+							Storage: &local.Config{
+								Path: "tmp/team-b/history.json",
+							},
+						},
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "without history",
+			source: `
+tfmigrate {
+  namespace "team-a" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+}
+`,
+			want: &TfmigrateConfig{
+				ExecPath:     "terraform",
+				MigrationDir: ".",
+				Namespaces: map[string]*NamespaceConfig{
+					"team-a": {
+						MigrationDir: "teams/a/tfmigrate",
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "duplicated namespace",
+			source: `
+tfmigrate {
+  namespace "team-a" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+  namespace "team-a" {
+    migration_dir = "teams/b/tfmigrate"
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "history without namespace reference",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+  namespace "team-a" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "namespaces sharing history",
+			source: `
+tfmigrate {
+  history {
+    storage "local" {
+      path = "tmp/${namespace == "default" ? "default" : "shared"}/history.json"
+    }
+  }
+  namespace "team-a" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+  namespace "team-b" {
+    migration_dir = "teams/b/tfmigrate"
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "reserved namespace",
+			source: `
+tfmigrate {
+  namespace "default" {
+    migration_dir = "teams/a/tfmigrate"
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "missing required attribute (migration_dir)",
+			source: `
+tfmigrate {
+  namespace "team-a" {
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("TFMIGRATE_EXEC_PATH", "")
+			got, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTfmigrateConfigWithNamespace(t *testing.T) {
+	teamA := &NamespaceConfig{
+		MigrationDir: "teams/a/tfmigrate",
+		History: &history.Config{
+			Storage: &local.Config{
+				Path: "tmp/team-a/history.json",
+			},
+		},
+	}
+	config := &TfmigrateConfig{
+		ExecPath:     "terraform",
+		MigrationDir: "tfmigrate",
+		History: &history.Config{
+			Storage: &local.Config{
+				Path: "tmp/default/history.json",
+			},
+		},
+		Namespaces: map[string]*NamespaceConfig{
+			"team-a": teamA,
+		},
+	}
+
+	cases := []struct {
+		desc string
+		name string
+		want *TfmigrateConfig
+		ok   bool
+	}{
+		{
+			desc: "empty",
+			name: "",
+			want: config,
+			ok:   true,
+		},
+		{
+			desc: "default",
+			name: "default",
+			want: config,
+			ok:   true,
+		},
+		{
+			desc: "select",
+			name: "team-a",
+			want: &TfmigrateConfig{
+				ExecPath:     "terraform",
+				MigrationDir: "teams/a/tfmigrate",
+				History:      teamA.History,
+				Namespaces:   config.Namespaces,
+			},
+			ok: true,
+		},
+		{
+			desc: "unknown",
+			name: "team-b",
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := config.WithNamespace(tc.name)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
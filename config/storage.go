@@ -6,9 +6,15 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/azurerm"
+	"github.com/minamijoyo/tfmigrate/storage/cloud"
+	"github.com/minamijoyo/tfmigrate/storage/consul"
 	"github.com/minamijoyo/tfmigrate/storage/gcs"
+	"github.com/minamijoyo/tfmigrate/storage/http"
+	"github.com/minamijoyo/tfmigrate/storage/kubernetes"
 	"github.com/minamijoyo/tfmigrate/storage/local"
 	"github.com/minamijoyo/tfmigrate/storage/mock"
+	"github.com/minamijoyo/tfmigrate/storage/pg"
 	"github.com/minamijoyo/tfmigrate/storage/s3"
 )
 
@@ -19,6 +25,14 @@ type StorageBlock struct {
 	// - mock
 	// - local
 	// - s3
+	// - gcs
+	// - azurerm
+	// - pg
+	// - http
+	// - kubernetes
+	// - consul
+	// - cloud (remote)
+	// - backend
 	Type string `hcl:"type,label"`
 	// Remain is a body of storage block.
 	// We first decode only a block header and then decode schema depending on
@@ -41,6 +55,27 @@ func parseStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, er
 	case "gcs":
 		return parseGCSStorageBlock(b, ctx)
 
+	case "azurerm":
+		return parseAzurermStorageBlock(b, ctx)
+
+	case "pg":
+		return parsePgStorageBlock(b, ctx)
+
+	case "http":
+		return parseHTTPStorageBlock(b, ctx)
+
+	case "kubernetes":
+		return parseKubernetesStorageBlock(b, ctx)
+
+	case "consul":
+		return parseConsulStorageBlock(b, ctx)
+
+	case "cloud", "remote":
+		return parseCloudStorageBlock(b, ctx)
+
+	case "backend":
+		return parseBackendStorageBlock(b, ctx)
+
 	default:
 		return nil, fmt.Errorf("unknown history storage type: %s", b.Type)
 	}
@@ -88,3 +123,69 @@ func parseGCSStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config,
 
 	return &config, nil
 }
+
+// parseAzurermStorageBlock parses a storage block for azurerm and returns a storage.Config.
+func parseAzurermStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config azurerm.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
+
+// parsePgStorageBlock parses a storage block for pg and returns a storage.Config.
+func parsePgStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config pg.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
+
+// parseHTTPStorageBlock parses a storage block for http and returns a storage.Config.
+func parseHTTPStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config http.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
+
+// parseKubernetesStorageBlock parses a storage block for kubernetes and returns a storage.Config.
+func parseKubernetesStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config kubernetes.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
+
+// parseConsulStorageBlock parses a storage block for consul and returns a storage.Config.
+func parseConsulStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config consul.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
+
+// parseCloudStorageBlock parses a storage block for cloud and returns a storage.Config.
+func parseCloudStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var config cloud.Config
+	diags := gohcl.DecodeBody(b.Remain, ctx, &config)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/azurerm"
+)
+
+func TestParseAzurermStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      storage_account_name = "tfmigrate"
+      container_name       = "tfstate"
+      key                  = "tfmigrate/history.json"
+    }
+  }
+}
+`,
+			want: &azurerm.Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      storage_account_name = "tfmigrate"
+      container_name       = "tfstate"
+      key                  = "tfmigrate/history.json"
+      access_key           = "dummy-key"
+      sas_token            = "dummy-sas"
+      tenant_id            = "dummy-tenant"
+      client_id            = "dummy-client"
+      client_secret        = "dummy-secret"
+      endpoint             = "http://azurite:10000/devstoreaccount1"
+    }
+  }
+}
+`,
+			want: &azurerm.Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+				AccessKey:          "dummy-key",
+				SASToken:           "dummy-sas",
+				TenantID:           "dummy-tenant",
+				ClientID:           "dummy-client",
+				ClientSecret:       "dummy-secret",
+				Endpoint:           "http://azurite:10000/devstoreaccount1",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (connection string)",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      connection_string = "UseDevelopmentStorage=true"
+      container_name    = "tfstate"
+      key               = "tfmigrate/history.json"
+    }
+  }
+}
+`,
+			want: &azurerm.Config{
+				ConnectionString: "UseDevelopmentStorage=true",
+				ContainerName:    "tfstate",
+				Key:              "tfmigrate/history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "missing required attribute (container_name)",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      storage_account_name = "tfmigrate"
+      key                  = "tfmigrate/history.json"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "missing required attribute (key)",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      storage_account_name = "tfmigrate"
+      container_name       = "tfstate"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "azurerm" {
+      storage_account_name = "tfmigrate"
+      container_name       = "tfstate"
+      key                  = "tfmigrate/history.json"
+      foo                  = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
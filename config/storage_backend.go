@@ -0,0 +1,363 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/azurerm"
+	"github.com/minamijoyo/tfmigrate/storage/cloud"
+	"github.com/minamijoyo/tfmigrate/storage/gcs"
+	"github.com/minamijoyo/tfmigrate/storage/local"
+	"github.com/minamijoyo/tfmigrate/storage/s3"
+)
+
+// defaultKeySuffix is a default suffix appended to a key of the state to
+// derive a key of the history file.
+const defaultKeySuffix = ".tfmigrate-history.json"
+
+// BackendStorageBlock represents a storage block of the backend type in HCL.
+// It derives a storage config from a backend block of a Terraform root module.
+type BackendStorageBlock struct {
+	// Dir is a path to the root module which has a backend block.
+	// Relative to the current working directory.
+	Dir string `hcl:"dir"`
+	// KeySuffix is a suffix appended to a key of the state.
+	// Default to `.tfmigrate-history.json`.
+	KeySuffix string `hcl:"key_suffix,optional"`
+	// BackendConfig is a list of partial backend configurations like the
+	// -backend-config flag of terraform init. Each element is either a
+	// key=value pair or a path to a file.
+	BackendConfig []string `hcl:"backend_config,optional"`
+}
+
+// backendStorageTypes is a map of backend types to storage configs which can
+// be derived from them.
+var backendStorageTypes = map[string]func() any{
+	"s3":      func() any { return &s3.Config{} },
+	"gcs":     func() any { return &gcs.Config{} },
+	"azurerm": func() any { return &azurerm.Config{} },
+	"local":   func() any { return &local.Config{} },
+	"cloud":   func() any { return &cloud.Config{} },
+}
+
+// parseBackendStorageBlock parses a storage block for backend and returns a storage.Config.
+func parseBackendStorageBlock(b StorageBlock, ctx *hcl.EvalContext) (storage.Config, error) {
+	var block BackendStorageBlock
+	diags := gohcl.DecodeBody(b.Remain, ctx, &block)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	backendType, attrs, err := loadBackendBlock(block.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range block.BackendConfig {
+		partial, err := parseBackendConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range partial {
+			attrs[k] = v
+		}
+	}
+
+	newConfig, ok := backendStorageTypes[backendType]
+	if !ok {
+		return nil, fmt.Errorf("failed to derive history storage from backend of %s: unsupported backend type: %s", block.Dir, backendType)
+	}
+
+	suffix := block.KeySuffix
+	if suffix == "" {
+		suffix = defaultKeySuffix
+	}
+	if err := deriveHistoryKey(backendType, attrs, block.Dir, suffix); err != nil {
+		return nil, fmt.Errorf("failed to derive history storage from backend of %s: %w", block.Dir, err)
+	}
+
+	schema, _ := gohcl.ImpliedBodySchema(newConfig())
+	body, err := storageBody(attrs, schema)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] [config] derived history storage from backend of %s: storage %q {\n%s}\n", block.Dir, backendType, maskedAttrNames(attrs))
+
+	return parseStorageBlock(StorageBlock{Type: backendType, Remain: body}, ctx)
+}
+
+// loadBackendBlock reads .tf files in a given directory and returns a type
+// and attributes of the backend block.
+func loadBackendBlock(dir string) (string, map[string]cty.Value, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(files)
+
+	parser := hclparse.NewParser()
+	for _, filename := range files {
+		f, diags := parser.ParseHCLFile(filename)
+		if diags.HasErrors() {
+			return "", nil, diags
+		}
+
+		content, _, diags := f.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+		})
+		if diags.HasErrors() {
+			return "", nil, diags
+		}
+
+		for _, tf := range content.Blocks {
+			inner, _, diags := tf.Body.PartialContent(&hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{
+					{Type: "backend", LabelNames: []string{"type"}},
+					{Type: "cloud"},
+				},
+			})
+			if diags.HasErrors() {
+				return "", nil, diags
+			}
+
+			for _, backend := range inner.Blocks {
+				if backend.Type == "cloud" {
+					attrs, err := evalCloudAttributes(backend.Body)
+					if err != nil {
+						return "", nil, fmt.Errorf("failed to derive history storage from cloud block of %s: %w", dir, err)
+					}
+					return "cloud", attrs, nil
+				}
+
+				attrs, err := evalAttributes(backend.Body)
+				if err != nil {
+					return "", nil, err
+				}
+				return backend.Labels[0], attrs, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("failed to derive history storage: backend block not found in %s", dir)
+}
+
+// parseBackendConfig parses a partial backend configuration, which is either
+// a key=value pair or a path to a file. As well as the -backend-config flag of
+// terraform init, it is a key=value pair if it contains `=`.
+func parseBackendConfig(c string) (map[string]cty.Value, error) {
+	if k, v, ok := strings.Cut(c, "="); ok {
+		// A value is always a string as well as the -backend-config flag of
+		// terraform init. It will be converted to an expected type on decoding.
+		return map[string]cty.Value{k: cty.StringVal(v)}, nil
+	}
+
+	source, err := os.ReadFile(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse backend_config: %s is neither a key=value pair nor a readable file: %w", c, err)
+	}
+	f, diags := hclsyntax.ParseConfig(source, c, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return evalAttributes(f.Body)
+}
+
+// evalAttributes evaluates all attributes in a given body.
+// A backend block cannot refer to any variables, so a nil context is used.
+func evalAttributes(body hcl.Body) (map[string]cty.Value, error) {
+	hclAttrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs := make(map[string]cty.Value, len(hclAttrs))
+	for name, attr := range hclAttrs {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		attrs[name] = v
+	}
+	return attrs, nil
+}
+
+// cloudBlock represents a cloud block in the terraform block.
+type cloudBlock struct {
+	Hostname     string `hcl:"hostname,optional"`
+	Organization string `hcl:"organization,optional"`
+	Token        string `hcl:"token,optional"`
+	Workspaces   *struct {
+		Name   string   `hcl:"name,optional"`
+		Remain hcl.Body `hcl:",remain"`
+	} `hcl:"workspaces,block"`
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// evalCloudAttributes evaluates attributes of a cloud block and flattens a
+// name of the workspaces block into a workspace attribute of the cloud
+// storage. Selecting workspaces by tags is not supported, because the history
+// is stored in a single workspace.
+func evalCloudAttributes(body hcl.Body) (map[string]cty.Value, error) {
+	var b cloudBlock
+	diags := gohcl.DecodeBody(body, nil, &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if b.Workspaces == nil || b.Workspaces.Name == "" {
+		return nil, fmt.Errorf("workspaces.name is required")
+	}
+
+	attrs := map[string]cty.Value{
+		"workspace": cty.StringVal(b.Workspaces.Name),
+	}
+	for name, v := range map[string]string{
+		"hostname":     b.Hostname,
+		"organization": b.Organization,
+		"token":        b.Token,
+	} {
+		if v != "" {
+			attrs[name] = cty.StringVal(v)
+		}
+	}
+	return attrs, nil
+}
+
+// selectedWorkspace returns a name of the workspace selected in a given
+// directory in the same way as terraform, that is, the TF_WORKSPACE
+// environment variable or the .terraform/environment file.
+func selectedWorkspace(dir string) string {
+	if w := os.Getenv("TF_WORKSPACE"); w != "" {
+		return w
+	}
+	b, err := os.ReadFile(filepath.Join(dir, ".terraform", "environment"))
+	if err != nil {
+		return defaultWorkspace
+	}
+	if w := strings.TrimSpace(string(b)); w != "" {
+		return w
+	}
+	return defaultWorkspace
+}
+
+// defaultWorkspace is a name of the default workspace of terraform.
+const defaultWorkspace = "default"
+
+// deriveHistoryKey replaces a key of the state with a key of the history
+// file in given attributes. Note that only the default workspace is
+// considered. Since a history is shared across workspaces, it warns if
+// another workspace is selected.
+func deriveHistoryKey(backendType string, attrs map[string]cty.Value, dir string, suffix string) error {
+	// The cloud block names a workspace explicitly, and the history is stored
+	// as a variable of the workspace, so that neither the selected workspace
+	// nor the suffix matters.
+	if backendType == "cloud" {
+		return nil
+	}
+
+	if w := selectedWorkspace(dir); w != defaultWorkspace {
+		log.Printf("[WARN] [config] workspace %s is selected in %s, but the history key is derived from the state of the default workspace, which is shared across workspaces\n", w, dir)
+	}
+
+	switch backendType {
+	case "s3", "azurerm":
+		key, ok := stringAttr(attrs, "key")
+		if !ok {
+			return fmt.Errorf("key is required")
+		}
+		attrs["key"] = cty.StringVal(key + suffix)
+
+	case "gcs":
+		prefix, _ := stringAttr(attrs, "prefix")
+		delete(attrs, "prefix")
+		attrs["name"] = cty.StringVal(path.Join(prefix, "default.tfstate") + suffix)
+
+	case "local":
+		p, ok := stringAttr(attrs, "path")
+		if !ok {
+			p = "terraform.tfstate"
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		attrs["path"] = cty.StringVal(p + suffix)
+	}
+	return nil
+}
+
+// stringAttr returns a string value of a given attribute if exists.
+func stringAttr(attrs map[string]cty.Value, name string) (string, bool) {
+	v, ok := attrs[name]
+	if !ok || v.IsNull() || !v.Type().Equals(cty.String) {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+// storageBody builds a body of a storage block from given attributes.
+// Attributes which are not supported by the storage are ignored, and object
+// attributes such as assume_role are converted to nested blocks.
+func storageBody(attrs map[string]cty.Value, schema *hcl.BodySchema) (hcl.Body, error) {
+	attrSchema := map[string]bool{}
+	for _, a := range schema.Attributes {
+		attrSchema[a.Name] = true
+	}
+	blockSchema := map[string]bool{}
+	for _, b := range schema.Blocks {
+		blockSchema[b.Type] = true
+	}
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	for _, name := range names {
+		v := attrs[name]
+		switch {
+		case v.IsNull():
+		case attrSchema[name]:
+			body.SetAttributeValue(name, v)
+		case blockSchema[name] && (v.Type().IsObjectType() || v.Type().IsMapType()):
+			nested := body.AppendNewBlock(name, nil).Body()
+			for k, nv := range v.AsValueMap() {
+				nested.SetAttributeValue(k, nv)
+			}
+		default:
+			log.Printf("[DEBUG] [config] ignore an unsupported backend attribute: %s\n", name)
+		}
+	}
+
+	file, diags := hclsyntax.ParseConfig(f.Bytes(), "backend.hcl", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return file.Body, nil
+}
+
+// maskedAttrNames returns a list of attribute names for debug logging.
+// Values are not printed because they may contain credentials.
+func maskedAttrNames(attrs map[string]cty.Value) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, "  "+name+" = (sensitive)\n")
+	}
+	sort.Strings(names)
+	return strings.Join(names, "")
+}
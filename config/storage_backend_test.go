@@ -0,0 +1,434 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/cloud"
+	"github.com/minamijoyo/tfmigrate/storage/gcs"
+	"github.com/minamijoyo/tfmigrate/storage/local"
+	"github.com/minamijoyo/tfmigrate/storage/s3"
+)
+
+func TestParseBackendStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc  string
+		files map[string]string
+		// source is a format string which takes a path to the root module.
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "s3",
+			files: map[string]string{
+				"versions.tf": `
+terraform {
+  required_version = ">= 1.6"
+}
+`,
+				"main.tf": `
+terraform {
+  backend "s3" {
+    bucket         = "tfstate-test"
+    key            = "infra/core/terraform.tfstate"
+    region         = "ap-northeast-1"
+    dynamodb_table = "tfstate-lock"
+    assume_role = {
+      role_arn = "arn:aws:iam::123456789012:role/test"
+    }
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: &s3.Config{
+				Bucket: "tfstate-test",
+				Key:    "infra/core/terraform.tfstate.tfmigrate-history.json",
+				Region: "ap-northeast-1",
+				AssumeRole: &s3.AssumeRole{
+					RoleARN: "arn:aws:iam::123456789012:role/test",
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "partial backend config",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "s3" {
+    key = "terraform.tfstate"
+  }
+}
+`,
+				"prod.tfbackend": `
+bucket = "tfstate-prod"
+region = "ap-northeast-1"
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir            = "%[1]s"
+      key_suffix     = ".history"
+      backend_config = ["%[1]s/prod.tfbackend", "use_path_style=true"]
+    }
+  }
+}
+`,
+			want: &s3.Config{
+				Bucket:       "tfstate-prod",
+				Key:          "terraform.tfstate.history",
+				Region:       "ap-northeast-1",
+				UsePathStyle: true,
+			},
+			ok: true,
+		},
+		{
+			desc: "gcs",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "gcs" {
+    bucket = "tfstate-test"
+    prefix = "infra/core"
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: &gcs.Config{
+				Bucket: "tfstate-test",
+				Name:   "infra/core/default.tfstate.tfmigrate-history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "local",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "local" {
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			// The path is filled in the test body because it depends on the dir.
+			want: &local.Config{},
+			ok:   true,
+		},
+		{
+			desc: "unsupported backend type",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "consul" {
+    path = "tfstate"
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "cloud block",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  cloud {
+    organization = "example"
+    workspaces {
+      name = "core"
+    }
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir            = "%s"
+      backend_config = ["hostname=tfe.example.com"]
+    }
+  }
+}
+`,
+			want: &cloud.Config{
+				Hostname:     "tfe.example.com",
+				Organization: "example",
+				Workspace:    "core",
+			},
+			ok: true,
+		},
+		{
+			desc: "cloud block with workspace tags",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  cloud {
+    organization = "example"
+    workspaces {
+      tags = ["core"]
+    }
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "backend block not found",
+			files: map[string]string{
+				"main.tf": `
+resource "null_resource" "foo" {}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "missing required attribute (key)",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "s3" {
+    bucket = "tfstate-test"
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir = "%s"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "invalid backend_config",
+			files: map[string]string{
+				"main.tf": `
+terraform {
+  backend "s3" {
+    bucket = "tfstate-test"
+    key    = "terraform.tfstate"
+  }
+}
+`,
+			},
+			source: `
+tfmigrate {
+  history {
+    storage "backend" {
+      dir            = "%s"
+      backend_config = ["not-exist.tfbackend"]
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+					t.Fatalf("failed to write file: %s", err)
+				}
+			}
+			if c, ok := tc.want.(*local.Config); ok {
+				c.Path = filepath.Join(dir, "terraform.tfstate.tfmigrate-history.json")
+			}
+
+			source := fmt.Sprintf(tc.source, dir)
+			config, err := ParseConfigurationFile("test.hcl", []byte(source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBackendConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prod.tfbackend")
+	if err := os.WriteFile(file, []byte(`key = "prod/terraform.tfstate"`), 0600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	cases := []struct {
+		desc string
+		c    string
+		want map[string]cty.Value
+		ok   bool
+	}{
+		{
+			desc: "key=value",
+			c:    "use_path_style=true",
+			want: map[string]cty.Value{"use_path_style": cty.StringVal("true")},
+			ok:   true,
+		},
+		{
+			desc: "key=value whose value is a path to a file",
+			c:    "key=" + file,
+			want: map[string]cty.Value{"key": cty.StringVal(file)},
+			ok:   true,
+		},
+		{
+			desc: "file",
+			c:    file,
+			want: map[string]cty.Value{"key": cty.StringVal("prod/terraform.tfstate")},
+			ok:   true,
+		},
+		{
+			desc: "file not found",
+			c:    filepath.Join(dir, "not-exist.tfbackend"),
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := parseBackendConfig(tc.c)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSelectedWorkspace(t *testing.T) {
+	cases := []struct {
+		desc        string
+		env         string
+		environment string
+		want        string
+	}{
+		{
+			desc: "default",
+			want: "default",
+		},
+		{
+			desc: "TF_WORKSPACE",
+			env:  "foo",
+			want: "foo",
+		},
+		{
+			desc:        "environment file",
+			environment: "bar",
+			want:        "bar",
+		},
+		{
+			desc:        "TF_WORKSPACE takes precedence",
+			env:         "foo",
+			environment: "bar",
+			want:        "foo",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("TF_WORKSPACE", tc.env)
+			dir := t.TempDir()
+			if tc.environment != "" {
+				if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0700); err != nil {
+					t.Fatalf("failed to create dir: %s", err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, ".terraform", "environment"), []byte(tc.environment), 0600); err != nil {
+					t.Fatalf("failed to write file: %s", err)
+				}
+			}
+			got := selectedWorkspace(dir)
+			if got != tc.want {
+				t.Errorf("got: %s, want: %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/cloud"
+)
+
+func TestParseCloudStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "cloud" {
+      workspace = "tfmigrate"
+    }
+  }
+}
+`,
+			want: &cloud.Config{
+				Workspace: "tfmigrate",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "cloud" {
+      hostname     = "tfe.example.com"
+      organization = "example"
+      workspace    = "tfmigrate"
+      key          = "history"
+      token        = "token"
+    }
+  }
+}
+`,
+			want: &cloud.Config{
+				Hostname:     "tfe.example.com",
+				Organization: "example",
+				Workspace:    "tfmigrate",
+				Key:          "history",
+				Token:        "token",
+			},
+			ok: true,
+		},
+		{
+			desc: "remote is an alias of cloud",
+			source: `
+tfmigrate {
+  history {
+    storage "remote" {
+      organization = "example"
+      workspace    = "tfmigrate"
+    }
+  }
+}
+`,
+			want: &cloud.Config{
+				Organization: "example",
+				Workspace:    "tfmigrate",
+			},
+			ok: true,
+		},
+		{
+			desc: "missing required attribute (workspace)",
+			source: `
+tfmigrate {
+  history {
+    storage "cloud" {
+      organization = "example"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "cloud" {
+      workspace = "tfmigrate"
+      foo       = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/consul"
+)
+
+func TestParseConsulStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "consul" {
+      path = "tfmigrate/history.json"
+    }
+  }
+}
+`,
+			want: &consul.Config{
+				Path: "tfmigrate/history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "consul" {
+      path         = "tfmigrate/history.json"
+      address      = "consul.example.com:8501"
+      scheme       = "https"
+      access_token = "token"
+      datacenter   = "dc1"
+      http_auth    = "foo:bar"
+      ca_file      = "/path/to/ca.pem"
+      cert_file    = "/path/to/cert.pem"
+      key_file     = "/path/to/key.pem"
+    }
+  }
+}
+`,
+			want: &consul.Config{
+				Path:        "tfmigrate/history.json",
+				Address:     "consul.example.com:8501",
+				Scheme:      "https",
+				AccessToken: "token",
+				Datacenter:  "dc1",
+				HTTPAuth:    "foo:bar",
+				CAFile:      "/path/to/ca.pem",
+				CertFile:    "/path/to/cert.pem",
+				KeyFile:     "/path/to/key.pem",
+			},
+			ok: true,
+		},
+		{
+			desc: "missing required attribute (path)",
+			source: `
+tfmigrate {
+  history {
+    storage "consul" {
+      address = "consul.example.com:8500"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "consul" {
+      path = "tfmigrate/history.json"
+      foo  = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -34,6 +34,39 @@ tfmigrate {
 			},
 			ok: true,
 		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "gcs" {
+      bucket = "tfmigrate-test"
+      name   = "tfmigrate/history.json"
+
+      credentials                           = "/path/to/credentials.json"
+      access_token                          = "token"
+      impersonate_service_account           = "tfmigrate@example.iam.gserviceaccount.com"
+      impersonate_service_account_delegates = ["delegate@example.iam.gserviceaccount.com"]
+      storage_custom_endpoint               = "http://fake-gcs-server:4443/storage/v1/"
+      encryption_key                        = "key"
+      kms_encryption_key                    = "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz"
+    }
+  }
+}
+`,
+			want: &gcs.Config{
+				Bucket:                             "tfmigrate-test",
+				Name:                               "tfmigrate/history.json",
+				Credentials:                        "/path/to/credentials.json",
+				AccessToken:                        "token",
+				ImpersonateServiceAccount:          "tfmigrate@example.iam.gserviceaccount.com",
+				ImpersonateServiceAccountDelegates: []string{"delegate@example.iam.gserviceaccount.com"},
+				StorageCustomEndpoint:              "http://fake-gcs-server:4443/storage/v1/",
+				EncryptionKey:                      "key",
+				KMSEncryptionKey:                   "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz",
+			},
+			ok: true,
+		},
 		{
 			desc: "env vars",
 			env: map[string]string{
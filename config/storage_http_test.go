@@ -0,0 +1,117 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/http"
+)
+
+func TestParseHTTPStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "http" {
+      address = "https://example.com/history"
+    }
+  }
+}
+`,
+			want: &http.Config{
+				Address: "https://example.com/history",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "http" {
+      address                = "https://example.com/history"
+      update_method          = "PUT"
+      lock_address           = "https://example.com/history/lock"
+      lock_method            = "POST"
+      unlock_address         = "https://example.com/history/unlock"
+      unlock_method          = "DELETE"
+      username               = "foo"
+      password               = "bar"
+      headers = {
+        X-Foo = "baz"
+      }
+      skip_cert_verification = true
+    }
+  }
+}
+`,
+			want: &http.Config{
+				Address:              "https://example.com/history",
+				UpdateMethod:         "PUT",
+				LockAddress:          "https://example.com/history/lock",
+				LockMethod:           "POST",
+				UnlockAddress:        "https://example.com/history/unlock",
+				UnlockMethod:         "DELETE",
+				Username:             "foo",
+				Password:             "bar",
+				Headers:              map[string]string{"X-Foo": "baz"},
+				SkipCertVerification: true,
+			},
+			ok: true,
+		},
+		{
+			desc: "missing required attribute (address)",
+			source: `
+tfmigrate {
+  history {
+    storage "http" {
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "http" {
+      address = "https://example.com/history"
+      foo     = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
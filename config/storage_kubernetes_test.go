@@ -0,0 +1,110 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/kubernetes"
+)
+
+func TestParseKubernetesStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "kubernetes" {
+      name = "tfmigrate"
+    }
+  }
+}
+`,
+			want: &kubernetes.Config{
+				Name: "tfmigrate",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "kubernetes" {
+      kind              = "ConfigMap"
+      namespace         = "foo"
+      name              = "tfmigrate"
+      key               = "bar.json"
+      config_path       = "~/.kube/config"
+      config_context    = "baz"
+      in_cluster_config = true
+    }
+  }
+}
+`,
+			want: &kubernetes.Config{
+				Kind:            "ConfigMap",
+				Namespace:       "foo",
+				Name:            "tfmigrate",
+				Key:             "bar.json",
+				ConfigPath:      "~/.kube/config",
+				ConfigContext:   "baz",
+				InClusterConfig: true,
+			},
+			ok: true,
+		},
+		{
+			desc: "missing required attribute (name)",
+			source: `
+tfmigrate {
+  history {
+    storage "kubernetes" {
+      namespace = "foo"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "kubernetes" {
+      name = "tfmigrate"
+      foo  = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/pg"
+)
+
+func TestParsePgStorageBlock(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		want   storage.Config
+		ok     bool
+	}{
+		{
+			desc: "valid (required)",
+			source: `
+tfmigrate {
+  history {
+    storage "pg" {
+      conn_str = "postgres://localhost/tfmigrate"
+    }
+  }
+}
+`,
+			want: &pg.Config{
+				ConnStr: "postgres://localhost/tfmigrate",
+			},
+			ok: true,
+		},
+		{
+			desc: "valid (with optional)",
+			source: `
+tfmigrate {
+  history {
+    storage "pg" {
+      conn_str             = "postgres://localhost/tfmigrate"
+      schema_name          = "foo"
+      table_name           = "bar"
+      name                 = "baz"
+      skip_schema_creation = true
+      skip_table_creation  = true
+    }
+  }
+}
+`,
+			want: &pg.Config{
+				ConnStr:            "postgres://localhost/tfmigrate",
+				SchemaName:         "foo",
+				TableName:          "bar",
+				Name:               "baz",
+				SkipSchemaCreation: true,
+				SkipTableCreation:  true,
+			},
+			ok: true,
+		},
+		{
+			desc: "unknown attribute",
+			source: `
+tfmigrate {
+  history {
+    storage "pg" {
+      conn_str = "postgres://localhost/tfmigrate"
+      foo      = "bar"
+    }
+  }
+}
+`,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := ParseConfigurationFile("test.hcl", []byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", config)
+			}
+			if tc.ok {
+				got := config.History.Storage
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got: %#v, want: %#v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -69,6 +69,70 @@ tfmigrate {
 			},
 			ok: true,
 		},
+		{
+			desc: "valid (assume role and endpoints)",
+			source: `
+tfmigrate {
+  history {
+    storage "s3" {
+      bucket = "tfmigrate-test"
+      key    = "tfmigrate/history.json"
+
+      shared_config_files      = ["/path/to/config"]
+      shared_credentials_files = ["/path/to/credentials"]
+      use_fips_endpoint        = true
+      use_path_style           = true
+      use_lockfile             = true
+
+      assume_role {
+        role_arn     = "arn:aws:iam::123456789012:role/tfmigrate"
+        duration     = "1h"
+        external_id  = "foo"
+        session_name = "bar"
+        tags = {
+          key = "value"
+        }
+      }
+
+      assume_role_with_web_identity {
+        role_arn                = "arn:aws:iam::123456789012:role/tfmigrate-oidc"
+        web_identity_token_file = "/path/to/token"
+      }
+
+      endpoints {
+        s3  = "http://localstack:4566"
+        sts = "http://localstack:4566"
+      }
+    }
+  }
+}
+`,
+			want: &s3.Config{
+				Bucket:                 "tfmigrate-test",
+				Key:                    "tfmigrate/history.json",
+				SharedConfigFiles:      []string{"/path/to/config"},
+				SharedCredentialsFiles: []string{"/path/to/credentials"},
+				UseFIPSEndpoint:        true,
+				UsePathStyle:           true,
+				UseLockfile:            true,
+				AssumeRole: &s3.AssumeRole{
+					RoleARN:     "arn:aws:iam::123456789012:role/tfmigrate",
+					Duration:    "1h",
+					ExternalID:  "foo",
+					SessionName: "bar",
+					Tags:        map[string]string{"key": "value"},
+				},
+				AssumeRoleWithWebIdentity: &s3.AssumeRoleWithWebIdentity{
+					RoleARN:              "arn:aws:iam::123456789012:role/tfmigrate-oidc",
+					WebIdentityTokenFile: "/path/to/token",
+				},
+				Endpoints: &s3.Endpoints{
+					S3:  "http://localstack:4566",
+					STS: "http://localstack:4566",
+				},
+			},
+			ok: true,
+		},
 		{
 			desc: "env vars",
 			env: map[string]string{
@@ -36,8 +36,19 @@ type TfmigrateBlock struct {
 	// ToTfExecPath is a string indicating how terraform command is executed for the destination directory.
 	// Overrides ExecPath for the destination directory when specified.
 	ToTfExecPath string `hcl:"to_tf_exec_path,optional"`
+	// Recursive is a flag to discover migration files in subdirectories of
+	// the migration dir. Default to false.
+	Recursive bool `hcl:"recursive,optional"`
+	// Include is a list of glob patterns for migration files.
+	Include []string `hcl:"include,optional"`
+	// Exclude is a list of glob patterns for files and directories which are
+	// not treated as migrations.
+	Exclude []string `hcl:"exclude,optional"`
 	// History is a block for migration history management.
 	History *HistoryBlock `hcl:"history,block"`
+	// Namespaces is a list of blocks for sets of migrations which share the
+	// history block.
+	Namespaces []NamespaceBlock `hcl:"namespace,block"`
 }
 
 // TfmigrateConfig is a config for top-level CLI settings.
@@ -59,8 +70,19 @@ type TfmigrateConfig struct {
 	// ToTfExecPath is a string indicating how terraform command is executed for the destination directory.
 	// Overrides ExecPath for the destination directory when specified.
 	ToTfExecPath string
+	// Recursive is a flag to discover migration files in subdirectories of
+	// the migration dir.
+	Recursive bool
+	// Include is a list of glob patterns for migration files.
+	Include []string
+	// Exclude is a list of glob patterns for files and directories which are
+	// not treated as migrations.
+	Exclude []string
 	// History is a config for migration history management.
 	History *history.Config
+	// Namespaces is a map of namespace names to configs.
+	// Use WithNamespace to select one of them.
+	Namespaces map[string]*NamespaceConfig
 }
 
 // LoadConfigurationFile is a helper function which reads and parses a given configuration file.
@@ -117,17 +139,53 @@ func ParseConfigurationFile(filename string, source []byte) (*TfmigrateConfig, e
 		config.ToTfExecPath = f.Tfmigrate.ToTfExecPath
 	}
 
+	config.Recursive = f.Tfmigrate.Recursive
+	for _, p := range append(f.Tfmigrate.Include, f.Tfmigrate.Exclude...) {
+		if err := history.ValidateGlob(p); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %s", p, err)
+		}
+	}
+	config.Include = f.Tfmigrate.Include
+	config.Exclude = f.Tfmigrate.Exclude
+
 	if f.Tfmigrate.History != nil {
-		history, err := parseHistoryBlock(*f.Tfmigrate.History, ctx)
+		history, err := parseHistoryBlock(*f.Tfmigrate.History, namespaceEvalContext(ctx, DefaultNamespace))
 		if err != nil {
 			return nil, err
 		}
 		config.History = history
 	}
 
+	if len(f.Tfmigrate.Namespaces) > 0 {
+		namespaces, err := parseNamespaceBlocks(f.Tfmigrate.Namespaces, f.Tfmigrate.History, config.History, ctx)
+		if err != nil {
+			return nil, err
+		}
+		config.Namespaces = namespaces
+	}
+
+	// Migration files are discovered by the history controller, so pass
+	// through the settings for discovery.
+	if config.History != nil {
+		config.setDiscovery(config.History)
+	}
+	for _, ns := range config.Namespaces {
+		if ns.History != nil {
+			config.setDiscovery(ns.History)
+		}
+	}
+
 	return config, nil
 }
 
+// setDiscovery sets settings for discovering migration files to a given
+// history config.
+func (c *TfmigrateConfig) setDiscovery(h *history.Config) {
+	h.Recursive = c.Recursive
+	h.Include = c.Include
+	h.Exclude = c.Exclude
+}
+
 // NewDefaultConfig returns a new instance of TfmigrateConfig.
 func NewDefaultConfig() *TfmigrateConfig {
 	// Get default exec path from environment variable
@@ -103,6 +103,50 @@ tfmigrate {
 			},
 			ok: true,
 		},
+		{
+			desc: "discovery",
+			env:  nil,
+			source: `
+tfmigrate {
+  migration_dir = "tfmigrate"
+  recursive     = true
+  include       = ["*.hcl"]
+  exclude       = ["**/fixtures/**", "draft_*"]
+  history {
+    storage "local" {
+      path = "tmp/history.json"
+    }
+  }
+}
+`,
+			want: &TfmigrateConfig{
+				ExecPath:     "terraform",
+				MigrationDir: "tfmigrate",
+				Recursive:    true,
+				Include:      []string{"*.hcl"},
+				Exclude:      []string{"**/fixtures/**", "draft_*"},
+				History: &history.Config{
+					Storage: &local.Config{
+						Path: "tmp/history.json",
+					},
+					Recursive: true,
+					Include:   []string{"*.hcl"},
+					Exclude:   []string{"**/fixtures/**", "draft_*"},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid glob pattern",
+			env:  nil,
+			source: `
+tfmigrate {
+  exclude = ["["]
+}
+`,
+			want: nil,
+			ok:   false,
+		},
 		{
 			desc: "unknown block",
 			env:  nil,
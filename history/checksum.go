@@ -0,0 +1,16 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// checksumPrefix is a prefix of checksum which indicates a hash algorithm.
+// It allows us to change the algorithm in the future.
+const checksumPrefix = "sha256:"
+
+// Checksum returns a checksum of a given migration file content.
+func Checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return checksumPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package history
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	cases := []struct {
+		desc string
+		b    []byte
+		want string
+	}{
+		{
+			desc: "simple",
+			b:    []byte("foo"),
+			want: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		{
+			desc: "empty",
+			b:    []byte(""),
+			want: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := Checksum(tc.b)
+			if got != tc.want {
+				t.Errorf("got = %s, want = %s", got, tc.want)
+			}
+		})
+	}
+}
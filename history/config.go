@@ -1,6 +1,8 @@
 package history
 
 import (
+	"time"
+
 	"github.com/minamijoyo/tfmigrate/storage"
 )
 
@@ -10,4 +12,30 @@ type Config struct {
 	MigrationDir string
 	// Storage is an interface of factory method for Storage
 	Storage storage.Config
+	// ChecksumMismatch is a behavior when a checksum of an applied migration
+	// file doesn't match the one recorded in history.
+	// Valid values are ChecksumMismatchError or ChecksumMismatchWarn.
+	// Default to ChecksumMismatchError.
+	ChecksumMismatch string
+	// LockTTL is a time-to-live of a lock of the history storage.
+	// A lock older than it is considered stale and can be taken over.
+	// Default to storage.DefaultLockTTL.
+	LockTTL time.Duration
+	// Recursive is a flag to discover migration files in subdirectories.
+	// A migration in a subdirectory is identified by a slash-separated path
+	// relative to the migration dir.
+	Recursive bool
+	// Include is a list of glob patterns for migration files.
+	// If set, only files matching any of them are treated as migrations.
+	Include []string
+	// Exclude is a list of glob patterns for files and directories which are
+	// not treated as migrations.
+	Exclude []string
 }
+
+const (
+	// ChecksumMismatchError fails plan or apply when a checksum mismatches.
+	ChecksumMismatchError = "error"
+	// ChecksumMismatchWarn logs a warning when a checksum mismatches.
+	ChecksumMismatchWarn = "warn"
+)
@@ -2,6 +2,9 @@ package history
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -26,18 +29,30 @@ type Controller struct {
 	history History
 	// config customizes behavior of history management.
 	config Config
+	// version is a version token of the history file loaded from storage.
+	// It is used for compare-and-swap writes if the storage supports it.
+	version string
+	// changes is a list of changes to history which have not been saved yet.
+	// They are replayed onto the latest history when a write conflicts.
+	changes []change
 }
 
+// change is a change to history which can be replayed onto another history.
+type change func(h *History) error
+
+// maxSaveRetries is a maximum number of retries on a write conflict.
+const maxSaveRetries = 3
+
 // NewController returns a new Controller instance.
 func NewController(ctx context.Context, migrationDir string, config *Config) (*Controller, error) {
 	log.Printf("[DEBUG] [history] load migration dir: %s\n", migrationDir)
-	migrations, err := loadMigrationFileNames(migrationDir)
+	migrations, err := loadMigrationFileNames(migrationDir, config)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Print("[DEBUG] [history] load history\n")
-	h, err := loadHistory(ctx, config.Storage)
+	h, version, err := loadHistory(ctx, config.Storage)
 	if err != nil {
 		return nil, err
 	}
@@ -47,33 +62,62 @@ func NewController(ctx context.Context, migrationDir string, config *Config) (*C
 		migrations:   migrations,
 		history:      *h,
 		config:       *config,
+		version:      version,
 	}
 
 	return c, nil
 }
 
-// loadMigrationDir loads a migration directory and lists migration files from local.
+// loadMigrationFileNames loads a migration directory and lists migration
+// files from local. If the config is recursive, it also walks subdirectories
+// and returns slash-separated paths relative to the directory.
 // The returned slice is sorted alphabetically.
-func loadMigrationFileNames(dir string) ([]string, error) {
+func loadMigrationFileNames(dir string, config *Config) ([]string, error) {
 	migrations := []string{}
 
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// skip a hidden directory such as .terraform and excluded one.
+			if !config.Recursive || strings.HasPrefix(d.Name(), ".") || matchAnyGlob(config.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
-	for _, f := range files {
 		// skip a file without .hcl or .json extension.
-		ext := filepath.Ext(f.Name())
+		ext := filepath.Ext(d.Name())
 		if !(ext == ".hcl" || ext == ".json") {
-			continue
+			return nil
 		}
 		// skip a hidden file such as .tfmigrate.hcl or .terraform.lock.hcl.
-		if strings.HasPrefix(f.Name(), ".") {
-			continue
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if len(config.Include) > 0 && !matchAnyGlob(config.Include, rel) {
+			return nil
+		}
+		if matchAnyGlob(config.Exclude, rel) {
+			return nil
 		}
 
-		migrations = append(migrations, f.Name())
+		migrations = append(migrations, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Migrations should be applied in the order of the file name.
@@ -83,16 +127,24 @@ func loadMigrationFileNames(dir string) ([]string, error) {
 
 // loadHistory loads a history file from a storage.
 // If a given history is not found, create a new one.
-func loadHistory(ctx context.Context, c storage.Config) (*History, error) {
+// If the storage supports versioning, it also returns a version token.
+// Otherwise, the version token is always empty.
+func loadHistory(ctx context.Context, c storage.Config) (*History, string, error) {
 	s, err := c.NewStorage()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	log.Printf("[DEBUG] [history] read storage %#v\n", s)
-	b, err := s.Read(ctx)
+	var b []byte
+	var version string
+	if vs, ok := s.(storage.VersionedStorage); ok {
+		b, version, err = vs.ReadWithVersion(ctx)
+	} else {
+		b, err = s.Read(ctx)
+	}
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	log.Printf("[TRACE] [history] read history file: %#v\n", b)
 
@@ -100,33 +152,105 @@ func loadHistory(ctx context.Context, c storage.Config) (*History, error) {
 	// In this case, we assume that it's the first use and create a new history.
 	if len(b) == 0 {
 		log.Print("[DEBUG] [history] new empty history\n")
-		return newEmptyHistory(), nil
+		return newEmptyHistory(), version, nil
 	}
 
 	h, err := ParseHistoryFile(b)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return h, nil
+	return h, version, nil
+}
+
+// LoadHistory loads a history file from a given storage.
+// It is intended to read history from another storage such as for import.
+// If the history is not found, it returns an empty history.
+func LoadHistory(ctx context.Context, c storage.Config) (*History, error) {
+	h, _, err := loadHistory(ctx, c)
+	return h, err
 }
 
 // Save persists a current state of historyFile to storage.
+// If the storage supports versioning, it writes history only if nobody else
+// has updated it since loaded. On conflict, it reloads the latest history,
+// replays unsaved changes onto it and retries. If the changes cannot be
+// merged, such as the same migration has been applied by someone else,
+// it returns an error instead of losing the other's records.
 func (c *Controller) Save(ctx context.Context) error {
 	s, err := c.config.Storage.NewStorage()
 	if err != nil {
 		return err
 	}
 
-	f := newFileV1(c.history)
+	vs, ok := s.(storage.VersionedStorage)
+	if !ok {
+		b, err := c.serialize()
+		if err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] [history] write storage: %#v\n", s)
+		if err := s.Write(ctx, b); err != nil {
+			return err
+		}
+		c.changes = nil
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		b, err := c.serialize()
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] [history] write storage with version %q: %#v\n", c.version, s)
+		version, err := vs.WriteWithVersion(ctx, b, c.version)
+		if err == nil {
+			c.version = version
+			c.changes = nil
+			return nil
+		}
+		if !errors.Is(err, storage.ErrVersionConflict) || attempt >= maxSaveRetries {
+			return err
+		}
+
+		log.Printf("[WARN] [history] history has been updated by someone else. Merge changes and retry: %s\n", err)
+		if err := c.rebase(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// serialize encodes the current history to bytes.
+// Always save in the latest format.
+// If a history file v1 was loaded, it is upgraded here.
+func (c *Controller) serialize() ([]byte, error) {
+	f := newFileV2(c.history)
 	b, err := f.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[TRACE] [history] write history file: %#v\n", b)
+	return b, nil
+}
+
+// rebase reloads the latest history from storage and replays unsaved changes
+// onto it.
+func (c *Controller) rebase(ctx context.Context) error {
+	h, version, err := loadHistory(ctx, c.config.Storage)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("[DEBUG] [history] write storage: %#v\n", s)
-	log.Printf("[TRACE] [history] write history file: %#v\n", b)
-	return s.Write(ctx, b)
+	for _, change := range c.changes {
+		if err := change(h); err != nil {
+			return fmt.Errorf("failed to merge history: %w", err)
+		}
+	}
+
+	c.history = *h
+	c.version = version
+	return nil
 }
 
 // Migrations returns a list of all migration file names.
@@ -161,6 +285,13 @@ func (c *Controller) AlreadyApplied(filename string) bool {
 // This method doesn't persist history. Call Save() to save the history.
 // If appliedAt is nil, a timestamp is automatically set to time.Now().
 func (c *Controller) AddRecord(filename string, migrationType string, name string, appliedAt *time.Time) {
+	c.AddRecordWithMetadata(filename, migrationType, name, appliedAt, nil)
+}
+
+// AddRecordWithMetadata adds a record with an audit log to history.
+// This method doesn't persist history. Call Save() to save the history.
+// If appliedAt is nil, a timestamp is automatically set to time.Now().
+func (c *Controller) AddRecordWithMetadata(filename string, migrationType string, name string, appliedAt *time.Time, metadata *Metadata) {
 	timestamp := appliedAt
 	if timestamp == nil {
 		now := time.Now()
@@ -170,12 +301,130 @@ func (c *Controller) AddRecord(filename string, migrationType string, name strin
 		Type:      migrationType,
 		Name:      name,
 		AppliedAt: *timestamp,
+		Checksum:  c.fileChecksum(filename),
+		Metadata:  metadata,
+	}
+
+	c.history.Add(filename, r)
+	c.changes = append(c.changes, func(h *History) error {
+		if h.Contains(filename) {
+			return fmt.Errorf("a migration has already been applied by someone else: %s", filename)
+		}
+		h.Add(filename, r)
+		return nil
+	})
+}
+
+// fileChecksum returns a checksum of a given migration file.
+// If the file cannot be read, returns an empty string.
+func (c *Controller) fileChecksum(filename string) string {
+	b, err := os.ReadFile(filepath.Join(c.migrationDir, filepath.FromSlash(filename)))
+	if err != nil {
+		log.Printf("[DEBUG] [history] failed to read migration file for checksum: %s\n", err)
+		return ""
 	}
+	return Checksum(b)
+}
 
+// SetChecksum updates a checksum of a record in history.
+// This method doesn't persist history. Call Save() to save the history.
+// If a given filename doesn't exist, no-op.
+func (c *Controller) SetChecksum(filename string, checksum string) {
+	r, ok := c.history.records[filename]
+	if !ok {
+		return
+	}
+	r.Checksum = checksum
 	c.history.Add(filename, r)
+	c.changes = append(c.changes, func(h *History) error {
+		if latest, ok := h.records[filename]; ok {
+			latest.Checksum = checksum
+			h.Add(filename, latest)
+		}
+		return nil
+	})
 }
 
 // Records returns the history records map
 func (c *Controller) Records() map[string]Record {
 	return c.history.records
 }
+
+// DeleteRecord deletes a record from history.
+// This method doesn't persist history. Call Save() to save the history.
+// If a given filename doesn't exist, no-op.
+func (c *Controller) DeleteRecord(filename string) {
+	c.history.Delete(filename)
+	c.changes = append(c.changes, func(h *History) error {
+		h.Delete(filename)
+		return nil
+	})
+}
+
+// Import merges records of a given history into the current history, and
+// returns a list of imported file names. If the same migration is recorded
+// with a different name or applied_at, it returns an error.
+// This method doesn't persist history. Call Save() to save the history.
+func (c *Controller) Import(h *History) ([]string, error) {
+	imported, err := c.history.Merge(h)
+	if err != nil {
+		return nil, err
+	}
+
+	c.changes = append(c.changes, func(latest *History) error {
+		_, err := latest.Merge(h)
+		return err
+	})
+	return imported, nil
+}
+
+// Export encodes the current history to bytes in a given file format version.
+// The version 1 format doesn't have checksums and metadata, so they are
+// dropped. It is intended for downgrading tfmigrate.
+func (c *Controller) Export(version int) ([]byte, error) {
+	switch version {
+	case 1:
+		return newFileV1(c.history).Serialize()
+
+	case 2:
+		return c.serialize()
+
+	default:
+		return nil, fmt.Errorf("unknown history file version: %d", version)
+	}
+}
+
+// LatestAppliedMigration returns a migration file name which has been applied
+// most recently. If no migration has been applied, it returns false.
+// If multiple records have the same timestamp, the last one in alphabetical
+// order of the file name is returned.
+func (c *Controller) LatestAppliedMigration() (string, bool) {
+	latest := ""
+	var latestAt time.Time
+	for filename, r := range c.history.records {
+		if latest == "" || r.AppliedAt.After(latestAt) || (r.AppliedAt.Equal(latestAt) && filename > latest) {
+			latest = filename
+			latestAt = r.AppliedAt
+		}
+	}
+	return latest, latest != ""
+}
+
+// MissingMigrations returns a list of migration file names which are recorded
+// in history but don't exist in the migration dir.
+// The returned slice is sorted alphabetically.
+func (c *Controller) MissingMigrations() []string {
+	local := make(map[string]bool)
+	for _, m := range c.migrations {
+		local[m] = true
+	}
+
+	missing := []string{}
+	for filename := range c.history.records {
+		if !local[filename] {
+			missing = append(missing, filename)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
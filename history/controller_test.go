@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -15,10 +16,11 @@ import (
 
 func TestLoadMigrationFileNames(t *testing.T) {
 	cases := []struct {
-		desc  string
-		files []string
-		want  []string
-		ok    bool
+		desc   string
+		files  []string
+		config *Config
+		want   []string
+		ok     bool
 	}{
 		{
 			desc: "hcl",
@@ -77,6 +79,72 @@ func TestLoadMigrationFileNames(t *testing.T) {
 			want:  []string{},
 			ok:    true,
 		},
+		{
+			desc: "ignore subdirectories",
+			files: []string{
+				"20201012010101_foo.hcl",
+				"2020q4/20201012020202_foo.hcl",
+			},
+			want: []string{
+				"20201012010101_foo.hcl",
+			},
+			ok: true,
+		},
+		{
+			desc: "recursive",
+			files: []string{
+				"20201012030303_foo.hcl",
+				"2020q4/20201012020202_foo.hcl",
+				"2020q4/team-a/20201012010101_foo.json",
+				".terraform/20201012040404_foo.hcl",
+			},
+			config: &Config{
+				Recursive: true,
+			},
+			want: []string{
+				"20201012030303_foo.hcl",
+				"2020q4/20201012020202_foo.hcl",
+				"2020q4/team-a/20201012010101_foo.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "include",
+			files: []string{
+				"20201012010101_foo.hcl",
+				"20201012020202_foo.json",
+				"2020q4/20201012030303_foo.hcl",
+			},
+			config: &Config{
+				Recursive: true,
+				Include:   []string{"*.hcl"},
+			},
+			want: []string{
+				"20201012010101_foo.hcl",
+				"2020q4/20201012030303_foo.hcl",
+			},
+			ok: true,
+		},
+		{
+			desc: "exclude",
+			files: []string{
+				"20201012010101_foo.hcl",
+				"README.json",
+				"draft_foo.hcl",
+				"fixtures/20201012020202_foo.hcl",
+				"2020q4/fixtures/20201012030303_foo.hcl",
+				"2020q4/20201012040404_foo.hcl",
+			},
+			config: &Config{
+				Recursive: true,
+				Exclude:   []string{"README.json", "draft_*", "fixtures", "**/fixtures/**"},
+			},
+			want: []string{
+				"20201012010101_foo.hcl",
+				"2020q4/20201012040404_foo.hcl",
+			},
+			ok: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -88,13 +156,21 @@ func TestLoadMigrationFileNames(t *testing.T) {
 			t.Cleanup(func() { os.RemoveAll(migrationDir) })
 
 			for _, filename := range tc.files {
-				err = os.WriteFile(filepath.Join(migrationDir, filename), []byte{}, 0600)
+				path := filepath.Join(migrationDir, filepath.FromSlash(filename))
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatalf("failed to create dir: %s", err)
+				}
+				err = os.WriteFile(path, []byte{}, 0600)
 				if err != nil {
 					t.Fatalf("failed to write dummy migration file: %s", err)
 				}
 			}
 
-			got, err := loadMigrationFileNames(migrationDir)
+			config := tc.config
+			if config == nil {
+				config = &Config{}
+			}
+			got, err := loadMigrationFileNames(migrationDir, config)
 
 			if tc.ok && err != nil {
 				t.Fatalf("unexpected err: %#v", err)
@@ -190,7 +266,7 @@ func TestLoadHistory(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			got, err := loadHistory(context.Background(), tc.config)
+			got, _, err := loadHistory(context.Background(), tc.config)
 			if tc.ok && err != nil {
 				t.Fatalf("unexpected err: %#v", err)
 			}
@@ -224,8 +300,36 @@ func TestControllerSave(t *testing.T) {
 			},
 			h: newEmptyHistory(),
 			want: []byte(`{
-    "version": 1,
+    "version": 2,
     "records": {}
+}`),
+			ok: true,
+		},
+		{
+			desc: "upgrade records without metadata",
+			config: &mock.Config{
+				Data:       "",
+				WriteError: false,
+				ReadError:  false,
+			},
+			h: &History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			want: []byte(`{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z"
+        }
+    }
 }`),
 			ok: true,
 		},
@@ -238,7 +342,7 @@ func TestControllerSave(t *testing.T) {
 			},
 			h: newEmptyHistory(),
 			want: []byte(`{
-    "version": 1,
+    "version": 2,
     "records": {}
 }`),
 			ok: false,
@@ -271,6 +375,145 @@ func TestControllerSave(t *testing.T) {
 	}
 }
 
+func TestControllerSaveConflict(t *testing.T) {
+	appliedAt := time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC)
+	cases := []struct {
+		desc string
+		// theirs is a change saved by someone else between load and save.
+		theirs func(t *testing.T, c *Controller)
+		// ours is a change saved by us.
+		ours func(t *testing.T, c *Controller)
+		want []string
+		ok   bool
+	}{
+		{
+			desc: "merge records added by both sides",
+			theirs: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", &appliedAt)
+			},
+			ours: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012030303_baz.hcl", "state", "baz", &appliedAt)
+			},
+			want: []string{"20201012010101_foo.hcl", "20201012020202_bar.hcl", "20201012030303_baz.hcl"},
+			ok:   true,
+		},
+		{
+			desc: "merge a deleted record",
+			theirs: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", &appliedAt)
+			},
+			ours: func(t *testing.T, c *Controller) {
+				c.DeleteRecord("20201012010101_foo.hcl")
+			},
+			want: []string{"20201012020202_bar.hcl"},
+			ok:   true,
+		},
+		{
+			desc: "the same migration applied by both sides",
+			theirs: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", &appliedAt)
+			},
+			ours: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", &appliedAt)
+			},
+			want: []string{"20201012010101_foo.hcl", "20201012020202_bar.hcl"},
+			ok:   false,
+		},
+		{
+			desc: "merge imported records",
+			theirs: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", &appliedAt)
+			},
+			ours: func(t *testing.T, c *Controller) {
+				h := newEmptyHistory()
+				h.Add("20201012020202_bar.hcl", Record{Type: "state", Name: "bar", AppliedAt: appliedAt})
+				h.Add("20201012030303_baz.hcl", Record{Type: "state", Name: "baz", AppliedAt: appliedAt})
+				if _, err := c.Import(h); err != nil {
+					t.Fatalf("failed to import: %s", err)
+				}
+			},
+			want: []string{"20201012010101_foo.hcl", "20201012020202_bar.hcl", "20201012030303_baz.hcl"},
+			ok:   true,
+		},
+		{
+			desc: "imported records conflict",
+			theirs: func(t *testing.T, c *Controller) {
+				c.AddRecord("20201012020202_bar.hcl", "state", "bar", nil)
+			},
+			ours: func(t *testing.T, c *Controller) {
+				h := newEmptyHistory()
+				h.Add("20201012020202_bar.hcl", Record{Type: "state", Name: "bar", AppliedAt: appliedAt})
+				if _, err := c.Import(h); err != nil {
+					t.Fatalf("failed to import: %s", err)
+				}
+			},
+			want: []string{"20201012010101_foo.hcl", "20201012020202_bar.hcl"},
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			migrationDir := t.TempDir()
+			for _, filename := range []string{"20201012010101_foo.hcl", "20201012020202_bar.hcl", "20201012030303_baz.hcl"} {
+				if err := os.WriteFile(filepath.Join(migrationDir, filename), []byte{}, 0600); err != nil {
+					t.Fatalf("failed to write migration file: %s", err)
+				}
+			}
+			config := &Config{
+				Storage: &mock.Config{
+					Data: `{
+    "version": 1,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z"
+        }
+    }
+}`,
+				},
+			}
+
+			ours, err := NewController(context.Background(), migrationDir, config)
+			if err != nil {
+				t.Fatalf("failed to new controller: %s", err)
+			}
+			theirs, err := NewController(context.Background(), migrationDir, config)
+			if err != nil {
+				t.Fatalf("failed to new controller: %s", err)
+			}
+
+			tc.theirs(t, theirs)
+			if err := theirs.Save(context.Background()); err != nil {
+				t.Fatalf("failed to save: %s", err)
+			}
+
+			tc.ours(t, ours)
+			err = ours.Save(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			latest, err := NewController(context.Background(), migrationDir, config)
+			if err != nil {
+				t.Fatalf("failed to new controller: %s", err)
+			}
+			got := []string{}
+			for filename := range latest.Records() {
+				got = append(got, filename)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("got: %v, want: %v, diff: %s", got, tc.want, diff)
+			}
+		})
+	}
+}
+
 func TestUnappliedMigrations(t *testing.T) {
 	cases := []struct {
 		desc       string
@@ -555,3 +798,374 @@ func TestControllerAddRecord(t *testing.T) {
 		})
 	}
 }
+
+func TestControllerDeleteRecord(t *testing.T) {
+	cases := []struct {
+		desc     string
+		history  History
+		filename string
+		want     History
+	}{
+		{
+			desc: "delete",
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+				},
+			},
+			filename: "20201012020202_foo.hcl",
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			desc: "not found",
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			filename: "20201012020202_foo.hcl",
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &Controller{
+				history: tc.history,
+			}
+			c.DeleteRecord(tc.filename)
+			got := c.history
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(got)); diff != "" {
+				t.Errorf("got = %#v, want = %#v, diff = %s", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestControllerLatestAppliedMigration(t *testing.T) {
+	cases := []struct {
+		desc    string
+		history History
+		want    string
+		ok      bool
+	}{
+		{
+			desc: "simple",
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+					"20201012030303_foo.hcl": Record{
+						Type:      "state",
+						Name:      "baz",
+						AppliedAt: time.Date(2020, 10, 13, 2, 3, 4, 0, time.UTC),
+					},
+				},
+			},
+			want: "20201012020202_foo.hcl",
+			ok:   true,
+		},
+		{
+			desc: "same timestamp",
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			want: "20201012020202_foo.hcl",
+			ok:   true,
+		},
+		{
+			desc:    "empty",
+			history: *newEmptyHistory(),
+			want:    "",
+			ok:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &Controller{
+				history: tc.history,
+			}
+			got, ok := c.LatestAppliedMigration()
+			if ok != tc.ok {
+				t.Fatalf("got ok = %t, want ok = %t", ok, tc.ok)
+			}
+			if got != tc.want {
+				t.Errorf("got = %s, want = %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestControllerMissingMigrations(t *testing.T) {
+	cases := []struct {
+		desc       string
+		migrations []string
+		history    History
+		want       []string
+	}{
+		{
+			desc: "simple",
+			migrations: []string{
+				"20201012020202_foo.hcl",
+				"20201012030303_foo.hcl",
+			},
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+					"20201012000000_foo.hcl": Record{
+						Type:      "state",
+						Name:      "baz",
+						AppliedAt: time.Date(2020, 10, 13, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+			want: []string{
+				"20201012000000_foo.hcl",
+				"20201012010101_foo.hcl",
+			},
+		},
+		{
+			desc: "no missing",
+			migrations: []string{
+				"20201012010101_foo.hcl",
+			},
+			history: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &Controller{
+				migrations: tc.migrations,
+				history:    tc.history,
+			}
+			got := c.MissingMigrations()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got = %#v, want = %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestControllerAddRecordWithChecksum(t *testing.T) {
+	migrationDir := t.TempDir()
+	filename := "20201012010101_foo.hcl"
+	if err := os.WriteFile(filepath.Join(migrationDir, filename), []byte("foo"), 0644); err != nil {
+		t.Fatalf("failed to write migration file: %s", err)
+	}
+
+	c := &Controller{
+		migrationDir: migrationDir,
+		migrations:   []string{filename},
+		history:      *newEmptyHistory(),
+	}
+
+	c.AddRecord(filename, "state", "foo", nil)
+	got := c.Records()[filename].Checksum
+	want := Checksum([]byte("foo"))
+	if got != want {
+		t.Errorf("got = %s, want = %s", got, want)
+	}
+}
+
+func TestControllerSetChecksum(t *testing.T) {
+	cases := []struct {
+		desc     string
+		filename string
+		want     History
+	}{
+		{
+			desc:     "update",
+			filename: "20201012010101_foo.hcl",
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+						Checksum:  "sha256:new",
+					},
+				},
+			},
+		},
+		{
+			desc:     "not found",
+			filename: "20201012020202_foo.hcl",
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+						Checksum:  "sha256:old",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &Controller{
+				history: History{
+					records: map[string]Record{
+						"20201012010101_foo.hcl": Record{
+							Type:      "state",
+							Name:      "foo",
+							AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+							Checksum:  "sha256:old",
+						},
+					},
+				},
+			}
+
+			c.SetChecksum(tc.filename, "sha256:new")
+			got := c.history
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(got)); diff != "" {
+				t.Errorf("got = %#v, want = %#v, diff = %s", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestControllerExport(t *testing.T) {
+	c := &Controller{
+		history: History{
+			records: map[string]Record{
+				"20201012010101_foo.hcl": Record{
+					Type:      "state",
+					Name:      "foo",
+					AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					Checksum:  "sha256:foo",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc    string
+		version int
+		want    string
+		ok      bool
+	}{
+		{
+			desc:    "v1",
+			version: 1,
+			want: `{
+    "version": 1,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z"
+        }
+    }
+}`,
+			ok: true,
+		},
+		{
+			desc:    "v2",
+			version: 2,
+			want: `{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z",
+            "checksum": "sha256:foo"
+        }
+    }
+}`,
+			ok: true,
+		},
+		{
+			desc:    "unknown",
+			version: 3,
+			want:    "",
+			ok:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := c.Export(tc.version)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if string(got) != tc.want {
+				t.Errorf("got = %s, want = %s", string(got), tc.want)
+			}
+		})
+	}
+}
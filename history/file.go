@@ -22,6 +22,9 @@ func ParseHistoryFile(b []byte) (*History, error) {
 	case 1:
 		return parseHistoryFileV1(b)
 
+	case 2:
+		return parseHistoryFileV2(b)
+
 	default:
 		return nil, fmt.Errorf("unknown history file version: %d", version)
 	}
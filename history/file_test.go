@@ -47,6 +47,37 @@ func TestParseHistoryFile(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			desc: "v2",
+			b: []byte(`{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z",
+            "metadata": {
+                "applied_by": "alice",
+                "duration": "1.5s"
+            }
+        }
+    }
+}`),
+			want: &History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+						Metadata: &Metadata{
+							AppliedBy: "alice",
+							Duration:  1500 * time.Millisecond,
+						},
+					},
+				},
+			},
+			ok: true,
+		},
 		{
 			desc: "unknown version",
 			b: []byte(`{
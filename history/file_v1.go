@@ -45,8 +45,13 @@ func newFileV1(h History) *FileV1 {
 }
 
 // newRecordV1 converts a Record to a RecordV1 instance.
+// Note that the v1 format doesn't have metadata, so it is dropped.
 func newRecordV1(r Record) RecordV1 {
-	return RecordV1(r)
+	return RecordV1{
+		Type:      r.Type,
+		Name:      r.Name,
+		AppliedAt: r.AppliedAt,
+	}
 }
 
 // Serialize encodes a FileV1 instance to bytes.
@@ -82,5 +87,9 @@ func (f *FileV1) toHistory() History {
 
 // toRecord converts a RecordV1 to a Record instance.
 func (r RecordV1) toRecord() Record {
-	return Record(r)
+	return Record{
+		Type:      r.Type,
+		Name:      r.Name,
+		AppliedAt: r.AppliedAt,
+	}
 }
@@ -0,0 +1,201 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FileV2 represents a data structure for history file format v2.
+// The v2 format adds an audit log of each applied migration to v1.
+type FileV2 struct {
+	// Version is a file format version. It is always set to 2.
+	Version int `json:"version"`
+	// Records is a set of applied migration log.
+	// Only success migrations are recorded.
+	// A key is migration file name.
+	// We record only the file name not to invalidate history when the migration
+	// directory is moved.
+	Records map[string]RecordV2 `json:"records"`
+}
+
+// RecordV2 represents an applied migration log.
+type RecordV2 struct {
+	// Type is a migration type.
+	Type string `json:"type"`
+	// Name is a migration name.
+	Name string `json:"name"`
+	// AppliedAt is a timestamp when the migration was applied.
+	// Note that we only record it when the migration was succeed.
+	AppliedAt time.Time `json:"applied_at"`
+	// Checksum is a checksum of the migration file content when applied.
+	Checksum string `json:"checksum,omitempty"`
+	// Metadata is an audit log of the applied migration.
+	// It is omitted for records upgraded from v1.
+	Metadata *MetadataV2 `json:"metadata,omitempty"`
+}
+
+// MetadataV2 represents an audit log of an applied migration.
+type MetadataV2 struct {
+	// AppliedBy is an identity of the user or CI who applied the migration.
+	AppliedBy string `json:"applied_by,omitempty"`
+	// TfmigrateVersion is a version of tfmigrate.
+	TfmigrateVersion string `json:"tfmigrate_version,omitempty"`
+	// TerraformVersion is a version of terraform command.
+	TerraformVersion string `json:"terraform_version,omitempty"`
+	// GitCommit is a commit hash of the migration dir.
+	GitCommit string `json:"git_commit,omitempty"`
+	// Actions is a list of concrete actions applied to states.
+	Actions []string `json:"actions,omitempty"`
+	// States is a list of states touched by the migration.
+	States []StateMetadataV2 `json:"states,omitempty"`
+	// Duration is a time taken to apply the migration such as "1m30s".
+	Duration string `json:"duration,omitempty"`
+}
+
+// StateMetadataV2 represents a state touched by a migration.
+type StateMetadataV2 struct {
+	// Dir is a working directory of the state.
+	Dir string `json:"dir"`
+	// Workspace is a workspace of the state.
+	Workspace string `json:"workspace"`
+	// SerialBefore is a serial number of the state before the migration.
+	SerialBefore int64 `json:"serial_before"`
+	// SerialAfter is a serial number of the state after the migration.
+	SerialAfter int64 `json:"serial_after"`
+}
+
+// newFileV2 converts a History to a FileV2 instance.
+func newFileV2(h History) *FileV2 {
+	m := make(map[string]RecordV2)
+	for k, v := range h.records {
+		r := newRecordV2(v)
+		m[k] = r
+	}
+
+	return &FileV2{
+		Version: 2,
+		Records: m,
+	}
+}
+
+// newRecordV2 converts a Record to a RecordV2 instance.
+func newRecordV2(r Record) RecordV2 {
+	return RecordV2{
+		Type:      r.Type,
+		Name:      r.Name,
+		AppliedAt: r.AppliedAt,
+		Checksum:  r.Checksum,
+		Metadata:  newMetadataV2(r.Metadata),
+	}
+}
+
+// newMetadataV2 converts a Metadata to a MetadataV2 instance.
+func newMetadataV2(m *Metadata) *MetadataV2 {
+	if m == nil {
+		return nil
+	}
+
+	var states []StateMetadataV2
+	for _, s := range m.States {
+		states = append(states, StateMetadataV2(s))
+	}
+
+	duration := ""
+	if m.Duration != 0 {
+		duration = m.Duration.String()
+	}
+
+	return &MetadataV2{
+		AppliedBy:        m.AppliedBy,
+		TfmigrateVersion: m.TfmigrateVersion,
+		TerraformVersion: m.TerraformVersion,
+		GitCommit:        m.GitCommit,
+		Actions:          m.Actions,
+		States:           states,
+		Duration:         duration,
+	}
+}
+
+// Serialize encodes a FileV2 instance to bytes.
+func (f *FileV2) Serialize() ([]byte, error) {
+	return json.MarshalIndent(f, "", "    ")
+}
+
+// parseHistoryFileV2 parses bytes and reteurns a History instance.
+func parseHistoryFileV2(b []byte) (*History, error) {
+	var f FileV2
+
+	err := json.Unmarshal(b, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := f.toHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// toHistory converts a FileV2 to a History instance.
+func (f *FileV2) toHistory() (History, error) {
+	m := make(map[string]Record)
+	for k, v := range f.Records {
+		r, err := v.toRecord()
+		if err != nil {
+			return History{}, err
+		}
+		m[k] = r
+	}
+	return History{
+		records: m,
+	}, nil
+}
+
+// toRecord converts a RecordV2 to a Record instance.
+func (r RecordV2) toRecord() (Record, error) {
+	metadata, err := r.Metadata.toMetadata()
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		Type:      r.Type,
+		Name:      r.Name,
+		AppliedAt: r.AppliedAt,
+		Checksum:  r.Checksum,
+		Metadata:  metadata,
+	}, nil
+}
+
+// toMetadata converts a MetadataV2 to a Metadata instance.
+func (m *MetadataV2) toMetadata() (*Metadata, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	var states []StateMetadata
+	for _, s := range m.States {
+		states = append(states, StateMetadata(s))
+	}
+
+	var duration time.Duration
+	if m.Duration != "" {
+		d, err := time.ParseDuration(m.Duration)
+		if err != nil {
+			return nil, err
+		}
+		duration = d
+	}
+
+	return &Metadata{
+		AppliedBy:        m.AppliedBy,
+		TfmigrateVersion: m.TfmigrateVersion,
+		TerraformVersion: m.TerraformVersion,
+		GitCommit:        m.GitCommit,
+		Actions:          m.Actions,
+		States:           states,
+		Duration:         duration,
+	}, nil
+}
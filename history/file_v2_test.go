@@ -0,0 +1,273 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFileV2(t *testing.T) {
+	cases := []struct {
+		desc string
+		h    History
+		want *FileV2
+	}{
+		{
+			desc: "simple",
+			h: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "multi_state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+						Metadata: &Metadata{
+							AppliedBy:        "alice",
+							TfmigrateVersion: "0.4.2",
+							TerraformVersion: "1.9.8",
+							GitCommit:        "0123456789abcdef",
+							Actions:          []string{"mv null_resource.foo null_resource.foo2"},
+							States: []StateMetadata{
+								{Dir: "dir1", Workspace: "default", SerialBefore: 1, SerialAfter: 2},
+								{Dir: "dir2", Workspace: "default", SerialBefore: 3, SerialAfter: 4},
+							},
+							Duration: 90 * time.Second,
+						},
+					},
+				},
+			},
+			want: &FileV2{
+				Version: 2,
+				Records: map[string]RecordV2{
+					"20201012010101_foo.hcl": RecordV2{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": RecordV2{
+						Type:      "multi_state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+						Metadata: &MetadataV2{
+							AppliedBy:        "alice",
+							TfmigrateVersion: "0.4.2",
+							TerraformVersion: "1.9.8",
+							GitCommit:        "0123456789abcdef",
+							Actions:          []string{"mv null_resource.foo null_resource.foo2"},
+							States: []StateMetadataV2{
+								{Dir: "dir1", Workspace: "default", SerialBefore: 1, SerialAfter: 2},
+								{Dir: "dir2", Workspace: "default", SerialBefore: 3, SerialAfter: 4},
+							},
+							Duration: "1m30s",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := newFileV2(tc.h)
+
+			if diff := cmp.Diff(*got, *tc.want, cmp.AllowUnexported(*got)); diff != "" {
+				t.Errorf("got = %#v, want = %#v, diff = %s", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestFileV2Serialize(t *testing.T) {
+	cases := []struct {
+		desc string
+		f    FileV2
+		want string
+	}{
+		{
+			desc: "simple",
+			f: FileV2{
+				Version: 2,
+				Records: map[string]RecordV2{
+					"20201012010101_foo.hcl": RecordV2{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": RecordV2{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+						Metadata: &MetadataV2{
+							AppliedBy:        "alice",
+							TfmigrateVersion: "0.4.2",
+							TerraformVersion: "1.9.8",
+							Actions:          []string{"rm null_resource.foo"},
+							States: []StateMetadataV2{
+								{Dir: "dir1", Workspace: "default", SerialBefore: 1, SerialAfter: 2},
+							},
+							Duration: "2s",
+						},
+					},
+				},
+			},
+			want: `{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z"
+        },
+        "20201012020202_foo.hcl": {
+            "type": "state",
+            "name": "bar",
+            "applied_at": "2020-10-13T04:05:06Z",
+            "metadata": {
+                "applied_by": "alice",
+                "tfmigrate_version": "0.4.2",
+                "terraform_version": "1.9.8",
+                "actions": [
+                    "rm null_resource.foo"
+                ],
+                "states": [
+                    {
+                        "dir": "dir1",
+                        "workspace": "default",
+                        "serial_before": 1,
+                        "serial_after": 2
+                    }
+                ],
+                "duration": "2s"
+            }
+        }
+    }
+}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.f.Serialize()
+			if err != nil {
+				t.Fatalf("failed to serialize: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got = %s, want = %s", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseHistoryFileV2(t *testing.T) {
+	cases := []struct {
+		desc string
+		b    []byte
+		want *History
+		ok   bool
+	}{
+		{
+			desc: "valid",
+			b: []byte(`{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z"
+        },
+        "20201012020202_foo.hcl": {
+            "type": "state",
+            "name": "bar",
+            "applied_at": "2020-10-13T04:05:06Z",
+            "metadata": {
+                "applied_by": "alice",
+                "git_commit": "0123456789abcdef",
+                "actions": [
+                    "mv null_resource.foo null_resource.foo2"
+                ],
+                "states": [
+                    {
+                        "dir": "dir1",
+                        "workspace": "default",
+                        "serial_before": 1,
+                        "serial_after": 2
+                    }
+                ],
+                "duration": "2s"
+            }
+        }
+    }
+}`),
+			want: &History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_foo.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+						Metadata: &Metadata{
+							AppliedBy: "alice",
+							GitCommit: "0123456789abcdef",
+							Actions:   []string{"mv null_resource.foo null_resource.foo2"},
+							States: []StateMetadata{
+								{Dir: "dir1", Workspace: "default", SerialBefore: 1, SerialAfter: 2},
+							},
+							Duration: 2 * time.Second,
+						},
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid (broken)",
+			b:    []byte(`{`),
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "invalid duration",
+			b: []byte(`{
+    "version": 2,
+    "records": {
+        "20201012010101_foo.hcl": {
+            "type": "state",
+            "name": "foo",
+            "applied_at": "2020-10-13T01:02:03Z",
+            "metadata": {
+                "duration": "foo"
+            }
+        }
+    }
+}`),
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := parseHistoryFileV2(tc.b)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				if diff := cmp.Diff(*got, *tc.want, cmp.AllowUnexported(*got)); diff != "" {
+					t.Errorf("got = %#v, want = %#v, diff = %s", got, tc.want, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"path"
+	"strings"
+)
+
+// ValidateGlob returns an error if a given glob pattern is malformed.
+func ValidateGlob(pattern string) error {
+	for _, s := range strings.Split(pattern, "/") {
+		if _, err := path.Match(s, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether a slash-separated relative path matches a given
+// glob pattern. A pattern without a slash matches the base name, such as
+// `*.json`. Otherwise, it matches the whole path, and `**` matches zero or
+// more directories, such as `fixtures/**`.
+func matchGlob(pattern string, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments against pattern segments.
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchAnyGlob reports whether a given path matches any of glob patterns.
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
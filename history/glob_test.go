@@ -0,0 +1,56 @@
+package history
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.hcl", name: "20201012010101_foo.hcl", want: true},
+		{pattern: "*.hcl", name: "2020q4/20201012010101_foo.hcl", want: true},
+		{pattern: "*.hcl", name: "20201012010101_foo.json", want: false},
+		{pattern: "2020q4/*.hcl", name: "2020q4/20201012010101_foo.hcl", want: true},
+		{pattern: "2020q4/*.hcl", name: "2020q4/team-a/20201012010101_foo.hcl", want: false},
+		{pattern: "2020q4/**", name: "2020q4/team-a/20201012010101_foo.hcl", want: true},
+		{pattern: "**/fixtures/**", name: "fixtures/foo.hcl", want: true},
+		{pattern: "**/fixtures/**", name: "2020q4/fixtures/foo.hcl", want: true},
+		{pattern: "**/fixtures/**", name: "2020q4/foo.hcl", want: false},
+		{pattern: "**/*.hcl", name: "foo.hcl", want: true},
+		{pattern: "[", name: "foo.hcl", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			got := matchGlob(tc.pattern, tc.name)
+			if got != tc.want {
+				t.Errorf("got = %t, want = %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		ok      bool
+	}{
+		{pattern: "*.hcl", ok: true},
+		{pattern: "**/fixtures/**", ok: true},
+		{pattern: "[", ok: false},
+		{pattern: "foo/[", ok: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			err := ValidateGlob(tc.pattern)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package history
 
-import "time"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 // History records applied migration logs.
 type History struct {
@@ -21,6 +26,44 @@ type Record struct {
 	// AppliedAt is a timestamp when the migration was applied.
 	// Note that we only record it when the migration was succeed.
 	AppliedAt time.Time
+	// Checksum is a checksum of the migration file content when applied.
+	// It is used for detecting changes of already applied migration files.
+	// It is empty if not recorded, such as records loaded from the history file v1.
+	Checksum string
+	// Metadata is an audit log of the applied migration.
+	// It is nil if not recorded, such as records loaded from the history file v1.
+	Metadata *Metadata
+}
+
+// Metadata represents an audit log of an applied migration.
+type Metadata struct {
+	// AppliedBy is an identity of the user or CI who applied the migration.
+	AppliedBy string
+	// TfmigrateVersion is a version of tfmigrate.
+	TfmigrateVersion string
+	// TerraformVersion is a version of terraform command.
+	TerraformVersion string
+	// GitCommit is a commit hash of the migration dir.
+	GitCommit string
+	// Actions is a list of concrete actions applied to states.
+	// Actions with wildcards are expanded.
+	Actions []string
+	// States is a list of states touched by the migration.
+	States []StateMetadata
+	// Duration is a time taken to apply the migration.
+	Duration time.Duration
+}
+
+// StateMetadata represents a state touched by a migration.
+type StateMetadata struct {
+	// Dir is a working directory of the state.
+	Dir string
+	// Workspace is a workspace of the state.
+	Workspace string
+	// SerialBefore is a serial number of the state before the migration.
+	SerialBefore int64
+	// SerialAfter is a serial number of the state after the migration.
+	SerialAfter int64
 }
 
 // newEmptyHistory initializes a new History.
@@ -58,3 +101,34 @@ func (h *History) Clear() {
 func (h *History) Length() int {
 	return len(h.records)
 }
+
+// Merge adds records of another history which don't exist in this history,
+// and returns a list of added file names sorted alphabetically.
+// A record which exists in both with the same name and applied_at is
+// skipped. If the same file name is recorded with a different name or
+// applied_at, it returns an error without changing this history.
+func (h *History) Merge(other *History) ([]string, error) {
+	added := []string{}
+	conflicts := []string{}
+	for filename, r := range other.records {
+		current, ok := h.records[filename]
+		if !ok {
+			added = append(added, filename)
+			continue
+		}
+		if current.Name != r.Name || !current.AppliedAt.Equal(r.AppliedAt) {
+			conflicts = append(conflicts, filename)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("conflicting records found in history: %s", strings.Join(conflicts, ", "))
+	}
+
+	sort.Strings(added)
+	for _, filename := range added {
+		h.Add(filename, other.records[filename])
+	}
+	return added, nil
+}
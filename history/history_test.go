@@ -276,3 +276,146 @@ func TestHistoryLength(t *testing.T) {
 		})
 	}
 }
+
+func TestHistoryMerge(t *testing.T) {
+	cases := []struct {
+		desc      string
+		h         History
+		other     History
+		want      History
+		wantAdded []string
+		ok        bool
+	}{
+		{
+			desc: "merge",
+			h: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+						Checksum:  "sha256:foo",
+					},
+				},
+			},
+			other: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_bar.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+				},
+			},
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+						Checksum:  "sha256:foo",
+					},
+					"20201012020202_bar.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+				},
+			},
+			wantAdded: []string{"20201012020202_bar.hcl"},
+			ok:        true,
+		},
+		{
+			desc: "conflict (name)",
+			h: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			other: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo2",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+					"20201012020202_bar.hcl": Record{
+						Type:      "state",
+						Name:      "bar",
+						AppliedAt: time.Date(2020, 10, 13, 4, 5, 6, 0, time.UTC),
+					},
+				},
+			},
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			wantAdded: nil,
+			ok:        false,
+		},
+		{
+			desc: "conflict (applied_at)",
+			h: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			other: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 14, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			want: History{
+				records: map[string]Record{
+					"20201012010101_foo.hcl": Record{
+						Type:      "state",
+						Name:      "foo",
+						AppliedAt: time.Date(2020, 10, 13, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+			wantAdded: nil,
+			ok:        false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			added, err := tc.h.Merge(&tc.other)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if diff := cmp.Diff(added, tc.wantAdded); diff != "" {
+				t.Errorf("got added = %#v, want = %#v, diff = %s", added, tc.wantAdded, diff)
+			}
+			if diff := cmp.Diff(tc.h, tc.want, cmp.AllowUnexported(tc.h)); diff != "" {
+				t.Errorf("got = %#v, want = %#v, diff = %s", tc.h, tc.want, diff)
+			}
+		})
+	}
+}
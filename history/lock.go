@@ -0,0 +1,94 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// lockRetryInterval is an interval of retrying to acquire a lock.
+// It is a variable so that tests can shorten it.
+var lockRetryInterval = 1 * time.Second
+
+// Lock acquires a lock of the history storage for a given operation.
+// If the lock is held by someone else, it retries until the timeout expires.
+// A zero timeout means that it doesn't retry.
+// The lock expires after the LockTTL of a given config.
+// It returns a function to release the lock.
+// If the storage doesn't support locking, it logs a warning and returns a
+// no-op function.
+func Lock(ctx context.Context, config *Config, operation string, timeout time.Duration) (func(context.Context) error, error) {
+	s, err := config.Storage.NewStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	locker, ok := s.(storage.Locker)
+	if !ok {
+		log.Printf("[WARN] [history] the storage doesn't support locking: %T\n", s)
+		return func(context.Context) error { return nil }, nil
+	}
+
+	ttl := config.LockTTL
+	if ttl == 0 {
+		ttl = storage.DefaultLockTTL
+	}
+	info, err := storage.NewLockInfo(operation, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		log.Printf("[DEBUG] [history] acquire lock: %s\n", info)
+		err = locker.Lock(ctx, info)
+		if err == nil {
+			break
+		}
+
+		var lockErr *storage.LockError
+		if !errors.As(err, &lockErr) || !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+
+		log.Printf("[INFO] [history] waiting for lock: %s\n", lockErr.Info)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to acquire lock: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	log.Printf("[INFO] [history] lock acquired: %s\n", info.ID)
+
+	unlock := func(ctx context.Context) error {
+		log.Printf("[DEBUG] [history] release lock: %s\n", info.ID)
+		if err := locker.Unlock(ctx, info.ID); err != nil {
+			return fmt.Errorf("failed to release lock: %w", err)
+		}
+		log.Printf("[INFO] [history] lock released: %s\n", info.ID)
+		return nil
+	}
+
+	return unlock, nil
+}
+
+// ForceUnlock releases a lock of the history storage with a given lock ID
+// regardless of its holder. It is intended to recover from a stale lock left
+// by a crashed process.
+func ForceUnlock(ctx context.Context, config *Config, id string) error {
+	s, err := config.Storage.NewStorage()
+	if err != nil {
+		return err
+	}
+
+	locker, ok := s.(storage.Locker)
+	if !ok {
+		return fmt.Errorf("the storage doesn't support locking: %T", s)
+	}
+
+	return locker.Unlock(ctx, id)
+}
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/mock"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{
+		Storage: &mock.Config{},
+	}
+
+	unlock, err := Lock(ctx, config, "apply", 0)
+	if err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	_, err = Lock(ctx, config, "apply", 0)
+	var lockErr *storage.LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := unlock(ctx); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+
+	unlock, err = Lock(ctx, config, "apply", 0)
+	if err != nil {
+		t.Fatalf("failed to lock after unlock: %s", err)
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	orig := lockRetryInterval
+	lockRetryInterval = 10 * time.Millisecond
+	defer func() { lockRetryInterval = orig }()
+
+	ctx := context.Background()
+	config := &Config{
+		Storage: &mock.Config{},
+	}
+
+	unlock, err := Lock(ctx, config, "apply", 0)
+	if err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	start := time.Now()
+	_, err = Lock(ctx, config, "apply", 50*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected to fail to lock, but no error")
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Errorf("expected to retry until timeout, but returned after %s", time.Since(start))
+	}
+
+	if err := unlock(ctx); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
+
+func TestForceUnlock(t *testing.T) {
+	ctx := context.Background()
+	mc := &mock.Config{}
+	config := &Config{
+		Storage: mc,
+	}
+
+	if _, err := Lock(ctx, config, "apply", 0); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	info, err := storage.ParseLockInfo(mc.LockData())
+	if err != nil {
+		t.Fatalf("failed to parse lock info: %s", err)
+	}
+
+	if err := ForceUnlock(ctx, config, "wrong-id"); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := ForceUnlock(ctx, config, info.ID); err != nil {
+		t.Fatalf("failed to force unlock: %s", err)
+	}
+	if mc.LockData() != nil {
+		t.Fatalf("expected to release a lock, but got: %s", string(mc.LockData()))
+	}
+}
+
+func TestLockTTL(t *testing.T) {
+	cases := []struct {
+		desc    string
+		lockTTL time.Duration
+		want    time.Duration
+	}{
+		{
+			desc:    "default",
+			lockTTL: 0,
+			want:    storage.DefaultLockTTL,
+		},
+		{
+			desc:    "custom",
+			lockTTL: 6 * time.Hour,
+			want:    6 * time.Hour,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			mc := &mock.Config{}
+			config := &Config{
+				Storage: mc,
+				LockTTL: tc.lockTTL,
+			}
+
+			if _, err := Lock(ctx, config, "apply", 0); err != nil {
+				t.Fatalf("failed to lock: %s", err)
+			}
+			info, err := storage.ParseLockInfo(mc.LockData())
+			if err != nil {
+				t.Fatalf("failed to parse lock info: %s", err)
+			}
+			if info.TTL != tc.want {
+				t.Errorf("got: %s, want: %s", info.TTL, tc.want)
+			}
+		})
+	}
+}
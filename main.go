@@ -69,7 +69,8 @@ func logOutput() io.Writer {
 
 func initCommands(ui cli.Ui) map[string]cli.CommandFactory {
 	meta := command.Meta{
-		UI: ui,
+		UI:      ui,
+		Version: version,
 	}
 
 	commands := map[string]cli.CommandFactory{
@@ -83,11 +84,61 @@ func initCommands(ui cli.Ui) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"rollback": func() (cli.Command, error) {
+			return &command.RollbackCommand{
+				Meta: meta,
+			}, nil
+		},
 		"list": func() (cli.Command, error) {
 			return &command.ListCommand{
 				Meta: meta,
 			}, nil
 		},
+		"force-unlock": func() (cli.Command, error) {
+			return &command.ForceUnlockCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history": func() (cli.Command, error) {
+			return &command.HistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history show": func() (cli.Command, error) {
+			return &command.HistoryShowCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history mark-applied": func() (cli.Command, error) {
+			return &command.HistoryMarkAppliedCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history unmark": func() (cli.Command, error) {
+			return &command.HistoryUnmarkCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history prune": func() (cli.Command, error) {
+			return &command.HistoryPruneCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history repair": func() (cli.Command, error) {
+			return &command.HistoryRepairCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history export": func() (cli.Command, error) {
+			return &command.HistoryExportCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history import": func() (cli.Command, error) {
+			return &command.HistoryImportCommand{
+				Meta: meta,
+			}, nil
+		},
 	}
 
 	return commands
@@ -0,0 +1,183 @@
+package azurerm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// errBlobNotFound is an error which indicates a blob does not exist.
+var errBlobNotFound = errors.New("blob not found")
+
+// Client is an abstraction layer for Azure Blob Storage API.
+// It is intended to be replaced with a mock for testing.
+type Client interface {
+	// Download downloads a blob and returns its content and ETag.
+	// If the blob does not exist, returns errBlobNotFound.
+	Download(ctx context.Context, blobName string) ([]byte, string, error)
+	// Upload uploads a blob and returns its new ETag.
+	// If ifMatch is not empty, the blob is uploaded only if its ETag matches.
+	// If ifNoneMatch is "*", the blob is uploaded only if it does not exist.
+	// If the condition is not met, returns storage.ErrVersionConflict.
+	// A blob which doesn't exist doesn't match any ETag.
+	Upload(ctx context.Context, blobName string, p []byte, ifMatch string, ifNoneMatch string) (string, error)
+	// Delete deletes a blob. If the blob does not exist, no-op.
+	Delete(ctx context.Context, blobName string) error
+}
+
+// client is a real implementation of the Client.
+type client struct {
+	// azblobClient is an SDK client of Azure Blob Storage.
+	azblobClient *azblob.Client
+	// containerName is a name of the storage container.
+	containerName string
+}
+
+// newClient returns a new instance of Client.
+// Credentials are resolved in the following order:
+// connection string, access key, SAS token, service principal, and then
+// the default Azure credential chain such as managed identity and Azure CLI.
+func newClient(config *Config) (Client, error) {
+	azblobClient, err := newAzblobClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+	}
+
+	return &client{
+		azblobClient:  azblobClient,
+		containerName: config.ContainerName,
+	}, nil
+}
+
+// newAzblobClient returns a new SDK client authenticated with a given config.
+func newAzblobClient(config *Config) (*azblob.Client, error) {
+	if config.ConnectionString != "" {
+		return azblob.NewClientFromConnectionString(config.ConnectionString, nil)
+	}
+
+	if config.StorageAccountName == "" && config.Endpoint == "" {
+		return nil, fmt.Errorf("storage_account_name is required unless connection_string or endpoint is set")
+	}
+
+	serviceURL := config.Endpoint
+	if serviceURL == "" {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", config.StorageAccountName)
+	}
+
+	if accessKey := withEnv(config.AccessKey, "ARM_ACCESS_KEY"); accessKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(config.StorageAccountName, accessKey)
+		if err != nil {
+			return nil, err
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	}
+
+	if sasToken := withEnv(config.SASToken, "ARM_SAS_TOKEN"); sasToken != "" {
+		return azblob.NewClientWithNoCredential(serviceURL+"?"+strings.TrimPrefix(sasToken, "?"), nil)
+	}
+
+	var cred azcore.TokenCredential
+	var err error
+	tenantID := withEnv(config.TenantID, "ARM_TENANT_ID")
+	clientID := withEnv(config.ClientID, "ARM_CLIENT_ID")
+	clientSecret := withEnv(config.ClientSecret, "ARM_CLIENT_SECRET")
+	if tenantID != "" && clientID != "" && clientSecret != "" {
+		cred, err = azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	} else {
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewClient(serviceURL, cred, nil)
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of a
+// given environment variable.
+func withEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// Download downloads a blob and returns its content and ETag.
+func (c *client) Download(ctx context.Context, blobName string) ([]byte, string, error) {
+	resp, err := c.azblobClient.DownloadStream(ctx, c.containerName, blobName, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, "", errBlobNotFound
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed reading from azurerm://%s/%s: %w", c.containerName, blobName, err)
+	}
+
+	return b, etagString(resp.ETag), nil
+}
+
+// Upload uploads a blob and returns its new ETag.
+func (c *client) Upload(ctx context.Context, blobName string, p []byte, ifMatch string, ifNoneMatch string) (string, error) {
+	cond := &blob.ModifiedAccessConditions{}
+	if ifMatch != "" {
+		etag := azcore.ETag(ifMatch)
+		cond.IfMatch = &etag
+	}
+	if ifNoneMatch != "" {
+		etag := azcore.ETag(ifNoneMatch)
+		cond.IfNoneMatch = &etag
+	}
+
+	bbClient := c.azblobClient.ServiceClient().NewContainerClient(c.containerName).NewBlockBlobClient(blobName)
+	resp, err := bbClient.Upload(ctx, streaming.NopCloser(bytes.NewReader(p)), &blockblob.UploadOptions{
+		AccessConditions: &blob.AccessConditions{
+			ModifiedAccessConditions: cond,
+		},
+	})
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ConditionNotMet, bloberror.BlobAlreadyExists) {
+			return "", storage.ErrVersionConflict
+		}
+		if ifMatch != "" && bloberror.HasCode(err, bloberror.BlobNotFound) {
+			// The blob has been deleted after we read it.
+			return "", storage.ErrVersionConflict
+		}
+		return "", fmt.Errorf("failed writing to azurerm://%s/%s: %w", c.containerName, blobName, err)
+	}
+
+	return etagString(resp.ETag), nil
+}
+
+// Delete deletes a blob.
+func (c *client) Delete(ctx context.Context, blobName string) error {
+	_, err := c.azblobClient.DeleteBlob(ctx, c.containerName, blobName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return err
+	}
+	return nil
+}
+
+// etagString converts an ETag to a string.
+func etagString(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+	return string(*etag)
+}
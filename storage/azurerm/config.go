@@ -0,0 +1,47 @@
+package azurerm
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+// Config is a config for Azure Blob Storage.
+// This is expected to have almost the same options as Terraform azurerm backend.
+// https://developer.hashicorp.com/terraform/language/settings/backends/azurerm
+// However, it has many minor options and it's a pain to test all options from
+// first, so we added only options we need for now.
+type Config struct {
+	// Name of the storage account.
+	// Required unless a connection string is given.
+	StorageAccountName string `hcl:"storage_account_name,optional"`
+	// Name of the storage container.
+	ContainerName string `hcl:"container_name"`
+	// Name of the blob of the migration history file.
+	Key string `hcl:"key"`
+
+	// Connection string of the storage account.
+	ConnectionString string `hcl:"connection_string,optional"`
+	// Access key of the storage account.
+	// It can also be set via ARM_ACCESS_KEY environment variable.
+	AccessKey string `hcl:"access_key,optional"`
+	// SAS token of the storage account or container.
+	// It can also be set via ARM_SAS_TOKEN environment variable.
+	SASToken string `hcl:"sas_token,optional"`
+	// Tenant ID of the service principal.
+	// It can also be set via ARM_TENANT_ID environment variable.
+	TenantID string `hcl:"tenant_id,optional"`
+	// Client ID of the service principal.
+	// It can also be set via ARM_CLIENT_ID environment variable.
+	ClientID string `hcl:"client_id,optional"`
+	// Client secret of the service principal.
+	// It can also be set via ARM_CLIENT_SECRET environment variable.
+	ClientSecret string `hcl:"client_secret,optional"`
+	// Custom endpoint for the Blob service such as Azurite.
+	// Default to https://<storage_account_name>.blob.core.windows.net/
+	Endpoint string `hcl:"endpoint,optional"`
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
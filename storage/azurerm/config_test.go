@@ -0,0 +1,73 @@
+package azurerm
+
+import "testing"
+
+func TestConfigNewStorage(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "access key",
+			config: &Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+				AccessKey:          "ZHVtbXk=",
+			},
+			ok: true,
+		},
+		{
+			desc: "sas token",
+			config: &Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+				SASToken:           "?sv=2021-06-08&sig=dummy",
+			},
+			ok: true,
+		},
+		{
+			desc: "connection string",
+			config: &Config{
+				ConnectionString: "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey=ZHVtbXk=;BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;",
+				ContainerName:    "tfstate",
+				Key:              "tfmigrate/history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid connection string",
+			config: &Config{
+				ConnectionString: "foo",
+				ContainerName:    "tfstate",
+				Key:              "tfmigrate/history.json",
+			},
+			ok: false,
+		},
+		{
+			desc: "missing storage account name",
+			config: &Config{
+				ContainerName: "tfstate",
+				Key:           "tfmigrate/history.json",
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				_ = got.(*Storage)
+			}
+		})
+	}
+}
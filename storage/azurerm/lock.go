@@ -0,0 +1,76 @@
+package azurerm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock blob next to the history blob.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, s.lockObject(), info)
+}
+
+// Unlock releases a lock by deleting the lock blob.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, s.lockObject(), id)
+}
+
+// lockObject returns a storage.LockObject for the history blob.
+func (s *Storage) lockObject() *lockObject {
+	return &lockObject{
+		client:   s.client,
+		blobName: s.config.Key + storage.LockSuffix,
+	}
+}
+
+// lockObject is a storage.LockObject implementation for Azure Blob Storage.
+type lockObject struct {
+	// client is an instance of Client interface to call API.
+	client Client
+	// blobName is a name of the lock blob.
+	blobName string
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create creates a lock blob only if it doesn't exist.
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	_, err := l.client.Upload(ctx, l.blobName, b, "", "*")
+	if errors.Is(err, storage.ErrVersionConflict) {
+		// The lock blob already exists
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Read reads a content of the lock blob with its ETag as a version token.
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	b, etag, err := l.client.Download(ctx, l.blobName)
+	if errors.Is(err, errBlobNotFound) {
+		return nil, "", nil
+	}
+	return b, etag, err
+}
+
+// Replace overwrites the lock blob only if its ETag matches a given version.
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	_, err := l.client.Upload(ctx, l.blobName, b, version, "")
+	if errors.Is(err, storage.ErrVersionConflict) {
+		// The lock blob has been replaced or deleted
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the lock blob.
+func (l *lockObject) Delete(ctx context.Context) error {
+	return l.client.Delete(ctx, l.blobName)
+}
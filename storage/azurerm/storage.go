@@ -0,0 +1,75 @@
+package azurerm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Storage is a storage.Storage implementation for Azure Blob Storage.
+type Storage struct {
+	// config is a storage config for azurerm.
+	config *Config
+	// client is an instance of Client interface to call API.
+	// It is intended to be replaced with a mock for testing.
+	client Client
+}
+
+var _ storage.VersionedStorage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+func NewStorage(config *Config, client Client) (*Storage, error) {
+	if client == nil {
+		var err error
+		client, err = newClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Storage{
+		config: config,
+		client: client,
+	}
+
+	return s, nil
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	_, err := s.client.Upload(ctx, s.config.Key, b, "", "")
+	return err
+}
+
+// Read reads migration history data from storage.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, _, err := s.ReadWithVersion(ctx)
+	return b, err
+}
+
+// ReadWithVersion reads migration history data from storage with an ETag as
+// a version token.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	b, etag, err := s.client.Download(ctx, s.config.Key)
+	if errors.Is(err, errBlobNotFound) {
+		// If the key does not exist
+		return []byte{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return b, etag, nil
+}
+
+// WriteWithVersion writes migration history data to storage only if the ETag
+// of the current blob matches a given version.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	if version == "" {
+		return s.client.Upload(ctx, s.config.Key, b, "", "*")
+	}
+	return s.client.Upload(ctx, s.config.Key, b, version, "")
+}
@@ -0,0 +1,327 @@
+package azurerm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// mockClient is an in-memory implementation of Client for testing.
+type mockClient struct {
+	blobs map[string][]byte
+	etags map[string]string
+	seq   int
+	err   error
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{
+		blobs: map[string][]byte{},
+		etags: map[string]string{},
+	}
+}
+
+func (c *mockClient) Download(_ context.Context, blobName string) ([]byte, string, error) {
+	if c.err != nil {
+		return nil, "", c.err
+	}
+	b, ok := c.blobs[blobName]
+	if !ok {
+		return nil, "", errBlobNotFound
+	}
+	return b, c.etags[blobName], nil
+}
+
+func (c *mockClient) Upload(_ context.Context, blobName string, p []byte, ifMatch string, ifNoneMatch string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	_, exists := c.blobs[blobName]
+	if ifNoneMatch == "*" && exists {
+		return "", storage.ErrVersionConflict
+	}
+	if ifMatch != "" && ifMatch != c.etags[blobName] {
+		return "", storage.ErrVersionConflict
+	}
+	c.seq++
+	c.blobs[blobName] = p
+	c.etags[blobName] = fmt.Sprintf("0x%d", c.seq)
+	return c.etags[blobName], nil
+}
+
+func (c *mockClient) Delete(_ context.Context, blobName string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.blobs, blobName)
+	delete(c.etags, blobName)
+	return nil
+}
+
+func TestStorageWrite(t *testing.T) {
+	cases := []struct {
+		desc     string
+		client   *mockClient
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc:     "simple",
+			client:   newMockClient(),
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "container does not exist",
+			client: &mockClient{
+				err: errors.New("ContainerNotFound"),
+			},
+			contents: []byte("foo"),
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+			}
+			s, err := NewStorage(config, tc.client)
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			err = s.Write(context.Background(), tc.contents)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			if tc.ok {
+				got := tc.client.blobs[config.Key]
+				if string(got) != string(tc.contents) {
+					t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+				}
+			}
+		})
+	}
+}
+
+func TestStorageRead(t *testing.T) {
+	cases := []struct {
+		desc     string
+		client   *mockClient
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc: "simple",
+			client: &mockClient{
+				blobs: map[string][]byte{"tfmigrate/history.json": []byte("foo")},
+			},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc:     "blob does not exist",
+			client:   newMockClient(),
+			contents: []byte{},
+			ok:       true,
+		},
+		{
+			desc: "container does not exist",
+			client: &mockClient{
+				err: errors.New("ContainerNotFound"),
+			},
+			contents: nil,
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{
+				StorageAccountName: "tfmigrate",
+				ContainerName:      "tfstate",
+				Key:                "tfmigrate/history.json",
+			}
+			s, err := NewStorage(config, tc.client)
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			got, err := s.Read(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			if tc.ok {
+				if string(got) != string(tc.contents) {
+					t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+				}
+			}
+		})
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	config := &Config{
+		StorageAccountName: "tfmigrate",
+		ContainerName:      "tfstate",
+		Key:                "tfmigrate/history.json",
+	}
+	s, err := NewStorage(config, newMockClient())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	testStorageWriteWithVersion(t, s)
+}
+
+func TestStorageLock(t *testing.T) {
+	config := &Config{
+		StorageAccountName: "tfmigrate",
+		ContainerName:      "tfstate",
+		Key:                "tfmigrate/history.json",
+	}
+	client := newMockClient()
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	testStorageLock(t, s)
+
+	if _, ok := client.blobs["tfmigrate/history.json.lock"]; ok {
+		t.Error("expected to delete a lock blob, but found")
+	}
+}
+
+// TestAccStorage runs tests against Azurite, an emulator of Azure Storage.
+// It requires AZURITE_CONNECTION_STRING to be set.
+func TestAccStorage(t *testing.T) {
+	if os.Getenv("TEST_ACC") != "1" {
+		t.Skip("skip acceptance tests")
+	}
+	connectionString := os.Getenv("AZURITE_CONNECTION_STRING")
+	if connectionString == "" {
+		t.Skip("skip acceptance tests for azurerm because AZURITE_CONNECTION_STRING is not set")
+	}
+
+	ctx := context.Background()
+	containerName := "tfmigrate-test"
+	azblobClient, err := azblob.NewClientFromConnectionString(connectionString, nil)
+	if err != nil {
+		t.Fatalf("failed to create azure blob client: %s", err)
+	}
+	_, err = azblobClient.CreateContainer(ctx, containerName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		t.Fatalf("failed to create container: %s", err)
+	}
+
+	config := &Config{
+		ConnectionString: connectionString,
+		ContainerName:    containerName,
+		Key:              fmt.Sprintf("%s/history.json", t.Name()),
+	}
+	s, err := NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = s.client.Delete(ctx, config.Key)
+	})
+
+	testStorageWriteWithVersion(t, s)
+	testStorageLock(t, s)
+}
+
+// testStorageWriteWithVersion tests compare-and-swap writes of a given storage.
+func testStorageWriteWithVersion(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	b, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(b) != 0 || version != "" {
+		t.Fatalf("expected an empty version for a blob which doesn't exist, got: %s", version)
+	}
+
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("failed to create a blob: %s", err)
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for an existing blob, but got: %v", err)
+	}
+
+	// simulate a concurrent update by someone else
+	if err := s.Write(ctx, []byte("baz")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for a stale version, but got: %v", err)
+	}
+
+	got, v2, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "baz" {
+		t.Errorf("got: %s, want: baz", string(got))
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v2); err != nil {
+		t.Fatalf("failed to write with the latest version: %s", err)
+	}
+
+	got, err = s.Read(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "bar" {
+		t.Errorf("got: %s, want: bar", string(got))
+	}
+}
+
+// testStorageLock tests locking of a given storage.
+func testStorageLock(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
@@ -0,0 +1,404 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultHostname is a hostname of HCP Terraform.
+	defaultHostname = "app.terraform.io"
+	// contentType is a media type of JSON:API.
+	contentType = "application/vnd.api+json"
+	// pageSize is a page size for listing variables.
+	pageSize = 100
+)
+
+// errVariableExists is an error which indicates a variable already exists.
+var errVariableExists = errors.New("variable already exists")
+
+// errVariableNotFound is an error which indicates a variable does not exist.
+var errVariableNotFound = errors.New("variable not found")
+
+// Variable is a workspace variable.
+type Variable struct {
+	// ID is an ID of the variable.
+	ID string
+	// Key is a key of the variable.
+	Key string
+	// Value is a value of the variable.
+	Value string
+	// Version is a version token of the variable which changes whenever the
+	// variable is updated.
+	Version string
+}
+
+// Client is an abstraction layer for the workspace variables API.
+type Client interface {
+	// ListVariables returns a list of variables of the workspace.
+	ListVariables(ctx context.Context) ([]*Variable, error)
+	// CreateVariable creates a variable in the workspace.
+	// If the variable already exists, returns errVariableExists.
+	CreateVariable(ctx context.Context, key string, value string) (*Variable, error)
+	// UpdateVariable updates a value of the variable.
+	UpdateVariable(ctx context.Context, id string, value string) (*Variable, error)
+	// DeleteVariable deletes the variable.
+	// If the variable does not exist, returns errVariableNotFound.
+	DeleteVariable(ctx context.Context, id string) error
+}
+
+// client is a real implementation of the Client.
+type client struct {
+	// baseURL is a base URL of the API such as https://app.terraform.io/api/v2.
+	baseURL string
+	// token is an API token.
+	token string
+	// organization is a name of the organization.
+	organization string
+	// workspace is a name of the workspace.
+	workspace string
+	// workspaceID is a cache of the ID of the workspace.
+	workspaceID string
+	// httpClient is an HTTP client.
+	httpClient *http.Client
+}
+
+var _ Client = (*client)(nil)
+
+// newClient returns a new instance of Client.
+func newClient(config *Config) (Client, error) {
+	hostname := withEnv(config.Hostname, "TF_CLOUD_HOSTNAME")
+	if hostname == "" {
+		hostname = defaultHostname
+	}
+
+	organization := withEnv(config.Organization, "TF_CLOUD_ORGANIZATION")
+	if organization == "" {
+		return nil, fmt.Errorf("failed to create cloud client: organization is required")
+	}
+
+	token := config.Token
+	if token == "" {
+		var err error
+		token, err = terraformToken(hostname)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("failed to create cloud client: token for %s is not found, set token or run terraform login", hostname)
+	}
+
+	return &client{
+		baseURL:      "https://" + hostname + "/api/v2",
+		token:        token,
+		organization: organization,
+		workspace:    config.Workspace,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// terraformToken returns an API token for a given hostname in the same way
+// as Terraform. It reads TF_TOKEN_<hostname> environment variable, and then
+// the credentials file created by terraform login.
+func terraformToken(hostname string) (string, error) {
+	// Dots are encoded as underscores and hyphens as double underscores.
+	envName := "TF_TOKEN_" + strings.ReplaceAll(strings.ReplaceAll(hostname, "-", "__"), ".", "_")
+	if token := os.Getenv(envName); token != "" {
+		return token, nil
+	}
+
+	dir := os.Getenv("TF_CLI_CONFIG_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		dir = filepath.Join(home, ".terraform.d")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "credentials.tfrc.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var credentials struct {
+		Credentials map[string]struct {
+			Token string `json:"token"`
+		} `json:"credentials"`
+	}
+	if err := json.Unmarshal(b, &credentials); err != nil {
+		return "", fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return credentials.Credentials[hostname].Token, nil
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of a
+// given environment variable.
+func withEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// variableResource is a JSON:API resource of a workspace variable.
+type variableResource struct {
+	ID         string             `json:"id,omitempty"`
+	Type       string             `json:"type"`
+	Attributes variableAttributes `json:"attributes"`
+}
+
+// variableAttributes is attributes of a workspace variable.
+type variableAttributes struct {
+	Key         string `json:"key,omitempty"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"`
+	HCL         bool   `json:"hcl"`
+	Sensitive   bool   `json:"sensitive"`
+	VersionID   string `json:"version-id,omitempty"`
+}
+
+// newVariable converts a JSON:API resource to a Variable.
+// If the server doesn't return a version-id such as an old Terraform
+// Enterprise, a hash of the value is used as a version token instead.
+func newVariable(r variableResource) *Variable {
+	version := r.Attributes.VersionID
+	if version == "" {
+		sum := sha256.Sum256([]byte(r.Attributes.Value))
+		version = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return &Variable{
+		ID:      r.ID,
+		Key:     r.Attributes.Key,
+		Value:   r.Attributes.Value,
+		Version: version,
+	}
+}
+
+// newVariableAttributes returns attributes of a variable managed by tfmigrate.
+// The variable is an environment variable, not a terraform variable, so that
+// runs of the workspace don't receive it as an input variable. It is not
+// sensitive, because the value of a sensitive variable cannot be read back.
+func newVariableAttributes(key string, value string) variableAttributes {
+	return variableAttributes{
+		Key:         key,
+		Value:       value,
+		Description: "Managed by tfmigrate. Do not edit.",
+		Category:    "env",
+		HCL:         false,
+		Sensitive:   false,
+	}
+}
+
+// ListVariables returns a list of variables of the workspace.
+// It follows pagination to return all variables.
+func (c *client) ListVariables(ctx context.Context) ([]*Variable, error) {
+	id, err := c.workspaceIDOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []*Variable{}
+	for page := 1; page > 0; {
+		var resp struct {
+			Data []variableResource `json:"data"`
+			Meta struct {
+				Pagination struct {
+					NextPage int `json:"next-page"`
+				} `json:"pagination"`
+			} `json:"meta"`
+		}
+		path := fmt.Sprintf("/workspaces/%s/vars?page%%5Bnumber%%5D=%d&page%%5Bsize%%5D=%d", id, page, pageSize)
+		if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, r := range resp.Data {
+			vars = append(vars, newVariable(r))
+		}
+		// The next-page is null on the last page.
+		page = resp.Meta.Pagination.NextPage
+	}
+	return vars, nil
+}
+
+// CreateVariable creates a variable in the workspace.
+func (c *client) CreateVariable(ctx context.Context, key string, value string) (*Variable, error) {
+	id, err := c.workspaceIDOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]variableResource{
+		"data": {
+			Type:       "vars",
+			Attributes: newVariableAttributes(key, value),
+		},
+	}
+	var resp struct {
+		Data variableResource `json:"data"`
+	}
+	err = c.do(ctx, http.MethodPost, "/workspaces/"+id+"/vars", req, &resp)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity && apiErr.keyTaken() {
+		return nil, errVariableExists
+	} else if err != nil {
+		return nil, err
+	}
+	return newVariable(resp.Data), nil
+}
+
+// UpdateVariable updates a value of the variable.
+// It also updates the category and other attributes so that a variable
+// created by an older version of tfmigrate is converted.
+func (c *client) UpdateVariable(ctx context.Context, varID string, value string) (*Variable, error) {
+	id, err := c.workspaceIDOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]variableResource{
+		"data": {
+			ID:         varID,
+			Type:       "vars",
+			Attributes: newVariableAttributes("", value),
+		},
+	}
+	var resp struct {
+		Data variableResource `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPatch, "/workspaces/"+id+"/vars/"+varID, req, &resp); err != nil {
+		return nil, err
+	}
+	return newVariable(resp.Data), nil
+}
+
+// DeleteVariable deletes the variable.
+func (c *client) DeleteVariable(ctx context.Context, varID string) error {
+	id, err := c.workspaceIDOf(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.do(ctx, http.MethodDelete, "/workspaces/"+id+"/vars/"+varID, nil, nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return errVariableNotFound
+	}
+	return err
+}
+
+// workspaceIDOf returns an ID of the workspace.
+func (c *client) workspaceIDOf(ctx context.Context) (string, error) {
+	if c.workspaceID != "" {
+		return c.workspaceID, nil
+	}
+
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	path := "/organizations/" + url.PathEscape(c.organization) + "/workspaces/" + url.PathEscape(c.workspace)
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to get workspace %s/%s: %w", c.organization, c.workspace, err)
+	}
+	c.workspaceID = resp.Data.ID
+	return c.workspaceID, nil
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	// StatusCode is a status code of the response.
+	StatusCode int
+	// Method is a method of the request.
+	Method string
+	// Path is a path of the request.
+	Path string
+	// Body is a body of the response.
+	Body string
+}
+
+// Error returns a string representation of the error.
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// keyTaken returns true if the error indicates the key of a variable has
+// already been taken. The API returns 422 for other validation errors too,
+// so we check the error detail.
+func (e *apiError) keyTaken() bool {
+	var body struct {
+		Errors []struct {
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err != nil {
+		return false
+	}
+	for _, d := range body.Errors {
+		if strings.Contains(d.Detail, "has already been taken") {
+			return true
+		}
+	}
+	return false
+}
+
+// do sends a request to the API and decodes a response into a given value.
+func (c *client) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			StatusCode: resp.StatusCode,
+			Method:     method,
+			Path:       path,
+			Body:       string(b),
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
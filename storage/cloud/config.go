@@ -0,0 +1,44 @@
+package cloud
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+// Config is a config for HCP Terraform (formerly Terraform Cloud) and
+// Terraform Enterprise.
+// It stores a history file as an environment variable of a designated
+// workspace. The workspace is expected to be dedicated to tfmigrate, because
+// the variable is visible to runs of the workspace.
+type Config struct {
+	// Hostname of HCP Terraform or Terraform Enterprise.
+	// It can also be set via TF_CLOUD_HOSTNAME environment variable.
+	// Default to app.terraform.io.
+	Hostname string `hcl:"hostname,optional"`
+	// Name of the organization.
+	// It can also be set via TF_CLOUD_ORGANIZATION environment variable.
+	Organization string `hcl:"organization,optional"`
+	// Name of the workspace to store the history file.
+	Workspace string `hcl:"workspace"`
+	// Key of the workspace variable to store the history file.
+	// Default to tfmigrate_history.
+	Key string `hcl:"key,optional"`
+	// API token.
+	// If not set, the same token as Terraform is used, which is read from
+	// TF_TOKEN_<hostname> environment variable or the credentials file
+	// created by terraform login.
+	Token string `hcl:"token,optional"`
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
+
+// key returns a key of the workspace variable with a default value.
+func (c *Config) key() string {
+	if c.Key == "" {
+		return "tfmigrate_history"
+	}
+	return c.Key
+}
@@ -0,0 +1,111 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigNewStorage(t *testing.T) {
+	cases := []struct {
+		desc        string
+		env         map[string]string
+		credentials string
+		config      *Config
+		wantURL     string
+		wantToken   string
+		ok          bool
+	}{
+		{
+			desc: "token",
+			config: &Config{
+				Organization: "example",
+				Workspace:    "tfmigrate",
+				Token:        "foo",
+			},
+			wantURL:   "https://app.terraform.io/api/v2",
+			wantToken: "foo",
+			ok:        true,
+		},
+		{
+			desc: "token from env",
+			env: map[string]string{
+				"TF_TOKEN_tfe_example__corp_com": "bar",
+			},
+			config: &Config{
+				Hostname:     "tfe.example-corp.com",
+				Organization: "example",
+				Workspace:    "tfmigrate",
+			},
+			wantURL:   "https://tfe.example-corp.com/api/v2",
+			wantToken: "bar",
+			ok:        true,
+		},
+		{
+			desc: "token from credentials file",
+			env: map[string]string{
+				"TF_CLOUD_HOSTNAME":     "tfe.example.com",
+				"TF_CLOUD_ORGANIZATION": "example",
+			},
+			credentials: `{"credentials": {"tfe.example.com": {"token": "baz"}}}`,
+			config: &Config{
+				Workspace: "tfmigrate",
+			},
+			wantURL:   "https://tfe.example.com/api/v2",
+			wantToken: "baz",
+			ok:        true,
+		},
+		{
+			desc:        "token not found",
+			credentials: `{"credentials": {"tfe.example.com": {"token": "baz"}}}`,
+			config: &Config{
+				Organization: "example",
+				Workspace:    "tfmigrate",
+			},
+			ok: false,
+		},
+		{
+			desc: "missing organization",
+			config: &Config{
+				Workspace: "tfmigrate",
+				Token:     "foo",
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TF_CLI_CONFIG_DIR", dir)
+			t.Setenv("TF_CLOUD_HOSTNAME", "")
+			t.Setenv("TF_CLOUD_ORGANIZATION", "")
+			t.Setenv("TF_TOKEN_app_terraform_io", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if tc.credentials != "" {
+				if err := os.WriteFile(filepath.Join(dir, "credentials.tfrc.json"), []byte(tc.credentials), 0600); err != nil {
+					t.Fatalf("failed to write credentials file: %s", err)
+				}
+			}
+
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				c := got.(*Storage).client.(*client)
+				if c.baseURL != tc.wantURL {
+					t.Errorf("got: %s, want: %s", c.baseURL, tc.wantURL)
+				}
+				if c.token != tc.wantToken {
+					t.Errorf("got: %s, want: %s", c.token, tc.wantToken)
+				}
+			}
+		})
+	}
+}
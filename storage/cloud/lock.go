@@ -0,0 +1,91 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock variable next to the history
+// variable. We don't use the workspace lock because it doesn't tell us who
+// holds the lock.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, s.lockObject(), info)
+}
+
+// Unlock releases a lock by deleting the lock variable.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, s.lockObject(), id)
+}
+
+// lockObject returns a storage.LockObject for the history variable.
+// Since a variable key cannot contain a dot, the lock variable is suffixed
+// with _lock instead of storage.LockSuffix.
+func (s *Storage) lockObject() *lockObject {
+	return &lockObject{
+		client: s.client,
+		key:    s.config.key() + "_lock",
+	}
+}
+
+// lockObject is a storage.LockObject implementation for HCP Terraform.
+type lockObject struct {
+	// client is an instance of Client interface to call API.
+	client Client
+	// key is a key of the lock variable.
+	key string
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create creates a lock variable only if it doesn't exist.
+// It relies on the uniqueness of the variable keys in the workspace.
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	_, err := l.client.CreateVariable(ctx, l.key, string(b))
+	if errors.Is(err, errVariableExists) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Read reads a content of the lock variable with its ID as a version token.
+// Since a lock variable is never updated, the ID identifies the lock.
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	v, err := findVariable(ctx, l.client, l.key)
+	if err != nil || v == nil {
+		return nil, "", err
+	}
+	return []byte(v.Value), v.ID, nil
+}
+
+// Replace deletes the lock variable by a given ID and then creates a new one.
+// Since the API doesn't support a conditional update, we rely on the fact
+// that only one of contenders can delete the variable of the same ID.
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	err := l.client.DeleteVariable(ctx, version)
+	if errors.Is(err, errVariableNotFound) {
+		// The lock variable has been replaced or deleted
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return l.Create(ctx, b)
+}
+
+// Delete deletes the lock variable.
+func (l *lockObject) Delete(ctx context.Context) error {
+	v, err := findVariable(ctx, l.client, l.key)
+	if err != nil || v == nil {
+		return err
+	}
+	err = l.client.DeleteVariable(ctx, v.ID)
+	if errors.Is(err, errVariableNotFound) {
+		return nil
+	}
+	return err
+}
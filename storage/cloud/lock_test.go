@@ -0,0 +1,58 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	server := &fakeServer{token: "token"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config := &Config{
+		Organization: "example",
+		Workspace:    "tfmigrate",
+	}
+	s, err := NewStorage(config, newTestClient(ts, "tfmigrate"))
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	ctx := context.Background()
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+	if lockErr.Info.ID != info1.ID {
+		t.Errorf("got: %s, want: %s", lockErr.Info.ID, info1.ID)
+	}
+
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+
+	if len(server.vars) != 0 {
+		t.Errorf("expected to delete a lock variable, but got: %#v", server.vars)
+	}
+}
@@ -0,0 +1,130 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Storage is a storage.Storage implementation for HCP Terraform.
+// It stores a history file as an environment variable of a designated workspace.
+type Storage struct {
+	// config is a storage config for cloud.
+	config *Config
+	// client is an instance of Client interface to call API.
+	// It is intended to be replaced with a client for a test server.
+	client Client
+}
+
+var _ storage.Storage = (*Storage)(nil)
+var _ storage.VersionedStorage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+func NewStorage(config *Config, client Client) (*Storage, error) {
+	if client == nil {
+		var err error
+		client, err = newClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Storage{
+		config: config,
+		client: client,
+	}
+
+	return s, nil
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	v, err := findVariable(ctx, s.client, s.config.key())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		_, err = s.client.CreateVariable(ctx, s.config.key(), string(b))
+		return err
+	}
+	_, err = s.client.UpdateVariable(ctx, v.ID, string(b))
+	return err
+}
+
+// Read reads migration history data from storage.
+// If the variable does not exist, it is assumed to be uninitialized and
+// returns an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	v, err := findVariable(ctx, s.client, s.config.key())
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		// If the variable does not exist
+		return []byte{}, nil
+	}
+	return []byte(v.Value), nil
+}
+
+// ReadWithVersion reads migration history data with a version token.
+// The version token is a version-id of the variable.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	v, err := findVariable(ctx, s.client, s.config.key())
+	if err != nil {
+		return nil, "", err
+	}
+	if v == nil {
+		return []byte{}, "", nil
+	}
+	return []byte(v.Value), v.Version, nil
+}
+
+// WriteWithVersion writes migration history data only if the current version
+// token matches a given one.
+// Note that the API has no conditional update, so we check the version
+// before updating. It detects most of concurrent updates, but a narrow race
+// between the check and the update still exists.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	v, err := findVariable(ctx, s.client, s.config.key())
+	if err != nil {
+		return "", err
+	}
+
+	if v == nil {
+		if version != "" {
+			return "", storage.ErrVersionConflict
+		}
+		created, err := s.client.CreateVariable(ctx, s.config.key(), string(b))
+		if errors.Is(err, errVariableExists) {
+			return "", storage.ErrVersionConflict
+		} else if err != nil {
+			return "", err
+		}
+		return created.Version, nil
+	}
+
+	if v.Version != version {
+		return "", storage.ErrVersionConflict
+	}
+	updated, err := s.client.UpdateVariable(ctx, v.ID, string(b))
+	if err != nil {
+		return "", err
+	}
+	return updated.Version, nil
+}
+
+// findVariable returns a variable with a given key.
+// If not found, returns nil with no error.
+func findVariable(ctx context.Context, client Client, key string) (*Variable, error) {
+	vars, err := client.ListVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vars {
+		if v.Key == key {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,372 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// fakeServer is a fake of the workspace variables API.
+type fakeServer struct {
+	mu sync.Mutex
+	// vars is a list of variables of the workspace ws-test.
+	vars []*Variable
+	// categories is a map of variable IDs to categories.
+	categories map[string]string
+	seq        int
+	// token is an expected API token.
+	token string
+	// pageSize is a page size of listing variables, which overrides the
+	// requested one if set.
+	pageSize int
+}
+
+// resource returns a JSON:API resource of a given variable.
+func (s *fakeServer) resource(v *Variable) variableResource {
+	return variableResource{
+		ID:   v.ID,
+		Type: "vars",
+		Attributes: variableAttributes{
+			Key:       v.Key,
+			Value:     v.Value,
+			Category:  s.categories[v.ID],
+			VersionID: v.Version,
+		},
+	}
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.categories == nil {
+		s.categories = make(map[string]string)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v2")
+	switch {
+	case r.Method == http.MethodGet && path == "/organizations/example/workspaces/tfmigrate":
+		fmt.Fprint(w, `{"data":{"id":"ws-test","type":"workspaces"}}`)
+
+	case r.Method == http.MethodGet && path == "/workspaces/ws-test/vars":
+		page, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+		if s.pageSize > 0 {
+			size = s.pageSize
+		}
+		start := (page - 1) * size
+		end := start + size
+		if end > len(s.vars) {
+			end = len(s.vars)
+		}
+
+		resp := struct {
+			Data []variableResource `json:"data"`
+			Meta map[string]any     `json:"meta"`
+		}{Data: []variableResource{}}
+		for _, v := range s.vars[start:end] {
+			resp.Data = append(resp.Data, s.resource(v))
+		}
+		var next any
+		if end < len(s.vars) {
+			next = page + 1
+		}
+		resp.Meta = map[string]any{"pagination": map[string]any{"next-page": next}}
+		_ = json.NewEncoder(w).Encode(resp)
+
+	case r.Method == http.MethodPost && path == "/workspaces/ws-test/vars":
+		var req struct {
+			Data variableResource `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(req.Data.Attributes.Key, ".") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"errors":[{"status":"422","title":"invalid attribute","detail":"Key is invalid"}]}`)
+			return
+		}
+		for _, v := range s.vars {
+			if v.Key == req.Data.Attributes.Key {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"errors":[{"status":"422","title":"invalid attribute","detail":"Key has already been taken"}]}`)
+				return
+			}
+		}
+		s.seq++
+		v := &Variable{
+			ID:      fmt.Sprintf("var-%d", s.seq),
+			Key:     req.Data.Attributes.Key,
+			Value:   req.Data.Attributes.Value,
+			Version: fmt.Sprintf("v-%d", s.seq),
+		}
+		s.vars = append(s.vars, v)
+		s.categories[v.ID] = req.Data.Attributes.Category
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]variableResource{"data": s.resource(v)})
+
+	case strings.HasPrefix(path, "/workspaces/ws-test/vars/"):
+		id := strings.TrimPrefix(path, "/workspaces/ws-test/vars/")
+		for i, v := range s.vars {
+			if v.ID != id {
+				continue
+			}
+			switch r.Method {
+			case http.MethodPatch:
+				var req struct {
+					Data variableResource `json:"data"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				s.seq++
+				v.Value = req.Data.Attributes.Value
+				v.Version = fmt.Sprintf("v-%d", s.seq)
+				if req.Data.Attributes.Category != "" {
+					s.categories[v.ID] = req.Data.Attributes.Category
+				}
+				_ = json.NewEncoder(w).Encode(map[string]variableResource{"data": s.resource(v)})
+			case http.MethodDelete:
+				s.vars = append(s.vars[:i], s.vars[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newTestClient returns a new client for a given test server.
+func newTestClient(ts *httptest.Server, workspace string) *client {
+	return &client{
+		baseURL:      ts.URL + "/api/v2",
+		token:        "token",
+		organization: "example",
+		workspace:    workspace,
+		httpClient:   ts.Client(),
+	}
+}
+
+func TestStorageWriteRead(t *testing.T) {
+	cases := []struct {
+		desc      string
+		workspace string
+		key       string
+		contents  []byte
+		ok        bool
+	}{
+		{
+			desc:      "simple",
+			workspace: "tfmigrate",
+			contents:  []byte("foo"),
+			ok:        true,
+		},
+		{
+			desc:      "custom key",
+			workspace: "tfmigrate",
+			key:       "history",
+			contents:  []byte("foo"),
+			ok:        true,
+		},
+		{
+			desc:      "workspace does not exist",
+			workspace: "not_found",
+			contents:  []byte("foo"),
+			ok:        false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			server := &fakeServer{token: "token"}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			config := &Config{
+				Organization: "example",
+				Workspace:    tc.workspace,
+				Key:          tc.key,
+			}
+			s, err := NewStorage(config, newTestClient(ts, tc.workspace))
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+
+			// write twice to test both create and update.
+			for i := 0; i < 2; i++ {
+				err = s.Write(context.Background(), tc.contents)
+				if tc.ok && err != nil {
+					t.Fatalf("unexpected err: %s", err)
+				}
+				if !tc.ok {
+					if err == nil {
+						t.Fatal("expected to return an error, but no error")
+					}
+					return
+				}
+			}
+
+			if len(server.vars) != 1 || server.vars[0].Key != config.key() {
+				t.Fatalf("expected to create a variable %s, but got: %#v", config.key(), server.vars)
+			}
+			if got := server.categories[server.vars[0].ID]; got != "env" {
+				t.Errorf("expected to create an env variable, but got category: %s", got)
+			}
+
+			got, err := s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if string(got) != string(tc.contents) {
+				t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+			}
+		})
+	}
+}
+
+func TestStorageReadNotFound(t *testing.T) {
+	ts := httptest.NewServer(&fakeServer{token: "token"})
+	defer ts.Close()
+
+	config := &Config{
+		Organization: "example",
+		Workspace:    "tfmigrate",
+	}
+	s, err := NewStorage(config, newTestClient(ts, "tfmigrate"))
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	got, err := s.Read(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got: %s, want: empty", string(got))
+	}
+}
+
+func TestStorageUnauthorized(t *testing.T) {
+	ts := httptest.NewServer(&fakeServer{token: "token"})
+	defer ts.Close()
+
+	config := &Config{
+		Organization: "example",
+		Workspace:    "tfmigrate",
+	}
+	c := newTestClient(ts, "tfmigrate")
+	c.token = "invalid"
+	s, err := NewStorage(config, c)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	if _, err := s.Read(context.Background()); err == nil {
+		t.Fatal("expected to return an error, but no error")
+	}
+}
+
+func TestStorageReadPagination(t *testing.T) {
+	server := &fakeServer{token: "token", pageSize: 2}
+	for i := 1; i <= 5; i++ {
+		server.vars = append(server.vars, &Variable{
+			ID:    fmt.Sprintf("var-other-%d", i),
+			Key:   fmt.Sprintf("other_%d", i),
+			Value: "bar",
+		})
+	}
+	server.vars = append(server.vars, &Variable{ID: "var-history", Key: "tfmigrate_history", Value: "foo"})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config := &Config{
+		Organization: "example",
+		Workspace:    "tfmigrate",
+	}
+	s, err := NewStorage(config, newTestClient(ts, "tfmigrate"))
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	got, err := s.Read(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "foo" {
+		t.Errorf("got: %s, want: foo", string(got))
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	ts := httptest.NewServer(&fakeServer{token: "token"})
+	defer ts.Close()
+
+	config := &Config{
+		Organization: "example",
+		Workspace:    "tfmigrate",
+	}
+	s, err := NewStorage(config, newTestClient(ts, "tfmigrate"))
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	ctx := context.Background()
+
+	_, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), version)
+	if err != nil {
+		t.Fatalf("failed to create: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict on create, but got: %v", err)
+	}
+
+	v2, err := s.WriteWithVersion(ctx, []byte("bar"), v1)
+	if err != nil {
+		t.Fatalf("failed to update: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("baz"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict on update, but got: %v", err)
+	}
+
+	got, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+	if string(got) != "bar" || version != v2 {
+		t.Errorf("got: %s (%s), want: bar (%s)", string(got), version, v2)
+	}
+}
+
+func TestClientCreateVariableValidationError(t *testing.T) {
+	ts := httptest.NewServer(&fakeServer{token: "token"})
+	defer ts.Close()
+
+	c := newTestClient(ts, "tfmigrate")
+	_, err := c.CreateVariable(context.Background(), "invalid.key", "foo")
+	if err == nil || errors.Is(err, errVariableExists) {
+		t.Fatalf("expected to return a validation error, but got: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Client is an abstraction layer for Consul KV API.
+// It is intended to be replaced with a mock for testing.
+type Client interface {
+	// Get returns a value and a modify index of a given key.
+	// If the key does not exist, returns nil with zero index.
+	Get(ctx context.Context, key string) ([]byte, uint64, error)
+	// Put writes a value of a given key unconditionally.
+	Put(ctx context.Context, key string, value []byte) error
+	// CAS writes a value of a given key only if its modify index matches a
+	// given index and returns a new modify index. A zero index means the key
+	// must not exist. If the index doesn't match, returns
+	// storage.ErrVersionConflict.
+	CAS(ctx context.Context, key string, value []byte, index uint64) (uint64, error)
+	// Acquire writes a value of a given key with a new session only if the key
+	// is not held by any session. It returns false if the key is held by
+	// another session.
+	Acquire(ctx context.Context, key string, value []byte) (bool, error)
+	// Release deletes a given key and destroys the session holding it.
+	// If the key does not exist, no-op.
+	Release(ctx context.Context, key string) error
+	// ReleaseCAS deletes a given key and destroys the session holding it only
+	// if its modify index matches a given index. It returns false if the
+	// index doesn't match or the key does not exist.
+	ReleaseCAS(ctx context.Context, key string, index uint64) (bool, error)
+}
+
+// client is a real implementation of the Client.
+type client struct {
+	// api is an SDK client of Consul.
+	api *consulapi.Client
+}
+
+// newClient returns a new instance of Client.
+// Options which are not set in the config are read from the CONSUL_*
+// environment variables by the SDK.
+func newClient(config *Config) (Client, error) {
+	c := consulapi.DefaultConfig()
+	if config.Address != "" {
+		c.Address = config.Address
+	}
+	if config.Scheme != "" {
+		c.Scheme = config.Scheme
+	}
+	if config.AccessToken != "" {
+		c.Token = config.AccessToken
+	}
+	if config.Datacenter != "" {
+		c.Datacenter = config.Datacenter
+	}
+	if config.HTTPAuth != "" {
+		username, password, _ := strings.Cut(config.HTTPAuth, ":")
+		c.HttpAuth = &consulapi.HttpBasicAuth{
+			Username: username,
+			Password: password,
+		}
+	}
+	if config.CAFile != "" {
+		c.TLSConfig.CAFile = config.CAFile
+	}
+	if config.CertFile != "" {
+		c.TLSConfig.CertFile = config.CertFile
+	}
+	if config.KeyFile != "" {
+		c.TLSConfig.KeyFile = config.KeyFile
+	}
+
+	api, err := consulapi.NewClient(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %w", err)
+	}
+
+	return &client{api: api}, nil
+}
+
+// Get returns a value and a modify index of a given key.
+func (c *client) Get(ctx context.Context, key string) ([]byte, uint64, error) {
+	opts := (&consulapi.QueryOptions{RequireConsistent: true}).WithContext(ctx)
+	pair, _, err := c.api.KV().Get(key, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if pair == nil {
+		return nil, 0, nil
+	}
+	return pair.Value, pair.ModifyIndex, nil
+}
+
+// Put writes a value of a given key unconditionally.
+func (c *client) Put(ctx context.Context, key string, value []byte) error {
+	opts := (&consulapi.WriteOptions{}).WithContext(ctx)
+	_, err := c.api.KV().Put(&consulapi.KVPair{Key: key, Value: value}, opts)
+	return err
+}
+
+// CAS writes a value of a given key only if its modify index matches.
+// It uses a transaction to get a new modify index atomically.
+func (c *client) CAS(ctx context.Context, key string, value []byte, index uint64) (uint64, error) {
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	ops := consulapi.TxnOps{
+		&consulapi.TxnOp{
+			KV: &consulapi.KVTxnOp{
+				Verb:  consulapi.KVCAS,
+				Key:   key,
+				Value: value,
+				Index: index,
+			},
+		},
+	}
+	ok, resp, _, err := c.api.Txn().Txn(ops, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, txnError(key, resp)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].KV == nil {
+		return 0, fmt.Errorf("unexpected response of transaction for key %s", key)
+	}
+	return resp.Results[0].KV.ModifyIndex, nil
+}
+
+// txnIndexStaleMessage is a part of the error message of a rolled back
+// transaction, which consul returns when a modify index of the cas operation
+// doesn't match.
+const txnIndexStaleMessage = "index is stale"
+
+// txnError returns an error of a rolled back transaction.
+// It returns storage.ErrVersionConflict only if the index didn't match,
+// because a transaction is also rolled back for other reasons such as a
+// permission denied by ACL.
+func txnError(key string, resp *consulapi.TxnResponse) error {
+	if resp == nil || len(resp.Errors) == 0 {
+		return fmt.Errorf("transaction for key %s was rolled back without errors", key)
+	}
+
+	msgs := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		if strings.Contains(e.What, txnIndexStaleMessage) {
+			return storage.ErrVersionConflict
+		}
+		msgs = append(msgs, e.What)
+	}
+	return fmt.Errorf("transaction for key %s was rolled back: %s", key, strings.Join(msgs, ", "))
+}
+
+// Acquire writes a value of a given key with a new session.
+// The session is created with a delete behavior and no TTL, so the lock
+// remains until it is released explicitly.
+func (c *client) Acquire(ctx context.Context, key string, value []byte) (bool, error) {
+	opts := (&consulapi.WriteOptions{}).WithContext(ctx)
+	session, _, err := c.api.Session().Create(&consulapi.SessionEntry{
+		Name:     "tfmigrate",
+		Behavior: consulapi.SessionBehaviorDelete,
+	}, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	acquired, _, err := c.api.KV().Acquire(&consulapi.KVPair{Key: key, Value: value, Session: session}, opts)
+	if err != nil || !acquired {
+		// Clean up the unused session.
+		_, _ = c.api.Session().Destroy(session, opts)
+	}
+	return acquired, err
+}
+
+// Release deletes a given key and destroys the session holding it.
+// We release the key before destroying the session because invalidating a
+// session holding a lock prevents others from acquiring it for a lock-delay.
+func (c *client) Release(ctx context.Context, key string) error {
+	qopts := (&consulapi.QueryOptions{RequireConsistent: true}).WithContext(ctx)
+	pair, _, err := c.api.KV().Get(key, qopts)
+	if err != nil {
+		return err
+	}
+	if pair == nil {
+		return nil
+	}
+
+	opts := (&consulapi.WriteOptions{}).WithContext(ctx)
+	if pair.Session != "" {
+		if _, _, err := c.api.KV().Release(pair, opts); err != nil {
+			return fmt.Errorf("failed to release key %s: %w", key, err)
+		}
+	}
+	if _, err := c.api.KV().Delete(key, opts); err != nil {
+		return err
+	}
+	if pair.Session != "" {
+		if _, err := c.api.Session().Destroy(pair.Session, opts); err != nil {
+			return fmt.Errorf("failed to destroy session %s: %w", pair.Session, err)
+		}
+	}
+	return nil
+}
+
+// ReleaseCAS deletes a given key and destroys the session holding it only if
+// its modify index matches.
+// Unlike Release, we delete the key without releasing it first, because
+// releasing the key changes its modify index.
+func (c *client) ReleaseCAS(ctx context.Context, key string, index uint64) (bool, error) {
+	qopts := (&consulapi.QueryOptions{RequireConsistent: true}).WithContext(ctx)
+	pair, _, err := c.api.KV().Get(key, qopts)
+	if err != nil {
+		return false, err
+	}
+	if pair == nil || pair.ModifyIndex != index {
+		return false, nil
+	}
+
+	opts := (&consulapi.WriteOptions{}).WithContext(ctx)
+	deleted, _, err := c.api.KV().DeleteCAS(pair, opts)
+	if err != nil || !deleted {
+		return false, err
+	}
+	if pair.Session != "" {
+		if _, err := c.api.Session().Destroy(pair.Session, opts); err != nil {
+			return false, fmt.Errorf("failed to destroy session %s: %w", pair.Session, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,53 @@
+package consul
+
+import (
+	"errors"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestTxnError(t *testing.T) {
+	cases := []struct {
+		desc         string
+		resp         *consulapi.TxnResponse
+		wantConflict bool
+	}{
+		{
+			desc: "index is stale",
+			resp: &consulapi.TxnResponse{
+				Errors: consulapi.TxnErrors{
+					{OpIndex: 0, What: `failed to set key "tfmigrate/history.json", index is stale`},
+				},
+			},
+			wantConflict: true,
+		},
+		{
+			desc: "permission denied",
+			resp: &consulapi.TxnResponse{
+				Errors: consulapi.TxnErrors{
+					{OpIndex: 0, What: "Permission denied"},
+				},
+			},
+			wantConflict: false,
+		},
+		{
+			desc:         "no errors",
+			resp:         &consulapi.TxnResponse{},
+			wantConflict: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := txnError("tfmigrate/history.json", tc.resp)
+			if err == nil {
+				t.Fatalf("expected to return an error, but no error")
+			}
+			if got := errors.Is(err, storage.ErrVersionConflict); got != tc.wantConflict {
+				t.Errorf("got conflict: %t, want conflict: %t, err: %s", got, tc.wantConflict, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package consul
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+// Config is a config for Consul.
+// This is expected to have almost the same options as Terraform consul backend.
+// https://developer.hashicorp.com/terraform/language/settings/backends/consul
+// However, it has many minor options and it's a pain to test all options from
+// first, so we added only options we need for now.
+type Config struct {
+	// Path in the Consul KV store of the migration history file.
+	Path string `hcl:"path"`
+	// Address of the Consul agent such as 127.0.0.1:8500.
+	// It can also be set via CONSUL_HTTP_ADDR environment variable.
+	Address string `hcl:"address,optional"`
+	// Scheme to communicate with the Consul agent. Valid values are http or https.
+	Scheme string `hcl:"scheme,optional"`
+	// Access token of the Consul ACL.
+	// It can also be set via CONSUL_HTTP_TOKEN environment variable.
+	AccessToken string `hcl:"access_token,optional"`
+	// Datacenter to use. Default to the datacenter of the agent.
+	Datacenter string `hcl:"datacenter,optional"`
+	// HTTP basic authentication credentials in the form of username[:password].
+	// It can also be set via CONSUL_HTTP_AUTH environment variable.
+	HTTPAuth string `hcl:"http_auth,optional"`
+	// Path to a PEM-encoded CA certificate file to verify the Consul server.
+	// It can also be set via CONSUL_CACERT environment variable.
+	CAFile string `hcl:"ca_file,optional"`
+	// Path to a PEM-encoded client certificate file for mutual TLS.
+	// It can also be set via CONSUL_CLIENT_CERT environment variable.
+	CertFile string `hcl:"cert_file,optional"`
+	// Path to a PEM-encoded client private key file for mutual TLS.
+	// It can also be set via CONSUL_CLIENT_KEY environment variable.
+	KeyFile string `hcl:"key_file,optional"`
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
@@ -0,0 +1,49 @@
+package consul
+
+import "testing"
+
+func TestConfigNewStorage(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			config: &Config{
+				Path:        "tfmigrate/history.json",
+				Address:     "127.0.0.1:8500",
+				Scheme:      "http",
+				AccessToken: "token",
+				Datacenter:  "dc1",
+				HTTPAuth:    "foo:bar",
+			},
+			ok: true,
+		},
+		{
+			desc: "ca file does not exist",
+			config: &Config{
+				Path:    "tfmigrate/history.json",
+				Address: "127.0.0.1:8500",
+				Scheme:  "https",
+				CAFile:  "/path/to/not/found.pem",
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				_ = got.(*Storage)
+			}
+		})
+	}
+}
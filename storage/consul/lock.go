@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by acquiring a lock key next to the history key with
+// a Consul session.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, s.lockObject(), info)
+}
+
+// Unlock releases a lock by deleting the lock key and destroying its session.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, s.lockObject(), id)
+}
+
+// lockObject returns a storage.LockObject for the history key.
+func (s *Storage) lockObject() *lockObject {
+	return &lockObject{
+		client: s.client,
+		key:    s.config.Path + storage.LockSuffix,
+	}
+}
+
+// lockObject is a storage.LockObject implementation for Consul.
+type lockObject struct {
+	// client is an instance of Client interface to call API.
+	client Client
+	// key is a key of the lock.
+	key string
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create acquires a lock key only if it is not held by any session.
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	return l.client.Acquire(ctx, l.key, b)
+}
+
+// Read reads a content of the lock key with its modify index as a version
+// token.
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	b, index, err := l.client.Get(ctx, l.key)
+	if err != nil {
+		return nil, "", err
+	}
+	if index == 0 {
+		return nil, "", nil
+	}
+	return b, strconv.FormatUint(index, 10), nil
+}
+
+// Replace deletes the lock key only if its modify index matches a given
+// version, and then acquires it with a new session.
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	index, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid version of lock for consul: %s", version)
+	}
+	released, err := l.client.ReleaseCAS(ctx, l.key, index)
+	if err != nil || !released {
+		return false, err
+	}
+	return l.client.Acquire(ctx, l.key, b)
+}
+
+// Delete deletes the lock key and destroys its session.
+func (l *lockObject) Delete(ctx context.Context) error {
+	return l.client.Release(ctx, l.key)
+}
@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Storage is a storage.Storage implementation for Consul KV.
+type Storage struct {
+	// config is a storage config for consul.
+	config *Config
+	// client is an instance of Client interface to call API.
+	// It is intended to be replaced with a mock for testing.
+	client Client
+}
+
+var _ storage.VersionedStorage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+func NewStorage(config *Config, client Client) (*Storage, error) {
+	if client == nil {
+		var err error
+		client, err = newClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Storage{
+		config: config,
+		client: client,
+	}
+
+	return s, nil
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	return s.client.Put(ctx, s.config.Path, b)
+}
+
+// Read reads migration history data from storage.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, _, err := s.ReadWithVersion(ctx)
+	return b, err
+}
+
+// ReadWithVersion reads migration history data from storage with a modify
+// index of the key as a version token.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	b, index, err := s.client.Get(ctx, s.config.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	if index == 0 {
+		// If the key does not exist
+		return []byte{}, "", nil
+	}
+	return b, strconv.FormatUint(index, 10), nil
+}
+
+// WriteWithVersion writes migration history data to storage only if the
+// modify index of the current key matches a given version.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	var index uint64
+	if version != "" {
+		var err error
+		index, err = strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse version %s: %w", version, err)
+		}
+	}
+
+	newIndex, err := s.client.CAS(ctx, s.config.Path, b, index)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(newIndex, 10), nil
+}
@@ -0,0 +1,350 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// mockClient is an in-memory implementation of Client for testing.
+type mockClient struct {
+	values   map[string][]byte
+	indexes  map[string]uint64
+	sessions map[string]bool
+	seq      uint64
+	err      error
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{
+		values:   map[string][]byte{},
+		indexes:  map[string]uint64{},
+		sessions: map[string]bool{},
+	}
+}
+
+func (c *mockClient) Get(_ context.Context, key string) ([]byte, uint64, error) {
+	if c.err != nil {
+		return nil, 0, c.err
+	}
+	return c.values[key], c.indexes[key], nil
+}
+
+func (c *mockClient) Put(_ context.Context, key string, value []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.seq++
+	c.values[key] = value
+	c.indexes[key] = c.seq
+	return nil
+}
+
+func (c *mockClient) CAS(_ context.Context, key string, value []byte, index uint64) (uint64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.indexes[key] != index {
+		return 0, storage.ErrVersionConflict
+	}
+	c.seq++
+	c.values[key] = value
+	c.indexes[key] = c.seq
+	return c.seq, nil
+}
+
+func (c *mockClient) Acquire(_ context.Context, key string, value []byte) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.sessions[key] {
+		return false, nil
+	}
+	c.seq++
+	c.values[key] = value
+	c.indexes[key] = c.seq
+	c.sessions[key] = true
+	return true, nil
+}
+
+func (c *mockClient) Release(_ context.Context, key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.values, key)
+	delete(c.indexes, key)
+	delete(c.sessions, key)
+	return nil
+}
+
+func (c *mockClient) ReleaseCAS(_ context.Context, key string, index uint64) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if _, ok := c.values[key]; !ok || c.indexes[key] != index {
+		return false, nil
+	}
+	delete(c.values, key)
+	delete(c.indexes, key)
+	delete(c.sessions, key)
+	return true, nil
+}
+
+func TestStorageWrite(t *testing.T) {
+	cases := []struct {
+		desc     string
+		client   *mockClient
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc:     "simple",
+			client:   newMockClient(),
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "permission denied",
+			client: &mockClient{
+				err: errors.New("Permission denied"),
+			},
+			contents: []byte("foo"),
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{
+				Path: "tfmigrate/history.json",
+			}
+			s, err := NewStorage(config, tc.client)
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			err = s.Write(context.Background(), tc.contents)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			if tc.ok {
+				got := tc.client.values[config.Path]
+				if string(got) != string(tc.contents) {
+					t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+				}
+			}
+		})
+	}
+}
+
+func TestStorageRead(t *testing.T) {
+	cases := []struct {
+		desc     string
+		client   *mockClient
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc: "simple",
+			client: &mockClient{
+				values:  map[string][]byte{"tfmigrate/history.json": []byte("foo")},
+				indexes: map[string]uint64{"tfmigrate/history.json": 1},
+			},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc:     "key does not exist",
+			client:   newMockClient(),
+			contents: []byte{},
+			ok:       true,
+		},
+		{
+			desc: "permission denied",
+			client: &mockClient{
+				err: errors.New("Permission denied"),
+			},
+			contents: nil,
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{
+				Path: "tfmigrate/history.json",
+			}
+			s, err := NewStorage(config, tc.client)
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			got, err := s.Read(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			if tc.ok {
+				if string(got) != string(tc.contents) {
+					t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+				}
+			}
+		})
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	config := &Config{
+		Path: "tfmigrate/history.json",
+	}
+	s, err := NewStorage(config, newMockClient())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	testStorageWriteWithVersion(t, s)
+}
+
+func TestStorageLock(t *testing.T) {
+	config := &Config{
+		Path: "tfmigrate/history.json",
+	}
+	client := newMockClient()
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	testStorageLock(t, s)
+
+	if _, ok := client.values["tfmigrate/history.json.lock"]; ok {
+		t.Error("expected to delete a lock key, but found")
+	}
+}
+
+// TestAccStorage runs tests against a Consul agent such as `consul agent -dev`.
+// It requires CONSUL_HTTP_ADDR to be set.
+func TestAccStorage(t *testing.T) {
+	if os.Getenv("TEST_ACC") != "1" {
+		t.Skip("skip acceptance tests")
+	}
+	if os.Getenv("CONSUL_HTTP_ADDR") == "" {
+		t.Skip("skip acceptance tests for consul because CONSUL_HTTP_ADDR is not set")
+	}
+
+	ctx := context.Background()
+	config := &Config{
+		Path: fmt.Sprintf("tfmigrate-test/%s/history.json", t.Name()),
+	}
+	s, err := NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = s.client.Release(ctx, config.Path)
+		_ = s.client.Release(ctx, config.Path+storage.LockSuffix)
+	})
+
+	testStorageWriteWithVersion(t, s)
+	testStorageLock(t, s)
+}
+
+// testStorageWriteWithVersion tests compare-and-swap writes of a given storage.
+func testStorageWriteWithVersion(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	b, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(b) != 0 || version != "" {
+		t.Fatalf("expected an empty version for a key which doesn't exist, got: %s", version)
+	}
+
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("failed to create a key: %s", err)
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for an existing key, but got: %v", err)
+	}
+
+	// simulate a concurrent update by someone else
+	if err := s.Write(ctx, []byte("baz")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for a stale version, but got: %v", err)
+	}
+
+	got, v2, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "baz" {
+		t.Errorf("got: %s, want: baz", string(got))
+	}
+	v3, err := s.WriteWithVersion(ctx, []byte("bar"), v2)
+	if err != nil {
+		t.Fatalf("failed to write with the latest version: %s", err)
+	}
+
+	got, v4, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "bar" {
+		t.Errorf("got: %s, want: bar", string(got))
+	}
+	if v3 != v4 {
+		t.Errorf("expected to return the latest version, got: %s, want: %s", v3, v4)
+	}
+}
+
+// testStorageLock tests locking of a given storage.
+func testStorageLock(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+
+	// The lock can be acquired again just after released.
+	if err := s.Lock(ctx, info2); err != nil {
+		t.Fatalf("failed to lock again: %s", err)
+	}
+	if err := s.Unlock(ctx, info2.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
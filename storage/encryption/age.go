@@ -0,0 +1,116 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+)
+
+// AgeConfig is a config for an age key provider.
+type AgeConfig struct {
+	// Recipients is a list of age public keys to encrypt a data key.
+	// If not set, recipients are derived from identities.
+	Recipients []string `hcl:"recipients,optional"`
+	// IdentityFile is a path to an age identity file to decrypt a data key.
+	// It can also be set via the AGE_IDENTITY_FILE environment variable.
+	IdentityFile string `hcl:"identity_file,optional"`
+}
+
+// AgeConfig implements a KeyProviderConfig.
+var _ KeyProviderConfig = (*AgeConfig)(nil)
+
+// NewKeyProvider returns a new instance of KeyProvider.
+func (c *AgeConfig) NewKeyProvider() (KeyProvider, error) {
+	p := &AgeKeyProvider{}
+
+	for _, r := range c.Recipients {
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age recipient: %w", err)
+		}
+		p.recipients = append(p.recipients, recipient)
+	}
+
+	identityFile := c.IdentityFile
+	if identityFile == "" {
+		identityFile = os.Getenv("AGE_IDENTITY_FILE")
+	}
+	if identityFile != "" {
+		b, err := os.ReadFile(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load age identity: %w", err)
+		}
+		identities, err := age.ParseIdentities(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity: %w", err)
+		}
+		p.identities = identities
+
+		if len(c.Recipients) == 0 {
+			for _, i := range identities {
+				if x, ok := i.(*age.X25519Identity); ok {
+					p.recipients = append(p.recipients, x.Recipient())
+				}
+			}
+		}
+	}
+
+	if len(p.recipients) == 0 {
+		return nil, fmt.Errorf("failed to create age key provider: either recipients or identity_file is required")
+	}
+
+	return p, nil
+}
+
+// AgeKeyProvider is a KeyProvider implementation with age.
+// https://age-encryption.org/
+type AgeKeyProvider struct {
+	// recipients is a list of recipients to encrypt a data key.
+	recipients []age.Recipient
+	// identities is a list of identities to decrypt a data key.
+	identities []age.Identity
+}
+
+var _ KeyProvider = (*AgeKeyProvider)(nil)
+
+// Name returns a type name of the key provider.
+func (p *AgeKeyProvider) Name() string {
+	return "age"
+}
+
+// WrapKey encrypts a given data key.
+func (p *AgeKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, p.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %w", err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnwrapKey decrypts a given wrapped data key.
+func (p *AgeKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(p.identities) == 0 {
+		return nil, fmt.Errorf("failed to decrypt with age: identity_file is not set")
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(wrapped), p.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with age: %w", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with age: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,124 @@
+package encryption
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate identity: %s", err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate identity: %s", err)
+	}
+
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write identity file: %s", err)
+	}
+
+	dataKey := []byte("abcdefghijabcdefghijabcdefghijab")
+
+	cases := []struct {
+		desc      string
+		env       map[string]string
+		encrypt   *AgeConfig
+		decrypt   *AgeConfig
+		configOK  bool
+		decryptOK bool
+	}{
+		{
+			desc:      "identity file",
+			encrypt:   &AgeConfig{IdentityFile: identityFile},
+			decrypt:   &AgeConfig{IdentityFile: identityFile},
+			configOK:  true,
+			decryptOK: true,
+		},
+		{
+			desc:      "identity file from env",
+			env:       map[string]string{"AGE_IDENTITY_FILE": identityFile},
+			encrypt:   &AgeConfig{},
+			decrypt:   &AgeConfig{},
+			configOK:  true,
+			decryptOK: true,
+		},
+		{
+			desc:      "multiple recipients",
+			encrypt:   &AgeConfig{Recipients: []string{other.Recipient().String(), identity.Recipient().String()}},
+			decrypt:   &AgeConfig{IdentityFile: identityFile},
+			configOK:  true,
+			decryptOK: true,
+		},
+		{
+			desc:      "recipients only",
+			encrypt:   &AgeConfig{Recipients: []string{identity.Recipient().String()}},
+			decrypt:   &AgeConfig{Recipients: []string{identity.Recipient().String()}},
+			configOK:  true,
+			decryptOK: false,
+		},
+		{
+			desc:      "not a recipient",
+			encrypt:   &AgeConfig{Recipients: []string{other.Recipient().String()}},
+			decrypt:   &AgeConfig{IdentityFile: identityFile},
+			configOK:  true,
+			decryptOK: false,
+		},
+		{
+			desc:     "invalid recipient",
+			encrypt:  &AgeConfig{Recipients: []string{"foo"}},
+			configOK: false,
+		},
+		{
+			desc:     "neither recipients nor identity_file",
+			encrypt:  &AgeConfig{},
+			configOK: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			enc, err := tc.encrypt.NewKeyProvider()
+			if tc.configOK && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.configOK {
+				if err == nil {
+					t.Fatalf("expected to return an error, but no error, got: %#v", enc)
+				}
+				return
+			}
+
+			wrapped, err := enc.WrapKey(ctx, dataKey)
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			dec, err := tc.decrypt.NewKeyProvider()
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			got, err := dec.UnwrapKey(ctx, wrapped)
+			if tc.decryptOK && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.decryptOK && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %x", got)
+			}
+			if tc.decryptOK && string(got) != string(dataKey) {
+				t.Errorf("got: %x, want: %x", got, dataKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// awsKMSEncryptionContext is an encryption context bound to a data key.
+var awsKMSEncryptionContext = map[string]string{
+	"tfmigrate": "history",
+}
+
+// AWSKMSConfig is a config for an AWS KMS key provider.
+type AWSKMSConfig struct {
+	// KeyID is an ID, ARN or alias of the KMS key.
+	KeyID string `hcl:"key_id"`
+	// AWS region.
+	Region string `hcl:"region,optional"`
+	// Name of AWS profile in AWS shared credentials file.
+	Profile string `hcl:"profile,optional"`
+	// Custom endpoint for the AWS KMS API.
+	Endpoint string `hcl:"endpoint,optional"`
+}
+
+// AWSKMSConfig implements a KeyProviderConfig.
+var _ KeyProviderConfig = (*AWSKMSConfig)(nil)
+
+// NewKeyProvider returns a new instance of KeyProvider.
+func (c *AWSKMSConfig) NewKeyProvider() (KeyProvider, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if c.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(c.Region))
+	}
+	if c.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(c.Profile))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	client := kms.NewFromConfig(awsConfig, func(options *kms.Options) {
+		if c.Endpoint != "" {
+			options.BaseEndpoint = aws.String(c.Endpoint)
+		}
+	})
+
+	return NewAWSKMSKeyProvider(c, client), nil
+}
+
+// AWSKMSClient is an abstraction layer for AWS KMS API.
+// It is intended to be replaced with a mock for testing.
+type AWSKMSClient interface {
+	// Encrypt encrypts a plaintext with a KMS key.
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	// Decrypt decrypts a ciphertext encrypted with a KMS key.
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// AWSKMSKeyProvider is a KeyProvider implementation with AWS KMS.
+type AWSKMSKeyProvider struct {
+	// config is a config for AWS KMS.
+	config *AWSKMSConfig
+	// client is an instance of AWSKMSClient.
+	client AWSKMSClient
+}
+
+var _ KeyProvider = (*AWSKMSKeyProvider)(nil)
+
+// NewAWSKMSKeyProvider returns a new instance of AWSKMSKeyProvider.
+func NewAWSKMSKeyProvider(config *AWSKMSConfig, client AWSKMSClient) *AWSKMSKeyProvider {
+	return &AWSKMSKeyProvider{
+		config: config,
+		client: client,
+	}
+}
+
+// Name returns a type name of the key provider.
+func (p *AWSKMSKeyProvider) Name() string {
+	return "aws_kms"
+}
+
+// WrapKey encrypts a given data key.
+func (p *AWSKMSKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	output, err := p.client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(p.config.KeyID),
+		Plaintext:         dataKey,
+		EncryptionContext: awsKMSEncryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with aws kms: %w", err)
+	}
+	return output.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts a given wrapped data key.
+func (p *AWSKMSKeyProvider) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	output, err := p.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(p.config.KeyID),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: awsKMSEncryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with aws kms: %w", err)
+	}
+	return output.Plaintext, nil
+}
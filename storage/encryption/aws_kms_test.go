@@ -0,0 +1,95 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// mockAWSKMSClient is a mock implementation of AWSKMSClient for testing.
+// It "encrypts" a plaintext by prepending a key ID.
+type mockAWSKMSClient struct {
+	err error
+}
+
+func (c *mockAWSKMSClient) Encrypt(_ context.Context, params *kms.EncryptInput, _ ...func(*kms.Options)) (*kms.EncryptOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if !reflect.DeepEqual(params.EncryptionContext, awsKMSEncryptionContext) {
+		return nil, errors.New("unexpected encryption context")
+	}
+	blob := append([]byte(aws.ToString(params.KeyId)+":"), params.Plaintext...)
+	return &kms.EncryptOutput{CiphertextBlob: blob}, nil
+}
+
+func (c *mockAWSKMSClient) Decrypt(_ context.Context, params *kms.DecryptInput, _ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	prefix := []byte(aws.ToString(params.KeyId) + ":")
+	if len(params.CiphertextBlob) < len(prefix) || string(params.CiphertextBlob[:len(prefix)]) != string(prefix) {
+		return nil, errors.New("IncorrectKeyException")
+	}
+	return &kms.DecryptOutput{Plaintext: params.CiphertextBlob[len(prefix):]}, nil
+}
+
+func TestAWSKMSKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	dataKey := []byte("abcdefghijabcdefghijabcdefghijab")
+
+	cases := []struct {
+		desc    string
+		encrypt string
+		decrypt string
+		err     error
+		ok      bool
+	}{
+		{
+			desc:    "valid",
+			encrypt: "alias/tfmigrate",
+			decrypt: "alias/tfmigrate",
+			ok:      true,
+		},
+		{
+			desc:    "different key",
+			encrypt: "alias/tfmigrate",
+			decrypt: "alias/foo",
+			ok:      false,
+		},
+		{
+			desc:    "api error",
+			encrypt: "alias/tfmigrate",
+			decrypt: "alias/tfmigrate",
+			err:     errors.New("AccessDeniedException"),
+			ok:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := &mockAWSKMSClient{err: tc.err}
+			enc := NewAWSKMSKeyProvider(&AWSKMSConfig{KeyID: tc.encrypt}, client)
+			dec := NewAWSKMSKeyProvider(&AWSKMSConfig{KeyID: tc.decrypt}, client)
+
+			wrapped, err := enc.WrapKey(ctx, dataKey)
+			if err == nil {
+				var got []byte
+				got, err = dec.UnwrapKey(ctx, wrapped)
+				if err == nil && string(got) != string(dataKey) {
+					t.Errorf("got: %x, want: %x", got, dataKey)
+				}
+			}
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package encryption
+
+import (
+	"fmt"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Config is a config for encryption of migration history.
+// It wraps any storage config and encrypts data written to it.
+type Config struct {
+	// Storage is a config of the underlying storage.
+	Storage storage.Config
+	// KeyProviders is a list of key provider configs.
+	// A data key is wrapped by all of them on write, and unwrapped by any of
+	// them on read.
+	KeyProviders []KeyProviderConfig
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	if len(c.KeyProviders) == 0 {
+		return nil, fmt.Errorf("failed to create encrypted storage: at least one key provider is required")
+	}
+
+	s, err := c.Storage.NewStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]KeyProvider, 0, len(c.KeyProviders))
+	for _, pc := range c.KeyProviders {
+		p, err := pc.NewKeyProvider()
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	return NewStorage(s, providers), nil
+}
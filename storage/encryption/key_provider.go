@@ -0,0 +1,145 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider is an abstraction layer for a key encryption key.
+// It wraps and unwraps a data key which encrypts migration history data,
+// so that a key encryption key can be managed by an external system such as
+// age or a KMS.
+type KeyProvider interface {
+	// Name returns a type name of the key provider such as "age".
+	// It is recorded in encrypted data to select key providers on read.
+	Name() string
+	// WrapKey encrypts a given data key.
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a given wrapped data key.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// KeyProviderConfig is an interface of factory method for KeyProvider.
+type KeyProviderConfig interface {
+	// NewKeyProvider returns a new instance of KeyProvider.
+	NewKeyProvider() (KeyProvider, error)
+}
+
+// StaticConfig is a config for a static key provider.
+// The key is a base64 encoded 32 bytes key for AES-256-GCM.
+type StaticConfig struct {
+	// File is a path to a file which contains a key.
+	File string `hcl:"file,optional"`
+	// Env is a name of environment variable which contains a key.
+	Env string `hcl:"env,optional"`
+}
+
+// StaticConfig implements a KeyProviderConfig.
+var _ KeyProviderConfig = (*StaticConfig)(nil)
+
+// NewKeyProvider returns a new instance of KeyProvider.
+func (c *StaticConfig) NewKeyProvider() (KeyProvider, error) {
+	var encoded string
+	switch {
+	case c.File != "" && c.Env != "":
+		return nil, fmt.Errorf("failed to load static key: file and env are mutually exclusive")
+	case c.File != "":
+		b, err := os.ReadFile(c.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load static key: %w", err)
+		}
+		encoded = string(b)
+	case c.Env != "":
+		v, ok := os.LookupEnv(c.Env)
+		if !ok {
+			return nil, fmt.Errorf("failed to load static key: environment variable %s is not set", c.Env)
+		}
+		encoded = v
+	default:
+		return nil, fmt.Errorf("failed to load static key: either file or env is required")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode static key: %w", err)
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("failed to load static key: key must be %d bytes, got %d bytes", dataKeySize, len(key))
+	}
+
+	return &StaticKeyProvider{key: key}, nil
+}
+
+// StaticKeyProvider is a KeyProvider implementation with a static key.
+// It wraps a data key with AES-256-GCM.
+type StaticKeyProvider struct {
+	// key is a key encryption key.
+	key []byte
+}
+
+var _ KeyProvider = (*StaticKeyProvider)(nil)
+
+// Name returns a type name of the key provider.
+func (p *StaticKeyProvider) Name() string {
+	return "static"
+}
+
+// WrapKey encrypts a given data key.
+func (p *StaticKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return seal(p.key, dataKey)
+}
+
+// UnwrapKey decrypts a given wrapped data key.
+func (p *StaticKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return open(p.key, wrapped)
+}
+
+// seal encrypts a given plaintext with AES-256-GCM.
+// A random nonce is prepended to the ciphertext.
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a given ciphertext encrypted by seal.
+func open(key []byte, ciphertext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt: ciphertext too short")
+	}
+	nonce, body := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// newAEAD returns a new AES-GCM cipher with a given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,107 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticConfigNewKeyProvider(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("01234567890123456789012345678901"))
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write key file: %s", err)
+	}
+
+	cases := []struct {
+		desc   string
+		env    map[string]string
+		config *StaticConfig
+		ok     bool
+	}{
+		{
+			desc:   "file",
+			config: &StaticConfig{File: keyFile},
+			ok:     true,
+		},
+		{
+			desc:   "env",
+			env:    map[string]string{"TEST_KEY": key},
+			config: &StaticConfig{Env: "TEST_KEY"},
+			ok:     true,
+		},
+		{
+			desc:   "both file and env",
+			env:    map[string]string{"TEST_KEY": key},
+			config: &StaticConfig{File: keyFile, Env: "TEST_KEY"},
+			ok:     false,
+		},
+		{
+			desc:   "neither file nor env",
+			config: &StaticConfig{},
+			ok:     false,
+		},
+		{
+			desc:   "file not found",
+			config: &StaticConfig{File: filepath.Join(dir, "not_exist")},
+			ok:     false,
+		},
+		{
+			desc:   "env not set",
+			config: &StaticConfig{Env: "TEST_KEY_NOT_SET"},
+			ok:     false,
+		},
+		{
+			desc:   "invalid base64",
+			env:    map[string]string{"TEST_KEY": "foo!"},
+			config: &StaticConfig{Env: "TEST_KEY"},
+			ok:     false,
+		},
+		{
+			desc:   "invalid key size",
+			env:    map[string]string{"TEST_KEY": base64.StdEncoding.EncodeToString([]byte("foo"))},
+			config: &StaticConfig{Env: "TEST_KEY"},
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			got, err := tc.config.NewKeyProvider()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+		})
+	}
+}
+
+func TestStaticKeyProviderWrapKey(t *testing.T) {
+	ctx := context.Background()
+	p := &StaticKeyProvider{key: []byte("01234567890123456789012345678901")}
+	dataKey := []byte("abcdefghijabcdefghijabcdefghijab")
+
+	wrapped, err := p.WrapKey(ctx, dataKey)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	got, err := p.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != string(dataKey) {
+		t.Errorf("got: %x, want: %x", got, dataKey)
+	}
+
+	if _, err := p.UnwrapKey(ctx, wrapped[:8]); err == nil {
+		t.Errorf("expected to return an error for a truncated key, but no error")
+	}
+}
@@ -0,0 +1,225 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// dataKeySize is a size of a data key for AES-256-GCM.
+const dataKeySize = 32
+
+// envelopeVersion is a version of the encrypted data format.
+const envelopeVersion = 1
+
+// envelope is a data structure for persistence of encrypted data.
+// A random data key encrypts the history data, and the data key is wrapped by
+// each key provider.
+type envelope struct {
+	// Encryption is a metadata for decryption.
+	// It is used for distinguishing encrypted data from plaintext.
+	Encryption *envelopeHeader `json:"encryption"`
+	// Ciphertext is the encrypted history data with a nonce prepended.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// envelopeHeader is a metadata of encrypted data.
+type envelopeHeader struct {
+	// Version is a version of the envelope format.
+	Version int `json:"version"`
+	// Algorithm is an algorithm of data encryption.
+	Algorithm string `json:"algorithm"`
+	// Keys is a list of wrapped data keys.
+	Keys []wrappedKey `json:"keys"`
+}
+
+// wrappedKey is a data key wrapped by a key provider.
+type wrappedKey struct {
+	// Provider is a type name of the key provider.
+	Provider string `json:"provider"`
+	// Key is a wrapped data key.
+	Key []byte `json:"key"`
+}
+
+// Storage is a storage.Storage implementation which wraps another storage
+// with envelope encryption.
+// Data written by Write is always encrypted, but Read can also read plaintext
+// data so that we can enable encryption for an existing history.
+type Storage struct {
+	// storage is an underlying storage.
+	storage storage.Storage
+	// providers is a list of key providers.
+	providers []KeyProvider
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+// versionedStorage is a Storage with compare-and-swap support.
+type versionedStorage struct {
+	*Storage
+}
+
+var _ storage.VersionedStorage = (*versionedStorage)(nil)
+
+// lockingStorage is a Storage with locking support.
+// Lock data is not encrypted because it doesn't contain history data.
+type lockingStorage struct {
+	*Storage
+	storage.Locker
+}
+
+var _ storage.Locker = (*lockingStorage)(nil)
+
+// versionedLockingStorage is a Storage with both compare-and-swap and
+// locking support.
+type versionedLockingStorage struct {
+	*versionedStorage
+	storage.Locker
+}
+
+var _ storage.VersionedStorage = (*versionedLockingStorage)(nil)
+var _ storage.Locker = (*versionedLockingStorage)(nil)
+
+// NewStorage returns a new instance of storage.Storage which wraps a given
+// storage. The returned value implements optional interfaces such as
+// storage.VersionedStorage and storage.Locker only if the given storage does.
+func NewStorage(s storage.Storage, providers []KeyProvider) storage.Storage {
+	es := &Storage{
+		storage:   s,
+		providers: providers,
+	}
+
+	_, versioned := s.(storage.VersionedStorage)
+	locker, locking := s.(storage.Locker)
+	switch {
+	case versioned && locking:
+		return &versionedLockingStorage{versionedStorage: &versionedStorage{es}, Locker: locker}
+	case versioned:
+		return &versionedStorage{es}
+	case locking:
+		return &lockingStorage{Storage: es, Locker: locker}
+	default:
+		return es
+	}
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	encrypted, err := s.encrypt(ctx, b)
+	if err != nil {
+		return err
+	}
+	return s.storage.Write(ctx, encrypted)
+}
+
+// Read reads migration history data from storage.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, err := s.storage.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt(ctx, b)
+}
+
+// ReadWithVersion reads migration history data with a version token.
+func (s *versionedStorage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	b, version, err := s.storage.(storage.VersionedStorage).ReadWithVersion(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	decrypted, err := s.decrypt(ctx, b)
+	if err != nil {
+		return nil, "", err
+	}
+	return decrypted, version, nil
+}
+
+// WriteWithVersion writes migration history data only if the current version
+// matches a given one.
+func (s *versionedStorage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	encrypted, err := s.encrypt(ctx, b)
+	if err != nil {
+		return "", err
+	}
+	return s.storage.(storage.VersionedStorage).WriteWithVersion(ctx, encrypted, version)
+}
+
+// encrypt encrypts given data with a new data key.
+func (s *Storage) encrypt(ctx context.Context, b []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	header := &envelopeHeader{
+		Version:   envelopeVersion,
+		Algorithm: "AES256_GCM",
+	}
+	for _, p := range s.providers {
+		wrapped, err := p.WrapKey(ctx, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		header.Keys = append(header.Keys, wrappedKey{Provider: p.Name(), Key: wrapped})
+	}
+
+	ciphertext, err := seal(dataKey, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&envelope{Encryption: header, Ciphertext: ciphertext}, "", "    ")
+}
+
+// decrypt decrypts given data. If the data is not encrypted, it returns the
+// data as it is.
+func (s *Storage) decrypt(ctx context.Context, b []byte) ([]byte, error) {
+	var e envelope
+	if len(b) == 0 || json.Unmarshal(b, &e) != nil || e.Encryption == nil {
+		log.Printf("[DEBUG] [storage] history is not encrypted, read it as plaintext\n")
+		return b, nil
+	}
+
+	if e.Encryption.Version != envelopeVersion {
+		return nil, fmt.Errorf("failed to decrypt history: unknown encryption version: %d", e.Encryption.Version)
+	}
+
+	dataKey, err := s.unwrapKey(ctx, e.Encryption.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(dataKey, e.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt history: %w", err)
+	}
+	return plaintext, nil
+}
+
+// unwrapKey tries to unwrap a data key with any of key providers.
+func (s *Storage) unwrapKey(ctx context.Context, keys []wrappedKey) ([]byte, error) {
+	var errs []error
+	for _, p := range s.providers {
+		for _, k := range keys {
+			if k.Provider != p.Name() {
+				continue
+			}
+			dataKey, err := p.UnwrapKey(ctx, k.Key)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			return dataKey, nil
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("failed to decrypt history: no key provider matches the encrypted data")
+	}
+	return nil, fmt.Errorf("failed to decrypt history: %w", errors.Join(errs...))
+}
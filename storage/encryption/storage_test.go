@@ -0,0 +1,265 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"github.com/minamijoyo/tfmigrate/storage/mock"
+)
+
+// plainStorage is a storage.Storage implementation without any optional
+// interfaces for testing.
+type plainStorage struct {
+	data []byte
+}
+
+func (s *plainStorage) Write(_ context.Context, b []byte) error {
+	s.data = b
+	return nil
+}
+
+func (s *plainStorage) Read(_ context.Context) ([]byte, error) {
+	return s.data, nil
+}
+
+// newTestKeyProvider returns a new static key provider with a given key.
+func newTestKeyProvider(t *testing.T, key string) KeyProvider {
+	t.Helper()
+	t.Setenv("TEST_KEY", base64.StdEncoding.EncodeToString([]byte(key)))
+	p, err := (&StaticConfig{Env: "TEST_KEY"}).NewKeyProvider()
+	if err != nil {
+		t.Fatalf("failed to create key provider: %s", err)
+	}
+	return p
+}
+
+func TestStorageWriteRead(t *testing.T) {
+	ctx := context.Background()
+	plaintext := []byte(`{"version": 2, "records": {}}`)
+	inner := &plainStorage{}
+	s := NewStorage(inner, []KeyProvider{newTestKeyProvider(t, "01234567890123456789012345678901")})
+
+	if err := s.Write(ctx, plaintext); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if bytes.Contains(inner.data, []byte("records")) {
+		t.Errorf("data is not encrypted: %s", string(inner.data))
+	}
+
+	got, err := s.Read(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("got: %s, want: %s", string(got), string(plaintext))
+	}
+}
+
+func TestStorageRead(t *testing.T) {
+	ctx := context.Background()
+	key := "01234567890123456789012345678901"
+	encrypted := &plainStorage{}
+	if err := NewStorage(encrypted, []KeyProvider{newTestKeyProvider(t, key)}).Write(ctx, []byte("foo")); err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+
+	cases := []struct {
+		desc string
+		data []byte
+		key  string
+		want []byte
+		ok   bool
+	}{
+		{
+			desc: "encrypted",
+			data: encrypted.data,
+			key:  key,
+			want: []byte("foo"),
+			ok:   true,
+		},
+		{
+			desc: "plaintext",
+			data: []byte(`{"version": 2, "records": {}}`),
+			key:  key,
+			want: []byte(`{"version": 2, "records": {}}`),
+			ok:   true,
+		},
+		{
+			desc: "empty",
+			data: []byte{},
+			key:  key,
+			want: []byte{},
+			ok:   true,
+		},
+		{
+			desc: "wrong key",
+			data: encrypted.data,
+			key:  "abcdefghijabcdefghijabcdefghijab",
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "no matching key provider",
+			data: []byte(`{"encryption": {"version": 1, "algorithm": "AES256_GCM", "keys": [{"provider": "age", "key": ""}]}, "ciphertext": ""}`),
+			key:  key,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "unknown version",
+			data: []byte(`{"encryption": {"version": 2}}`),
+			key:  key,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := NewStorage(&plainStorage{data: tc.data}, []KeyProvider{newTestKeyProvider(t, tc.key)})
+			got, err := s.Read(ctx)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %s", string(got))
+			}
+			if tc.ok && !bytes.Equal(got, tc.want) {
+				t.Errorf("got: %s, want: %s", string(got), string(tc.want))
+			}
+		})
+	}
+}
+
+func TestStorageKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := newTestKeyProvider(t, "01234567890123456789012345678901")
+	newKey := newTestKeyProvider(t, "abcdefghijabcdefghijabcdefghijab")
+
+	inner := &plainStorage{}
+	if err := NewStorage(inner, []KeyProvider{oldKey}).Write(ctx, []byte("foo")); err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+
+	// read with the old key and write with both keys.
+	s := NewStorage(inner, []KeyProvider{newKey, oldKey})
+	b, err := s.Read(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := s.Write(ctx, b); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	got, err := NewStorage(inner, []KeyProvider{newKey}).Read(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "foo" {
+		t.Errorf("got: %s, want: foo", string(got))
+	}
+}
+
+func TestNewStorageOptionalInterfaces(t *testing.T) {
+	provider := newTestKeyProvider(t, "01234567890123456789012345678901")
+
+	s := NewStorage(&plainStorage{}, []KeyProvider{provider})
+	if _, ok := s.(storage.VersionedStorage); ok {
+		t.Errorf("expected not to implement storage.VersionedStorage: %T", s)
+	}
+	if _, ok := s.(storage.Locker); ok {
+		t.Errorf("expected not to implement storage.Locker: %T", s)
+	}
+
+	m, err := mock.NewStorage(&mock.Config{})
+	if err != nil {
+		t.Fatalf("failed to create mock storage: %s", err)
+	}
+	s = NewStorage(m, []KeyProvider{provider})
+	if _, ok := s.(storage.VersionedStorage); !ok {
+		t.Errorf("expected to implement storage.VersionedStorage: %T", s)
+	}
+	if _, ok := s.(storage.Locker); !ok {
+		t.Errorf("expected to implement storage.Locker: %T", s)
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	ctx := context.Background()
+	config := &mock.Config{}
+	m, err := mock.NewStorage(config)
+	if err != nil {
+		t.Fatalf("failed to create mock storage: %s", err)
+	}
+	s := NewStorage(m, []KeyProvider{newTestKeyProvider(t, "01234567890123456789012345678901")}).(storage.VersionedStorage)
+
+	version, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if strings.Contains(config.Data, "foo") {
+		t.Errorf("data is not encrypted: %s", config.Data)
+	}
+
+	got, gotVersion, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "foo" || gotVersion != version {
+		t.Errorf("got: %s (%s), want: foo (%s)", string(got), gotVersion, version)
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("expected to return ErrVersionConflict, got: %v", err)
+	}
+}
+
+func TestConfigNewStorage(t *testing.T) {
+	t.Setenv("TEST_KEY", base64.StdEncoding.EncodeToString([]byte("01234567890123456789012345678901")))
+	cases := []struct {
+		desc   string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			config: &Config{
+				Storage:      &mock.Config{},
+				KeyProviders: []KeyProviderConfig{&StaticConfig{Env: "TEST_KEY"}},
+			},
+			ok: true,
+		},
+		{
+			desc: "no key providers",
+			config: &Config{
+				Storage: &mock.Config{},
+			},
+			ok: false,
+		},
+		{
+			desc: "invalid key provider",
+			config: &Config{
+				Storage:      &mock.Config{},
+				KeyProviders: []KeyProviderConfig{&StaticConfig{Env: "NOT_EXIST"}},
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+		})
+	}
+}
@@ -2,10 +2,20 @@ package gcs
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 
 	gcStorage "cloud.google.com/go/storage"
+	"github.com/minamijoyo/tfmigrate/storage"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
 )
 
 // A minimal interface to mock behavior of GCS client.
@@ -15,6 +25,31 @@ type Client interface {
 
 	// Write an object onto a GCS bucket.
 	Write(ctx context.Context, p []byte) error
+
+	// ReadWithGeneration reads an object with its generation from a GCS bucket.
+	ReadWithGeneration(ctx context.Context) ([]byte, int64, error)
+
+	// WriteWithGeneration writes an object onto a GCS bucket only if its
+	// current generation matches a given one, and returns a new generation.
+	// A zero generation means that the object must not exist.
+	// If the generation doesn't match, returns storage.ErrVersionConflict.
+	WriteWithGeneration(ctx context.Context, p []byte, generation int64) (int64, error)
+
+	// CreateLock creates a lock object next to the object only if it doesn't exist.
+	// It returns false with no error if the lock object already exists.
+	CreateLock(ctx context.Context, p []byte) (bool, error)
+
+	// ReadLock reads a lock object with its generation.
+	// If it doesn't exist, returns nil with no error.
+	ReadLock(ctx context.Context) ([]byte, int64, error)
+
+	// ReplaceLock overwrites a lock object only if its current generation
+	// matches a given one. It returns false with no error if the generation
+	// doesn't match or the lock object doesn't exist.
+	ReplaceLock(ctx context.Context, p []byte, generation int64) (bool, error)
+
+	// DeleteLock deletes a lock object.
+	DeleteLock(ctx context.Context) error
 }
 
 // An implementation of Client that delegates actual operation to gcsStorage.Client.
@@ -23,10 +58,33 @@ type Adapter struct {
 	config Config
 	// A GCS client which is delegated actual operation.
 	client *gcStorage.Client
+	// A decoded customer-supplied encryption key for the history object.
+	encryptionKey []byte
+	// A Cloud KMS key name to encrypt the history object.
+	kmsKeyName string
+}
+
+// object returns a handle of the history object with an encryption key.
+// Note that the lock object is not encrypted.
+func (a Adapter) object() *gcStorage.ObjectHandle {
+	o := a.client.Bucket(a.config.Bucket).Object(a.config.Name)
+	if a.encryptionKey != nil {
+		o = o.Key(a.encryptionKey)
+	}
+	return o
+}
+
+// newWriter returns a writer of a given object handle with a KMS key.
+func (a Adapter) newWriter(ctx context.Context, o *gcStorage.ObjectHandle) *gcStorage.Writer {
+	w := o.NewWriter(ctx)
+	if a.kmsKeyName != "" {
+		w.KMSKeyName = a.kmsKeyName
+	}
+	return w
 }
 
 func (a Adapter) Read(ctx context.Context) ([]byte, error) {
-	r, err := a.client.Bucket(a.config.Bucket).Object(a.config.Name).NewReader(ctx)
+	r, err := a.object().NewReader(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +98,7 @@ func (a Adapter) Read(ctx context.Context) ([]byte, error) {
 }
 
 func (a Adapter) Write(ctx context.Context, p []byte) error {
-	w := a.client.Bucket(a.config.Bucket).Object(a.config.Name).NewWriter(ctx)
+	w := a.newWriter(ctx, a.object())
 	_, err := w.Write(p)
 
 	if err != nil {
@@ -49,12 +107,213 @@ func (a Adapter) Write(ctx context.Context, p []byte) error {
 	return w.Close()
 }
 
+func (a Adapter) ReadWithGeneration(ctx context.Context) ([]byte, int64, error) {
+	r, err := a.object().NewReader(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed reading from gcs://%s/%s: %w", a.config.Bucket, a.config.Name, err)
+	}
+	return body, r.Attrs.Generation, nil
+}
+
+func (a Adapter) WriteWithGeneration(ctx context.Context, p []byte, generation int64) (int64, error) {
+	// Use a precondition to write the object only if the generation matches.
+	cond := gcStorage.Conditions{GenerationMatch: generation}
+	if generation == 0 {
+		cond = gcStorage.Conditions{DoesNotExist: true}
+	}
+	w := a.newWriter(ctx, a.object().If(cond))
+	if _, err := w.Write(p); err != nil {
+		w.Close()
+		return 0, fmt.Errorf("failed writing to gcs://%s/%s: %w", a.config.Bucket, a.config.Name, err)
+	}
+
+	if err := w.Close(); err != nil {
+		if isPreconditionFailed(err) {
+			return 0, storage.ErrVersionConflict
+		}
+		return 0, fmt.Errorf("failed writing to gcs://%s/%s: %w", a.config.Bucket, a.config.Name, err)
+	}
+	return w.Attrs().Generation, nil
+}
+
+// isPreconditionFailed returns true if a given error is caused by a failure of
+// precondition.
+func isPreconditionFailed(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed
+}
+
+func (a Adapter) lockObject() *gcStorage.ObjectHandle {
+	return a.client.Bucket(a.config.Bucket).Object(a.config.Name + storage.LockSuffix)
+}
+
+func (a Adapter) CreateLock(ctx context.Context, p []byte) (bool, error) {
+	// Use a precondition to create the lock object atomically.
+	return a.writeLock(ctx, p, gcStorage.Conditions{DoesNotExist: true})
+}
+
+func (a Adapter) ReplaceLock(ctx context.Context, p []byte, generation int64) (bool, error) {
+	// Use a precondition to overwrite the lock object atomically.
+	// Note that a zero generation means the object doesn't exist.
+	if generation == 0 {
+		return false, nil
+	}
+	return a.writeLock(ctx, p, gcStorage.Conditions{GenerationMatch: generation})
+}
+
+// writeLock writes a lock object with a given precondition.
+// It returns false with no error if the precondition is not met.
+func (a Adapter) writeLock(ctx context.Context, p []byte, cond gcStorage.Conditions) (bool, error) {
+	w := a.lockObject().If(cond).NewWriter(ctx)
+	if _, err := w.Write(p); err != nil {
+		w.Close()
+		return false, fmt.Errorf("failed writing to gcs://%s/%s%s: %w", a.config.Bucket, a.config.Name, storage.LockSuffix, err)
+	}
+
+	err := w.Close()
+	if err != nil {
+		if isPreconditionFailed(err) {
+			// The lock object already exists or has been replaced
+			return false, nil
+		}
+		return false, fmt.Errorf("failed writing to gcs://%s/%s%s: %w", a.config.Bucket, a.config.Name, storage.LockSuffix, err)
+	}
+	return true, nil
+}
+
+func (a Adapter) ReadLock(ctx context.Context) ([]byte, int64, error) {
+	r, err := a.lockObject().NewReader(ctx)
+	if err == gcStorage.ErrObjectNotExist {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed reading from gcs://%s/%s%s: %w", a.config.Bucket, a.config.Name, storage.LockSuffix, err)
+	}
+	return body, r.Attrs.Generation, nil
+}
+
+func (a Adapter) DeleteLock(ctx context.Context) error {
+	err := a.lockObject().Delete(ctx)
+	if err == gcStorage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
+
 // NewClient returns a new Client with given Context and Config.
 func NewClient(ctx context.Context, config Config) (Client, error) {
-	c, err := gcStorage.NewClient(ctx)
+	opts, err := clientOptions(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var encryptionKey []byte
+	key := withEnv(config.EncryptionKey, "GOOGLE_ENCRYPTION_KEY")
+	kmsKeyName := withEnv(config.KMSEncryptionKey, "GOOGLE_KMS_ENCRYPTION_KEY")
+	if key != "" {
+		if kmsKeyName != "" {
+			return nil, fmt.Errorf("encryption_key and kms_encryption_key cannot be set at the same time")
+		}
+		encryptionKey, err = base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption_key: %w", err)
+		}
+		if len(encryptionKey) != 32 {
+			return nil, fmt.Errorf("encryption_key must be a 32 byte AES-256 key, but got %d bytes", len(encryptionKey))
+		}
+	}
+
+	c, err := gcStorage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	a := &Adapter{
-		config: config,
-		client: c,
+		config:        config,
+		client:        c,
+		encryptionKey: encryptionKey,
+		kmsKeyName:    kmsKeyName,
+	}
+	return a, nil
+}
+
+// clientOptions returns options of the GCS client for a given config.
+func clientOptions(ctx context.Context, config Config) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+
+	credentials := withEnv(config.Credentials, "GOOGLE_BACKEND_CREDENTIALS", "GOOGLE_CREDENTIALS")
+	accessToken := withEnv(config.AccessToken, "GOOGLE_OAUTH_ACCESS_TOKEN")
+	switch {
+	case accessToken != "":
+		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})))
+	case credentials != "":
+		// The credentials is either a path or a content of a key file.
+		b := []byte(credentials)
+		if _, err := os.Stat(credentials); err == nil {
+			b, err = os.ReadFile(credentials)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read credentials: %w", err)
+			}
+		}
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("credentials must be a path to or a content of a JSON key file")
+		}
+		opts = append(opts, option.WithCredentialsJSON(b))
+	}
+
+	impersonate := withEnv(config.ImpersonateServiceAccount, "GOOGLE_BACKEND_IMPERSONATE_SERVICE_ACCOUNT", "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT")
+	if impersonate != "" {
+		ts, err := impersonateTokenSource(ctx, impersonate, config.ImpersonateServiceAccountDelegates, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts = []option.ClientOption{option.WithTokenSource(ts)}
+	}
+
+	endpoint := withEnv(config.StorageCustomEndpoint, "GOOGLE_BACKEND_STORAGE_CUSTOM_ENDPOINT", "GOOGLE_STORAGE_CUSTOM_ENDPOINT")
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+
+	return opts, nil
+}
+
+// impersonateTokenSource returns a token source which impersonates a given
+// service account with base credentials.
+// It is a variable so that tests can replace it without calling the API.
+var impersonateTokenSource = func(ctx context.Context, target string, delegates []string, opts []option.ClientOption) (oauth2.TokenSource, error) {
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: target,
+		Scopes:          []string{gcStorage.ScopeReadWrite},
+		Delegates:       delegates,
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate service account %s: %w", target, err)
+	}
+	return ts, nil
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of the
+// first non-empty environment variable of given keys.
+func withEnv(value string, keys ...string) string {
+	if value != "" {
+		return value
+	}
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
 	}
-	return a, err
+	return ""
 }
@@ -0,0 +1,178 @@
+package gcs
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+)
+
+const testCredentials = `{"type": "authorized_user", "client_id": "foo", "client_secret": "bar", "refresh_token": "baz"}`
+
+func TestNewClient(t *testing.T) {
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(credentialsFile, []byte(testCredentials), 0600); err != nil {
+		t.Fatalf("failed to write credentials: %s", err)
+	}
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	cases := []struct {
+		desc              string
+		env               map[string]string
+		config            Config
+		wantEncryptionKey []byte
+		wantKMSKeyName    string
+		wantImpersonate   string
+		ok                bool
+	}{
+		{
+			desc: "access token",
+			config: Config{
+				Bucket:      "tfmigrate-test",
+				Name:        "tfmigrate/history.json",
+				AccessToken: "token",
+			},
+			ok: true,
+		},
+		{
+			desc: "credentials path",
+			config: Config{
+				Bucket:      "tfmigrate-test",
+				Name:        "tfmigrate/history.json",
+				Credentials: credentialsFile,
+			},
+			ok: true,
+		},
+		{
+			desc: "credentials content from env",
+			env: map[string]string{
+				"GOOGLE_CREDENTIALS": testCredentials,
+			},
+			config: Config{
+				Bucket: "tfmigrate-test",
+				Name:   "tfmigrate/history.json",
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid credentials",
+			config: Config{
+				Bucket:      "tfmigrate-test",
+				Name:        "tfmigrate/history.json",
+				Credentials: "/path/to/not/found.json",
+			},
+			ok: false,
+		},
+		{
+			desc: "impersonate service account",
+			config: Config{
+				Bucket:                    "tfmigrate-test",
+				Name:                      "tfmigrate/history.json",
+				AccessToken:               "token",
+				ImpersonateServiceAccount: "tfmigrate@example.iam.gserviceaccount.com",
+				StorageCustomEndpoint:     "http://localhost:4443/storage/v1/",
+			},
+			wantImpersonate: "tfmigrate@example.iam.gserviceaccount.com",
+			ok:              true,
+		},
+		{
+			desc: "encryption key",
+			config: Config{
+				Bucket:        "tfmigrate-test",
+				Name:          "tfmigrate/history.json",
+				AccessToken:   "token",
+				EncryptionKey: key,
+			},
+			wantEncryptionKey: []byte("0123456789abcdef0123456789abcdef"),
+			ok:                true,
+		},
+		{
+			desc: "kms encryption key from env",
+			env: map[string]string{
+				"GOOGLE_KMS_ENCRYPTION_KEY": "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz",
+			},
+			config: Config{
+				Bucket:      "tfmigrate-test",
+				Name:        "tfmigrate/history.json",
+				AccessToken: "token",
+			},
+			wantKMSKeyName: "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz",
+			ok:             true,
+		},
+		{
+			desc: "invalid encryption key",
+			config: Config{
+				Bucket:        "tfmigrate-test",
+				Name:          "tfmigrate/history.json",
+				AccessToken:   "token",
+				EncryptionKey: base64.StdEncoding.EncodeToString([]byte("foo")),
+			},
+			ok: false,
+		},
+		{
+			desc: "both encryption keys",
+			config: Config{
+				Bucket:           "tfmigrate-test",
+				Name:             "tfmigrate/history.json",
+				AccessToken:      "token",
+				EncryptionKey:    key,
+				KMSEncryptionKey: "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz",
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, k := range []string{
+				"GOOGLE_BACKEND_CREDENTIALS",
+				"GOOGLE_CREDENTIALS",
+				"GOOGLE_OAUTH_ACCESS_TOKEN",
+				"GOOGLE_BACKEND_IMPERSONATE_SERVICE_ACCOUNT",
+				"GOOGLE_IMPERSONATE_SERVICE_ACCOUNT",
+				"GOOGLE_BACKEND_STORAGE_CUSTOM_ENDPOINT",
+				"GOOGLE_STORAGE_CUSTOM_ENDPOINT",
+				"GOOGLE_ENCRYPTION_KEY",
+				"GOOGLE_KMS_ENCRYPTION_KEY",
+			} {
+				t.Setenv(k, "")
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			var gotImpersonate string
+			orig := impersonateTokenSource
+			impersonateTokenSource = func(_ context.Context, target string, _ []string, _ []option.ClientOption) (oauth2.TokenSource, error) {
+				gotImpersonate = target
+				return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "impersonated"}), nil
+			}
+			t.Cleanup(func() { impersonateTokenSource = orig })
+
+			got, err := NewClient(context.Background(), tc.config)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				a := got.(*Adapter)
+				if !reflect.DeepEqual(a.encryptionKey, tc.wantEncryptionKey) {
+					t.Errorf("got: %v, want: %v", a.encryptionKey, tc.wantEncryptionKey)
+				}
+				if a.kmsKeyName != tc.wantKMSKeyName {
+					t.Errorf("got: %s, want: %s", a.kmsKeyName, tc.wantKMSKeyName)
+				}
+				if gotImpersonate != tc.wantImpersonate {
+					t.Errorf("got: %s, want: %s", gotImpersonate, tc.wantImpersonate)
+				}
+			}
+		})
+	}
+}
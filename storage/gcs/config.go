@@ -4,7 +4,7 @@ import "github.com/minamijoyo/tfmigrate/storage"
 
 // Config is a config for Google Cloud Storage.
 // This is expected to have almost the same options as Terraform gcs backend.
-// https://www.terraform.io/language/settings/backends/gcs
+// https://developer.hashicorp.com/terraform/language/settings/backends/gcs
 // However, it has many minor options and it's a pain to test all options from
 // first, so we added only options we need for now.
 type Config struct {
@@ -12,6 +12,34 @@ type Config struct {
 	Bucket string `hcl:"bucket"`
 	// Path to the migration history file.
 	Name string `hcl:"name"`
+
+	// Path to or content of a service account key file in JSON format.
+	// It can also be set via GOOGLE_BACKEND_CREDENTIALS or GOOGLE_CREDENTIALS
+	// environment variable.
+	// If neither credentials nor access_token is set, the Application Default
+	// Credentials (ADC) are used.
+	Credentials string `hcl:"credentials,optional"`
+	// A temporary OAuth 2.0 access token.
+	// It can also be set via GOOGLE_OAUTH_ACCESS_TOKEN environment variable.
+	AccessToken string `hcl:"access_token,optional"`
+	// The service account to impersonate.
+	// It can also be set via GOOGLE_BACKEND_IMPERSONATE_SERVICE_ACCOUNT or
+	// GOOGLE_IMPERSONATE_SERVICE_ACCOUNT environment variable.
+	ImpersonateServiceAccount string `hcl:"impersonate_service_account,optional"`
+	// The delegation chain for impersonating the service account.
+	ImpersonateServiceAccountDelegates []string `hcl:"impersonate_service_account_delegates,optional"`
+	// A custom endpoint for the GCS API.
+	// It can also be set via GOOGLE_BACKEND_STORAGE_CUSTOM_ENDPOINT or
+	// GOOGLE_STORAGE_CUSTOM_ENDPOINT environment variable.
+	StorageCustomEndpoint string `hcl:"storage_custom_endpoint,optional"`
+	// A base64-encoded AES-256 customer-supplied encryption key (CSEK).
+	// It can also be set via GOOGLE_ENCRYPTION_KEY environment variable.
+	EncryptionKey string `hcl:"encryption_key,optional"`
+	// A Cloud KMS key to encrypt the history file (customer-managed encryption
+	// key, CMEK) in the form of
+	// projects/{project}/locations/{location}/keyRings/{keyRing}/cryptoKeys/{key}.
+	// It can also be set via GOOGLE_KMS_ENCRYPTION_KEY environment variable.
+	KMSEncryptionKey string `hcl:"kms_encryption_key,optional"`
 }
 
 // Config implements a storage.Config.
@@ -0,0 +1,50 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{
+		Bucket: "tfmigrate-test",
+		Name:   "tfmigrate/history.json",
+	}
+	client := &mockClient{}
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if client.lock != nil {
+		t.Fatalf("expected to delete a lock object, but got: %s", string(client.lock))
+	}
+}
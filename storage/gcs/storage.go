@@ -2,6 +2,8 @@ package gcs
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	gcStorage "cloud.google.com/go/storage"
 	"github.com/minamijoyo/tfmigrate/storage"
@@ -17,7 +19,7 @@ type Storage struct {
 	client Client
 }
 
-var _ storage.Storage = (*Storage)(nil)
+var _ storage.VersionedStorage = (*Storage)(nil)
 
 // NewStorage returns a new instance of Storage.
 func NewStorage(config *Config, client Client) (*Storage, error) {
@@ -52,16 +54,106 @@ func (s *Storage) Read(ctx context.Context) ([]byte, error) {
 	return r, nil
 }
 
+// ReadWithVersion reads an object with its generation as a version token.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	err := s.init(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r, generation, err := s.client.ReadWithGeneration(ctx)
+	if err == gcStorage.ErrObjectNotExist {
+		return []byte{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return r, strconv.FormatInt(generation, 10), nil
+}
+
+// WriteWithVersion writes an object only if its generation matches a given
+// version token.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	err := s.init(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var generation int64
+	if version != "" {
+		generation, err = strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version for gcs: %s", version)
+		}
+	}
+
+	newGeneration, err := s.client.WriteWithGeneration(ctx, b, generation)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(newGeneration, 10), nil
+}
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock object next to the history object.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	err := s.init(ctx)
+	if err != nil {
+		return err
+	}
+
+	return storage.AcquireLock(ctx, &lockObject{client: s.client}, info)
+}
+
+// Unlock releases a lock by deleting the lock object.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	err := s.init(ctx)
+	if err != nil {
+		return err
+	}
+
+	return storage.ReleaseLock(ctx, &lockObject{client: s.client}, id)
+}
+
+// lockObject is a storage.LockObject implementation for GCS.
+type lockObject struct {
+	// client is an instance of Client interface to call API.
+	client Client
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	return l.client.CreateLock(ctx, b)
+}
+
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	b, generation, err := l.client.ReadLock(ctx)
+	if err != nil || b == nil {
+		return nil, "", err
+	}
+	return b, strconv.FormatInt(generation, 10), nil
+}
+
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	generation, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid version of lock for gcs: %s", version)
+	}
+	return l.client.ReplaceLock(ctx, b, generation)
+}
+
+func (l *lockObject) Delete(ctx context.Context) error {
+	return l.client.DeleteLock(ctx)
+}
+
 func (s *Storage) init(ctx context.Context) error {
 	if s.client == nil {
-		client, err := gcStorage.NewClient(ctx)
+		client, err := NewClient(ctx, *s.config)
 		if err != nil {
 			return err
 		}
-		s.client = Adapter{
-			config: *s.config,
-			client: client,
-		}
+		s.client = client
 	}
 	return nil
 }
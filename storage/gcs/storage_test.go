@@ -2,15 +2,20 @@ package gcs
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	gcStorage "cloud.google.com/go/storage"
+	"github.com/minamijoyo/tfmigrate/storage"
 )
 
 // mockClient is a mock implementation for testing.
 type mockClient struct {
 	dataToRead []byte
 	err        error
+	lock       []byte
+	generation int64
+	lockGen    int64
 }
 
 func (c *mockClient) Read(_ context.Context) ([]byte, error) {
@@ -21,6 +26,58 @@ func (c *mockClient) Write(_ context.Context, _ []byte) error {
 	return c.err
 }
 
+func (c *mockClient) ReadWithGeneration(_ context.Context) ([]byte, int64, error) {
+	return c.dataToRead, c.generation, c.err
+}
+
+func (c *mockClient) WriteWithGeneration(_ context.Context, p []byte, generation int64) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if generation != c.generation {
+		return 0, storage.ErrVersionConflict
+	}
+	c.dataToRead = p
+	c.generation++
+	return c.generation, nil
+}
+
+func (c *mockClient) CreateLock(_ context.Context, p []byte) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.lock != nil {
+		return false, nil
+	}
+	c.lock = p
+	c.lockGen++
+	return true, nil
+}
+
+func (c *mockClient) ReadLock(_ context.Context) ([]byte, int64, error) {
+	return c.lock, c.lockGen, c.err
+}
+
+func (c *mockClient) ReplaceLock(_ context.Context, p []byte, generation int64) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.lock == nil || generation != c.lockGen {
+		return false, nil
+	}
+	c.lock = p
+	c.lockGen++
+	return true, nil
+}
+
+func (c *mockClient) DeleteLock(_ context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.lock = nil
+	return nil
+}
+
 func TestStorageWrite(t *testing.T) {
 	cases := []struct {
 		desc     string
@@ -142,3 +199,85 @@ func TestStorageRead(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	cases := []struct {
+		desc    string
+		client  *mockClient
+		version string
+		want    string
+		ok      bool
+		err     error
+	}{
+		{
+			desc:    "create new object",
+			client:  &mockClient{},
+			version: "",
+			want:    "1",
+			ok:      true,
+		},
+		{
+			desc: "update with matched generation",
+			client: &mockClient{
+				dataToRead: []byte("foo"),
+				generation: 3,
+			},
+			version: "3",
+			want:    "4",
+			ok:      true,
+		},
+		{
+			desc: "conflict",
+			client: &mockClient{
+				dataToRead: []byte("foo"),
+				generation: 4,
+			},
+			version: "3",
+			ok:      false,
+			err:     storage.ErrVersionConflict,
+		},
+		{
+			desc:    "invalid version",
+			client:  &mockClient{},
+			version: "foo",
+			ok:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{
+				Bucket: "tfmigrate-test",
+				Name:   "tfmigrate/history.json",
+			}
+			s, err := NewStorage(config, tc.client)
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			got, err := s.WriteWithVersion(context.Background(), []byte("bar"), tc.version)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok {
+				if err == nil {
+					t.Fatal("expected to return an error, but no error")
+				}
+				if tc.err != nil && !errors.Is(err, tc.err) {
+					t.Fatalf("got err: %v, want: %v", err, tc.err)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Errorf("got version: %s, want: %s", got, tc.want)
+			}
+
+			b, version, err := s.ReadWithVersion(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if string(b) != "bar" || version != tc.want {
+				t.Errorf("got: %s (%s), want: bar (%s)", string(b), version, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultTimeout is a timeout of HTTP requests.
+const defaultTimeout = 30 * time.Second
+
+// newClient returns a new HTTP client configured with TLS settings.
+func newClient(config *Config) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		// nolint gosec
+		// G402: TLS InsecureSkipVerify may be true.
+		// We ignore it because it's explicitly set by user.
+		InsecureSkipVerify: config.SkipCertVerification,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if config.ClientCACertificatePEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.ClientCACertificatePEM)) {
+			return nil, fmt.Errorf("failed to parse client_ca_certificate_pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertificatePEM != "" || config.ClientPrivateKeyPEM != "" {
+		if config.ClientCertificatePEM == "" || config.ClientPrivateKeyPEM == "" {
+			return nil, fmt.Errorf("client_certificate_pem and client_private_key_pem must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(config.ClientCertificatePEM), []byte(config.ClientPrivateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   defaultTimeout,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package http
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+// Config is a config for http storage.
+// This is expected to have almost the same options as Terraform http backend.
+// https://developer.hashicorp.com/terraform/language/settings/backends/http
+// However, it has many minor options and it's a pain to test all options from
+// first, so we added only options we need for now.
+type Config struct {
+	// The address of the REST endpoint.
+	Address string `hcl:"address"`
+	// HTTP method to use when updating history. Default to POST.
+	UpdateMethod string `hcl:"update_method,optional"`
+	// The address of the lock REST endpoint.
+	// If not set, locking is disabled.
+	LockAddress string `hcl:"lock_address,optional"`
+	// The HTTP method to use when locking. Default to LOCK.
+	LockMethod string `hcl:"lock_method,optional"`
+	// The address of the unlock REST endpoint. Default to lock_address.
+	UnlockAddress string `hcl:"unlock_address,optional"`
+	// The HTTP method to use when unlocking. Default to UNLOCK.
+	UnlockMethod string `hcl:"unlock_method,optional"`
+	// The username for HTTP basic authentication.
+	// It can also be set via TF_HTTP_USERNAME environment variable.
+	Username string `hcl:"username,optional"`
+	// The password for HTTP basic authentication.
+	// It can also be set via TF_HTTP_PASSWORD environment variable.
+	Password string `hcl:"password,optional"`
+	// Custom HTTP headers to send with each request.
+	Headers map[string]string `hcl:"headers,optional"`
+	// Whether to skip TLS verification.
+	SkipCertVerification bool `hcl:"skip_cert_verification,optional"`
+	// A PEM-encoded CA certificate chain used by the client to verify server
+	// certificates during TLS authentication.
+	ClientCACertificatePEM string `hcl:"client_ca_certificate_pem,optional"`
+	// A PEM-encoded certificate used by the server to verify the client
+	// during mutual TLS (mTLS) authentication.
+	ClientCertificatePEM string `hcl:"client_certificate_pem,optional"`
+	// A PEM-encoded private key, required if client_certificate_pem is set.
+	ClientPrivateKeyPEM string `hcl:"client_private_key_pem,optional"`
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// lockInfoJSON is a lock info in the format of Terraform http backend.
+// We use the same format so that servers compatible with Terraform such as
+// GitLab can handle our lock.
+type lockInfoJSON struct {
+	ID        string    `json:"ID"`
+	Operation string    `json:"Operation"`
+	Info      string    `json:"Info"`
+	Who       string    `json:"Who"`
+	Version   string    `json:"Version"`
+	Created   time.Time `json:"Created"`
+	Path      string    `json:"Path"`
+}
+
+var (
+	// heldLocksMu guards heldLocks.
+	heldLocksMu sync.Mutex
+	// heldLocks is a map of lock addresses to IDs of locks held by this
+	// process. Some servers such as GitLab require the lock ID on update,
+	// but a history is written by another storage instance than the one which
+	// acquired the lock. So the lock ID is shared in the process.
+	heldLocks = make(map[string]string)
+)
+
+// heldLockID returns an ID of the lock held by this process for a given lock
+// address. It returns an empty string if not locked.
+func heldLockID(lockAddress string) string {
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+	return heldLocks[lockAddress]
+}
+
+// Lock acquires a lock by sending a LOCK request to the lock address.
+// If the lock address is not set, locking is disabled and no-op.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	if s.config.LockAddress == "" {
+		log.Printf("[WARN] [storage] locking is disabled because lock_address is not set for http storage\n")
+		return nil
+	}
+
+	b, err := json.Marshal(lockInfoJSON{
+		ID:        info.ID,
+		Operation: info.Operation,
+		Info:      "tfmigrate",
+		Who:       info.Who,
+		Created:   info.Created,
+	})
+	if err != nil {
+		return err
+	}
+
+	method := s.config.LockMethod
+	if method == "" {
+		method = "LOCK"
+	}
+
+	resp, err := s.do(ctx, method, s.config.LockAddress, b)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		heldLocksMu.Lock()
+		heldLocks[s.config.LockAddress] = info.ID
+		heldLocksMu.Unlock()
+		return nil
+	case http.StatusLocked, http.StatusConflict:
+		return &storage.LockError{Info: parseLockResponse(resp.Body)}
+	default:
+		return fmt.Errorf("failed to lock: %s %s: %s", method, s.config.LockAddress, resp.Status)
+	}
+}
+
+// Unlock releases a lock by sending an UNLOCK request to the unlock address.
+// Note that the lock ID is verified by the server.
+// If the lock address is not set, locking is disabled and no-op.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	if s.config.LockAddress == "" {
+		log.Printf("[WARN] [storage] locking is disabled because lock_address is not set for http storage\n")
+		return nil
+	}
+
+	b, err := json.Marshal(lockInfoJSON{ID: id})
+	if err != nil {
+		return err
+	}
+
+	method := s.config.UnlockMethod
+	if method == "" {
+		method = "UNLOCK"
+	}
+	address := s.config.UnlockAddress
+	if address == "" {
+		address = s.config.LockAddress
+	}
+
+	resp, err := s.do(ctx, method, address, b)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		heldLocksMu.Lock()
+		if heldLocks[s.config.LockAddress] == id {
+			delete(heldLocks, s.config.LockAddress)
+		}
+		heldLocksMu.Unlock()
+		return nil
+	case http.StatusLocked, http.StatusConflict:
+		return storage.UnlockIDMismatchError(id, parseLockResponse(resp.Body))
+	default:
+		return fmt.Errorf("failed to unlock: %s %s: %s", method, address, resp.Status)
+	}
+}
+
+// parseLockResponse parses a response body of a conflicted lock request and
+// returns a lock info of the current holder.
+// If the body cannot be parsed, it returns a lock info with unknown holder.
+func parseLockResponse(r io.Reader) *storage.LockInfo {
+	var j lockInfoJSON
+	b, err := io.ReadAll(r)
+	if err != nil || json.Unmarshal(b, &j) != nil {
+		return &storage.LockInfo{Who: "unknown"}
+	}
+
+	return &storage.LockInfo{
+		ID:        j.ID,
+		Who:       j.Who,
+		Operation: j.Operation,
+		Created:   j.Created,
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	server := &testServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config := &Config{
+		Address:     ts.URL + "/history",
+		LockAddress: ts.URL + "/lock",
+	}
+	s, err := NewStorage(config, ts.Client())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	ctx := context.Background()
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to NewLockInfo: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	// A write from another storage instance with the same config carries
+	// the lock ID.
+	s2, err := NewStorage(config, ts.Client())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	if err := s2.Write(ctx, []byte("foo")); err != nil {
+		t.Fatalf("failed to write with lock: %s", err)
+	}
+	if got := server.lastRequest().URL.Query().Get("ID"); got != info1.ID {
+		t.Errorf("got: %s, want: %s", got, info1.ID)
+	}
+
+	// A second lock fails with the current holder.
+	another, err := NewStorage(config, ts.Client())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	info2, err := storage.NewLockInfo("rollback", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to NewLockInfo: %s", err)
+	}
+	err = another.Lock(ctx, info2)
+	var lockErr *storage.LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+	if lockErr.Info.ID != info1.ID {
+		t.Errorf("got: %s, want: %s", lockErr.Info.ID, info1.ID)
+	}
+	// Unlock with a wrong ID fails.
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to return an error, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if got := heldLockID(config.LockAddress); got != "" {
+		t.Errorf("expected lock ID to be cleared, but got: %s", got)
+	}
+	// A write after unlock doesn't carry the lock ID.
+	if err := s2.Write(ctx, []byte("bar")); err != nil {
+		t.Fatalf("failed to write without lock: %s", err)
+	}
+	if got := server.lastRequest().URL.Query().Get("ID"); got != "" {
+		t.Errorf("expected no lock ID, but got: %s", got)
+	}
+
+	if err := another.Lock(ctx, info2); err != nil {
+		t.Fatalf("failed to lock after unlock: %s", err)
+	}
+	if err := another.Unlock(ctx, info2.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
+
+func TestStorageLockDisabled(t *testing.T) {
+	server := &testServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config := &Config{
+		Address: ts.URL + "/history",
+	}
+	s, err := NewStorage(config, ts.Client())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	info, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to NewLockInfo: %s", err)
+	}
+	if err := s.Lock(context.Background(), info); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := s.Unlock(context.Background(), info.ID); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(server.requests) != 0 {
+		t.Errorf("expected no requests, but got: %d", len(server.requests))
+	}
+}
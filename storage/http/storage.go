@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Storage is a storage.Storage implementation for http.
+// It is compatible with the protocol of Terraform http backend, which reads
+// with GET and writes with POST to an address.
+// Note that the protocol has no conditional write, so that it doesn't
+// implement storage.VersionedStorage. Concurrent updates are only prevented
+// by locking.
+type Storage struct {
+	// config is a storage config for http.
+	config *Config
+	// client is an HTTP client.
+	// It is intended to be replaced with a client for a test server.
+	client *http.Client
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+func NewStorage(config *Config, client *http.Client) (*Storage, error) {
+	if _, err := url.Parse(config.Address); err != nil {
+		return nil, fmt.Errorf("failed to parse address: %w", err)
+	}
+
+	if client == nil {
+		var err error
+		client, err = newClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Storage{
+		config: config,
+		client: client,
+	}
+	return s, nil
+}
+
+// Write writes migration history data to storage.
+// If a lock is held, its ID is sent as a query parameter as Terraform does.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	address := s.config.Address
+	if lockID := heldLockID(s.config.LockAddress); lockID != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		q.Set("ID", lockID)
+		u.RawQuery = q.Encode()
+		address = u.String()
+	}
+
+	method := s.config.UpdateMethod
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	resp, err := s.do(ctx, method, address, b)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("failed to write history: %s %s: %s", method, s.config.Address, resp.Status)
+	}
+}
+
+// Read reads migration history data from storage.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.config.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNoContent, http.StatusNotFound:
+		// If the key does not exist
+		return []byte{}, nil
+	default:
+		return nil, fmt.Errorf("failed to read history: GET %s: %s", s.config.Address, resp.Status)
+	}
+}
+
+// do sends an HTTP request with authentication and custom headers.
+func (s *Storage) do(ctx context.Context, method string, address string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, address, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	username := withEnv(s.config.Username, "TF_HTTP_USERNAME")
+	password := withEnv(s.config.Password, "TF_HTTP_PASSWORD")
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	return s.client.Do(req)
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of a
+// given environment variable.
+func withEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
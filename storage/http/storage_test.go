@@ -0,0 +1,273 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testServer is a minimal server compatible with Terraform http backend.
+type testServer struct {
+	mu     sync.Mutex
+	data   []byte
+	lock   []byte
+	lockID string
+	// requests records methods, queries and headers of received requests.
+	requests []*http.Request
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/history" && r.Method == http.MethodGet:
+		if s.data == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(s.data)
+	case r.URL.Path == "/history" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		if s.lockID != "" && r.URL.Query().Get("ID") != s.lockID {
+			w.WriteHeader(http.StatusLocked)
+			_, _ = w.Write(s.lock)
+			return
+		}
+		s.data = body
+	case r.URL.Path == "/lock" && r.Method == "LOCK":
+		if s.lockID != "" {
+			w.WriteHeader(http.StatusLocked)
+			_, _ = w.Write(s.lock)
+			return
+		}
+		var info lockInfoJSON
+		if err := json.Unmarshal(body, &info); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.lock = body
+		s.lockID = info.ID
+	case r.URL.Path == "/lock" && r.Method == "UNLOCK":
+		var info lockInfoJSON
+		if err := json.Unmarshal(body, &info); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if s.lockID != info.ID {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write(s.lock)
+			return
+		}
+		s.lock = nil
+		s.lockID = ""
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// lastRequest returns the last received request.
+func (s *testServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func TestStorageWriteRead(t *testing.T) {
+	cases := []struct {
+		desc     string
+		config   *Config
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc:     "simple",
+			config:   &Config{},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "update method",
+			config: &Config{
+				UpdateMethod: http.MethodPut,
+			},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "unsupported update method",
+			config: &Config{
+				UpdateMethod: http.MethodPatch,
+			},
+			contents: []byte("foo"),
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := httptest.NewServer(&testServer{})
+			defer ts.Close()
+
+			tc.config.Address = ts.URL + "/history"
+			s, err := NewStorage(tc.config, ts.Client())
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+
+			// an uninitialized history is empty.
+			got, err := s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if len(got) != 0 {
+				t.Errorf("got: %s, want: empty", string(got))
+			}
+
+			err = s.Write(context.Background(), tc.contents)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok {
+				if err == nil {
+					t.Fatal("expected to return an error, but no error")
+				}
+				return
+			}
+
+			got, err = s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if string(got) != string(tc.contents) {
+				t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+			}
+		})
+	}
+}
+
+func TestStorageAuthAndHeaders(t *testing.T) {
+	server := &testServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	config := &Config{
+		Address:  ts.URL + "/history",
+		Username: "foo",
+		Password: "bar",
+		Headers: map[string]string{
+			"X-Foo": "baz",
+		},
+	}
+	s, err := NewStorage(config, ts.Client())
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	if _, err := s.Read(context.Background()); err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+
+	req := server.lastRequest()
+	username, password, ok := req.BasicAuth()
+	if !ok || username != "foo" || password != "bar" {
+		t.Errorf("unexpected basic auth: username = %s, password = %s, ok = %t", username, password, ok)
+	}
+	if got := req.Header.Get("X-Foo"); got != "baz" {
+		t.Errorf("got: %s, want: %s", got, "baz")
+	}
+}
+
+func TestNewStorageTLS(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "skip cert verification",
+			config: &Config{
+				Address:              "https://example.com/history",
+				SkipCertVerification: true,
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid ca certificate",
+			config: &Config{
+				Address:                "https://example.com/history",
+				ClientCACertificatePEM: "foo",
+			},
+			ok: false,
+		},
+		{
+			desc: "client certificate without private key",
+			config: &Config{
+				Address:              "https://example.com/history",
+				ClientCertificatePEM: "foo",
+			},
+			ok: false,
+		},
+		{
+			desc: "invalid client certificate",
+			config: &Config{
+				Address:              "https://example.com/history",
+				ClientCertificatePEM: "foo",
+				ClientPrivateKeyPEM:  "bar",
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := NewStorage(tc.config, nil)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+		})
+	}
+}
+
+func TestStorageTLSServer(t *testing.T) {
+	ts := httptest.NewTLSServer(&testServer{data: []byte("foo")})
+	defer ts.Close()
+
+	// Without trusting the test server certificate, the request fails.
+	config := &Config{
+		Address: ts.URL + "/history",
+	}
+	s, err := NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	if _, err := s.Read(context.Background()); err == nil {
+		t.Fatal("expected to return an error, but no error")
+	}
+
+	config.SkipCertVerification = true
+	s, err = NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	got, err := s.Read(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+	if string(got) != "foo" {
+		t.Errorf("got: %s, want: %s", string(got), "foo")
+	}
+}
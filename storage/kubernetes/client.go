@@ -0,0 +1,243 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// errObjectNotFound is an error which indicates the object doesn't exist.
+var errObjectNotFound = errors.New("object not found")
+
+// managedByLabels are labels set to objects created by tfmigrate.
+var managedByLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "tfmigrate",
+}
+
+// Client is an abstraction of a Secret or ConfigMap API.
+// It reads and writes a value of a single key in the data of an object, and
+// uses a resourceVersion of the object as a version token.
+type Client interface {
+	// Read returns a value of the key and a resourceVersion of the object.
+	// If the object doesn't exist, returns errObjectNotFound.
+	// If the object exists but the key doesn't, returns nil value.
+	Read(ctx context.Context, name string, key string) ([]byte, string, error)
+	// Create creates an object with a given value of the key.
+	// If the object already exists, returns storage.ErrVersionConflict.
+	Create(ctx context.Context, name string, key string, value []byte) (string, error)
+	// Update sets a value of the key in an existing object. Other keys are kept.
+	// If the object doesn't exist, returns errObjectNotFound.
+	// If the version is not empty, it is updated only if the resourceVersion
+	// of the object matches, otherwise returns storage.ErrVersionConflict.
+	Update(ctx context.Context, name string, key string, value []byte, version string) (string, error)
+	// Delete deletes an object.
+	Delete(ctx context.Context, name string) error
+}
+
+// newClientset returns a new Kubernetes clientset.
+func newClientset(config *Config) (kubernetes.Interface, error) {
+	var restConfig *rest.Config
+	var err error
+	if config.InClusterConfig {
+		restConfig, err = rest.InClusterConfig()
+	} else {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		rules.ExplicitPath = withEnv(config.ConfigPath, "KUBE_CONFIG_PATH")
+		overrides := &clientcmd.ConfigOverrides{
+			CurrentContext: withEnv(config.ConfigContext, "KUBE_CTX"),
+		}
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// newClient returns a new Client for a given kind of object.
+func newClient(config *Config, clientset kubernetes.Interface) (Client, error) {
+	switch config.kind() {
+	case KindSecret:
+		return &secretClient{api: clientset.CoreV1().Secrets(config.namespace())}, nil
+	case KindConfigMap:
+		return &configMapClient{api: clientset.CoreV1().ConfigMaps(config.namespace())}, nil
+	default:
+		return nil, fmt.Errorf("unknown kind: %s, valid values are %s or %s", config.Kind, KindSecret, KindConfigMap)
+	}
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of a
+// given environment variable.
+func withEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// convertError converts an API error for a conditional write to
+// storage.ErrVersionConflict.
+func convertError(err error) error {
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		return storage.ErrVersionConflict
+	}
+	return err
+}
+
+// secretClient is a Client implementation for Secret.
+type secretClient struct {
+	api interface {
+		Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error)
+		Create(ctx context.Context, secret *corev1.Secret, opts metav1.CreateOptions) (*corev1.Secret, error)
+		Update(ctx context.Context, secret *corev1.Secret, opts metav1.UpdateOptions) (*corev1.Secret, error)
+		Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	}
+}
+
+var _ Client = (*secretClient)(nil)
+
+// Read returns a value of the key and a resourceVersion of the Secret.
+func (c *secretClient) Read(ctx context.Context, name string, key string) ([]byte, string, error) {
+	obj, err := c.api.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, "", errObjectNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+	return obj.Data[key], obj.ResourceVersion, nil
+}
+
+// Create creates a Secret with a given value of the key.
+func (c *secretClient) Create(ctx context.Context, name string, key string, value []byte) (string, error) {
+	obj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: managedByLabels,
+		},
+		Data: map[string][]byte{key: value},
+	}
+	created, err := c.api.Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return "", convertError(err)
+	}
+	return created.ResourceVersion, nil
+}
+
+// Update sets a value of the key in an existing Secret.
+func (c *secretClient) Update(ctx context.Context, name string, key string, value []byte, version string) (string, error) {
+	obj, err := c.api.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", errObjectNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if version != "" {
+		if obj.ResourceVersion != version {
+			return "", storage.ErrVersionConflict
+		}
+		// The API server also rejects the update if the object has been
+		// modified after the above check.
+		obj.ResourceVersion = version
+	}
+	if obj.Data == nil {
+		obj.Data = map[string][]byte{}
+	}
+	obj.Data[key] = value
+
+	updated, err := c.api.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return "", convertError(err)
+	}
+	return updated.ResourceVersion, nil
+}
+
+// Delete deletes a Secret.
+func (c *secretClient) Delete(ctx context.Context, name string) error {
+	return c.api.Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// configMapClient is a Client implementation for ConfigMap.
+type configMapClient struct {
+	api interface {
+		Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
+		Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error)
+		Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error)
+		Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	}
+}
+
+var _ Client = (*configMapClient)(nil)
+
+// Read returns a value of the key and a resourceVersion of the ConfigMap.
+func (c *configMapClient) Read(ctx context.Context, name string, key string) ([]byte, string, error) {
+	obj, err := c.api.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, "", errObjectNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+	v, ok := obj.Data[key]
+	if !ok {
+		return nil, obj.ResourceVersion, nil
+	}
+	return []byte(v), obj.ResourceVersion, nil
+}
+
+// Create creates a ConfigMap with a given value of the key.
+func (c *configMapClient) Create(ctx context.Context, name string, key string, value []byte) (string, error) {
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: managedByLabels,
+		},
+		Data: map[string]string{key: string(value)},
+	}
+	created, err := c.api.Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return "", convertError(err)
+	}
+	return created.ResourceVersion, nil
+}
+
+// Update sets a value of the key in an existing ConfigMap.
+func (c *configMapClient) Update(ctx context.Context, name string, key string, value []byte, version string) (string, error) {
+	obj, err := c.api.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", errObjectNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if version != "" {
+		if obj.ResourceVersion != version {
+			return "", storage.ErrVersionConflict
+		}
+		// The API server also rejects the update if the object has been
+		// modified after the above check.
+		obj.ResourceVersion = version
+	}
+	if obj.Data == nil {
+		obj.Data = map[string]string{}
+	}
+	obj.Data[key] = string(value)
+
+	updated, err := c.api.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return "", convertError(err)
+	}
+	return updated.ResourceVersion, nil
+}
+
+// Delete deletes a ConfigMap.
+func (c *configMapClient) Delete(ctx context.Context, name string) error {
+	return c.api.Delete(ctx, name, metav1.DeleteOptions{})
+}
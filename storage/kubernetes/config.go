@@ -0,0 +1,69 @@
+package kubernetes
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+const (
+	// KindSecret is a kind of object to store history in a Secret.
+	KindSecret = "Secret"
+	// KindConfigMap is a kind of object to store history in a ConfigMap.
+	KindConfigMap = "ConfigMap"
+)
+
+// Config is a config for Kubernetes.
+// This is expected to have almost the same options as Terraform kubernetes backend.
+// https://developer.hashicorp.com/terraform/language/settings/backends/kubernetes
+// However, it has many minor options and it's a pain to test all options from
+// first, so we added only options we need for now.
+type Config struct {
+	// Kind of the object to store the migration history.
+	// Valid values are Secret or ConfigMap. Default to Secret.
+	Kind string `hcl:"kind,optional"`
+	// Namespace of the object. Default to default.
+	Namespace string `hcl:"namespace,optional"`
+	// Name of the object.
+	Name string `hcl:"name"`
+	// Key in the data of the object. Default to history.json.
+	Key string `hcl:"key,optional"`
+
+	// Path to the kube config file.
+	// It can also be set via KUBE_CONFIG_PATH environment variable.
+	// If not set, the default loading rules of kubectl are used.
+	ConfigPath string `hcl:"config_path,optional"`
+	// Context to choose from the kube config file.
+	// It can also be set via KUBE_CTX environment variable.
+	ConfigContext string `hcl:"config_context,optional"`
+	// Whether to use the service account of the pod running in the cluster.
+	InClusterConfig bool `hcl:"in_cluster_config,optional"`
+}
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
+
+// kind returns a kind of the object with a default value.
+func (c *Config) kind() string {
+	if c.Kind == "" {
+		return KindSecret
+	}
+	return c.Kind
+}
+
+// namespace returns a namespace of the object with a default value.
+func (c *Config) namespace() string {
+	if c.Namespace == "" {
+		return "default"
+	}
+	return c.Namespace
+}
+
+// key returns a key in the data of the object with a default value.
+func (c *Config) key() string {
+	if c.Key == "" {
+		return "history.json"
+	}
+	return c.Key
+}
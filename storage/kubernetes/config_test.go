@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeConfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: dummy
+`
+
+func TestConfigNewStorage(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeConfig), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %s", err)
+	}
+
+	cases := []struct {
+		desc   string
+		env    map[string]string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "config_path",
+			config: &Config{
+				Name:       "tfmigrate",
+				ConfigPath: kubeconfig,
+			},
+			ok: true,
+		},
+		{
+			desc: "config_path from env",
+			env: map[string]string{
+				"KUBE_CONFIG_PATH": kubeconfig,
+			},
+			config: &Config{
+				Name: "tfmigrate",
+			},
+			ok: true,
+		},
+		{
+			desc: "unknown context",
+			config: &Config{
+				Name:          "tfmigrate",
+				ConfigPath:    kubeconfig,
+				ConfigContext: "foo",
+			},
+			ok: false,
+		},
+		{
+			desc: "in cluster config outside of cluster",
+			config: &Config{
+				Name:            "tfmigrate",
+				InClusterConfig: true,
+			},
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("KUBE_CONFIG_PATH", "")
+			t.Setenv("KUBE_CTX", "")
+			t.Setenv("KUBERNETES_SERVICE_HOST", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				_ = got.(*Storage)
+			}
+		})
+	}
+}
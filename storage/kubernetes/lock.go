@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock object next to the history object.
+// The lock object has the same kind as the history object.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, s.lockObject(), info)
+}
+
+// Unlock releases a lock by deleting the lock object.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, s.lockObject(), id)
+}
+
+// lockObject returns a storage.LockObject for the history object.
+func (s *Storage) lockObject() *lockObject {
+	return &lockObject{
+		client: s.client,
+		name:   s.config.Name + storage.LockSuffix,
+		key:    s.config.key(),
+	}
+}
+
+// lockObject is a storage.LockObject implementation for Kubernetes.
+type lockObject struct {
+	// client is an instance of Client interface to call API.
+	client Client
+	// name is a name of the lock object.
+	name string
+	// key is a key in the data of the lock object.
+	key string
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create creates a lock object only if it doesn't exist.
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	_, err := l.client.Create(ctx, l.name, l.key, b)
+	if errors.Is(err, storage.ErrVersionConflict) {
+		// The lock object already exists
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Read reads a content of the lock object with its resourceVersion as a
+// version token.
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	b, version, err := l.client.Read(ctx, l.name, l.key)
+	if errors.Is(err, errObjectNotFound) {
+		return nil, "", nil
+	}
+	return b, version, err
+}
+
+// Replace updates the lock object only if its resourceVersion matches a given
+// version.
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	_, err := l.client.Update(ctx, l.name, l.key, b, version)
+	if errors.Is(err, storage.ErrVersionConflict) || errors.Is(err, errObjectNotFound) {
+		// The lock object has been replaced or deleted
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the lock object.
+func (l *lockObject) Delete(ctx context.Context) error {
+	err := l.client.Delete(ctx, l.name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	for _, kind := range []string{KindSecret, KindConfigMap} {
+		t.Run(kind, func(t *testing.T) {
+			config := &Config{
+				Kind: kind,
+				Name: "tfmigrate",
+			}
+			s, err := NewStorage(config, newFakeClientset())
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			ctx := context.Background()
+
+			info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+			if err != nil {
+				t.Fatalf("failed to NewLockInfo: %s", err)
+			}
+			if err := s.Lock(ctx, info1); err != nil {
+				t.Fatalf("failed to lock: %s", err)
+			}
+
+			info2, err := storage.NewLockInfo("rollback", storage.DefaultLockTTL)
+			if err != nil {
+				t.Fatalf("failed to NewLockInfo: %s", err)
+			}
+			err = s.Lock(ctx, info2)
+			var lockErr *storage.LockError
+			if !errors.As(err, &lockErr) {
+				t.Fatalf("expected to return a LockError, but got: %v", err)
+			}
+			if lockErr.Info.ID != info1.ID {
+				t.Errorf("got: %s, want: %s", lockErr.Info.ID, info1.ID)
+			}
+
+			if err := s.Unlock(ctx, info2.ID); err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+			if err := s.Unlock(ctx, info1.ID); err != nil {
+				t.Fatalf("failed to unlock: %s", err)
+			}
+			if err := s.Unlock(ctx, info1.ID); !errors.Is(err, storage.ErrNotLocked) {
+				t.Fatalf("expected to return ErrNotLocked, but got: %v", err)
+			}
+
+			if err := s.Lock(ctx, info2); err != nil {
+				t.Fatalf("failed to lock after unlock: %s", err)
+			}
+		})
+	}
+}
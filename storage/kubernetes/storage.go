@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Storage is a storage.Storage implementation for Kubernetes.
+// It stores a history file in a key of a Secret or ConfigMap.
+type Storage struct {
+	// config is a storage config for kubernetes.
+	config *Config
+	// client is an instance of Client interface to call API.
+	client Client
+}
+
+var _ storage.VersionedStorage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+// The clientset is intended to be replaced with a fake for testing.
+func NewStorage(config *Config, clientset kubernetes.Interface) (*Storage, error) {
+	if clientset == nil {
+		var err error
+		clientset, err = newClientset(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := newClient(config, clientset)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Storage{
+		config: config,
+		client: client,
+	}
+
+	return s, nil
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	_, err := s.client.Update(ctx, s.config.Name, s.config.key(), b, "")
+	if errors.Is(err, errObjectNotFound) {
+		_, err = s.client.Create(ctx, s.config.Name, s.config.key(), b)
+	}
+	return err
+}
+
+// Read reads migration history data from storage.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, _, err := s.ReadWithVersion(ctx)
+	return b, err
+}
+
+// ReadWithVersion reads migration history data from storage with a
+// resourceVersion of the object as a version token.
+// If the object does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+// If the object exists but the key doesn't, it returns an empty array with
+// the resourceVersion so that the history can be added to the object.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	b, version, err := s.client.Read(ctx, s.config.Name, s.config.key())
+	if errors.Is(err, errObjectNotFound) {
+		// If the object does not exist
+		return []byte{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	if b == nil {
+		b = []byte{}
+	}
+	return b, version, nil
+}
+
+// WriteWithVersion writes migration history data to storage only if the
+// resourceVersion of the current object matches a given version.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	if version == "" {
+		return s.client.Create(ctx, s.config.Name, s.config.key(), b)
+	}
+
+	newVersion, err := s.client.Update(ctx, s.config.Name, s.config.key(), b, version)
+	if errors.Is(err, errObjectNotFound) {
+		// The object has been deleted since it was read.
+		return "", storage.ErrVersionConflict
+	}
+	return newVersion, err
+}
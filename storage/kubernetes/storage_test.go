@@ -0,0 +1,241 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClientset returns a fake clientset which emulates the optimistic
+// concurrency control of the API server. The object tracker of the fake
+// clientset doesn't maintain resourceVersion by itself.
+func newFakeClientset(objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(objects...)
+	tracker := clientset.Tracker()
+
+	var mu sync.Mutex
+	var counter int
+	next := func() string {
+		counter++
+		return strconv.Itoa(counter)
+	}
+
+	clientset.PrependReactor("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		obj := action.(k8stesting.CreateAction).GetObject().DeepCopyObject()
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		accessor.SetResourceVersion(next())
+		if err := tracker.Create(action.GetResource(), obj, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	})
+
+	clientset.PrependReactor("update", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		obj := action.(k8stesting.UpdateAction).GetObject().DeepCopyObject()
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		current, err := tracker.Get(action.GetResource(), action.GetNamespace(), accessor.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		currentAccessor, err := meta.Accessor(current)
+		if err != nil {
+			return true, nil, err
+		}
+		if accessor.GetResourceVersion() != "" && accessor.GetResourceVersion() != currentAccessor.GetResourceVersion() {
+			return true, nil, apierrors.NewConflict(action.GetResource().GroupResource(), accessor.GetName(), errors.New("the object has been modified"))
+		}
+		accessor.SetResourceVersion(next())
+		if err := tracker.Update(action.GetResource(), obj, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	})
+
+	return clientset
+}
+
+func TestStorageWriteRead(t *testing.T) {
+	cases := []struct {
+		desc     string
+		config   *Config
+		contents []byte
+		ok       bool
+	}{
+		{
+			desc: "secret",
+			config: &Config{
+				Namespace: "foo",
+				Name:      "tfmigrate",
+			},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "configmap",
+			config: &Config{
+				Kind: KindConfigMap,
+				Name: "tfmigrate",
+				Key:  "bar.json",
+			},
+			contents: []byte("foo"),
+			ok:       true,
+		},
+		{
+			desc: "unknown kind",
+			config: &Config{
+				Kind: "Pod",
+				Name: "tfmigrate",
+			},
+			contents: []byte("foo"),
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, err := NewStorage(tc.config, newFakeClientset())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok {
+				if err == nil {
+					t.Fatalf("expected to return an error, but no error, got: %#v", s)
+				}
+				return
+			}
+
+			// an uninitialized history is empty.
+			got, err := s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if len(got) != 0 {
+				t.Errorf("got: %s, want: empty", string(got))
+			}
+
+			// write twice to test both create and update.
+			for i := 0; i < 2; i++ {
+				if err := s.Write(context.Background(), tc.contents); err != nil {
+					t.Fatalf("failed to write: %s", err)
+				}
+			}
+
+			got, err = s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if string(got) != string(tc.contents) {
+				t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+			}
+		})
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	for _, kind := range []string{KindSecret, KindConfigMap} {
+		t.Run(kind, func(t *testing.T) {
+			config := &Config{
+				Kind: kind,
+				Name: "tfmigrate",
+			}
+			s, err := NewStorage(config, newFakeClientset())
+			if err != nil {
+				t.Fatalf("failed to NewStorage: %s", err)
+			}
+			ctx := context.Background()
+
+			v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+			if err != nil {
+				t.Fatalf("failed to write a new object: %s", err)
+			}
+
+			// The object already exists.
+			if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+				t.Fatalf("expected to return ErrVersionConflict, but got: %v", err)
+			}
+
+			v2, err := s.WriteWithVersion(ctx, []byte("bar"), v1)
+			if err != nil {
+				t.Fatalf("failed to write with version: %s", err)
+			}
+
+			// The version is stale.
+			if _, err := s.WriteWithVersion(ctx, []byte("baz"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+				t.Fatalf("expected to return ErrVersionConflict, but got: %v", err)
+			}
+
+			got, version, err := s.ReadWithVersion(ctx)
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if string(got) != "bar" {
+				t.Errorf("got: %s, want: %s", string(got), "bar")
+			}
+			if version != v2 {
+				t.Errorf("got: %s, want: %s", version, v2)
+			}
+		})
+	}
+}
+
+func TestStorageWriteKeepsOtherKeys(t *testing.T) {
+	clientset := newFakeClientset()
+	config := &Config{
+		Name: "tfmigrate",
+	}
+
+	// Create an object with another key in advance.
+	other := &Config{
+		Name: "tfmigrate",
+		Key:  "other",
+	}
+	otherStorage, err := NewStorage(other, clientset)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	if err := otherStorage.Write(context.Background(), []byte("other")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+
+	s, err := NewStorage(config, clientset)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	got, version, err := s.ReadWithVersion(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+	if len(got) != 0 || version == "" {
+		t.Fatalf("expected an empty history with a version, but got: %s, %s", string(got), version)
+	}
+	if _, err := s.WriteWithVersion(context.Background(), []byte("foo"), version); err != nil {
+		t.Fatalf("failed to write with version: %s", err)
+	}
+
+	got, err = otherStorage.Read(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
+	if string(got) != "other" {
+		t.Errorf("got: %s, want: %s", string(got), "other")
+	}
+}
@@ -0,0 +1,109 @@
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock file next to the history file.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, &lockFile{path: s.config.Path + storage.LockSuffix}, info)
+}
+
+// Unlock releases a lock by deleting the lock file.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, &lockFile{path: s.config.Path + storage.LockSuffix}, id)
+}
+
+// lockFile is a storage.LockObject implementation for local file.
+type lockFile struct {
+	// path is a path to the lock file.
+	path string
+}
+
+var _ storage.LockObject = (*lockFile)(nil)
+
+// Create creates a lock file exclusively.
+func (l *lockFile) Create(_ context.Context, b []byte) (bool, error) {
+	// nolint gosec
+	// G302: Expect file permissions to be 0600 or less
+	// We ignore it because a lock file doesn't contains sensitive data.
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// Read reads a content of the lock file with a hash of the content as a
+// version token. Since a lock info contains a random ID, the hash identifies
+// the lock.
+func (l *lockFile) Read(_ context.Context) ([]byte, string, error) {
+	b, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return b, contentVersion(b), nil
+}
+
+// Replace replaces the lock file only if the version matches.
+// To check and delete the current lock file atomically, it moves the lock
+// file to a temporary file first. If it turns out to be someone else's lock,
+// it is restored unless a new lock file has been created in the meantime.
+func (l *lockFile) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	tmp := l.path + "." + version
+	if err := os.Rename(l.path, tmp); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	current, err := os.ReadFile(tmp)
+	if err != nil {
+		return false, err
+	}
+	if contentVersion(current) != version {
+		// Restore the lock file without overwriting a new one.
+		if err := os.Link(tmp, l.path); err != nil && !os.IsExist(err) {
+			return false, err
+		}
+		return false, os.Remove(tmp)
+	}
+
+	if err := os.Remove(tmp); err != nil {
+		return false, err
+	}
+	return l.Create(ctx, b)
+}
+
+// contentVersion returns a version token of the lock file for a given content.
+func contentVersion(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Delete deletes the lock file.
+func (l *lockFile) Delete(_ context.Context) error {
+	err := os.Remove(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
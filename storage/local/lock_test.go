@@ -0,0 +1,109 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	ctx := context.Background()
+	localDir := t.TempDir()
+	config := &Config{
+		Path: filepath.Join(localDir, "history.json"),
+	}
+
+	s1, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	s2, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	l1 := s1.(storage.Locker)
+	l2 := s2.(storage.Locker)
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := l1.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	if _, err := os.Stat(config.Path + storage.LockSuffix); err != nil {
+		t.Fatalf("expected to create a lock file: %s", err)
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := l2.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := l2.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := l2.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+
+	if _, err := os.Stat(config.Path + storage.LockSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected to delete a lock file: %v", err)
+	}
+
+	if err := l2.Lock(ctx, info2); err != nil {
+		t.Fatalf("failed to lock after unlock: %s", err)
+	}
+}
+
+func TestLockFileReplace(t *testing.T) {
+	ctx := context.Background()
+	l := &lockFile{path: filepath.Join(t.TempDir(), "history.json.lock")}
+
+	if ok, err := l.Replace(ctx, []byte("foo"), "stale"); err != nil || ok {
+		t.Fatalf("expected not to replace a lock file which doesn't exist, but got: %t, %v", ok, err)
+	}
+
+	if _, err := l.Create(ctx, []byte("bar")); err != nil {
+		t.Fatalf("failed to create a lock file: %s", err)
+	}
+	_, version, err := l.Read(ctx)
+	if err != nil {
+		t.Fatalf("failed to read a lock file: %s", err)
+	}
+
+	if ok, err := l.Replace(ctx, []byte("foo"), "stale"); err != nil || ok {
+		t.Fatalf("expected not to replace a lock file with a wrong version, but got: %t, %v", ok, err)
+	}
+	b, _, err := l.Read(ctx)
+	if err != nil {
+		t.Fatalf("failed to read a lock file: %s", err)
+	}
+	if string(b) != "bar" {
+		t.Fatalf("expected to restore a lock file, but got: %s", string(b))
+	}
+
+	if ok, err := l.Replace(ctx, []byte("baz"), version); err != nil || !ok {
+		t.Fatalf("failed to replace a lock file: %t, %v", ok, err)
+	}
+	b, _, err = l.Read(ctx)
+	if err != nil {
+		t.Fatalf("failed to read a lock file: %s", err)
+	}
+	if string(b) != "baz" {
+		t.Errorf("got: %s, want: baz", string(b))
+	}
+	if ok, err := l.Replace(ctx, []byte("qux"), version); err != nil || ok {
+		t.Fatalf("expected not to replace a lock file twice with the same version, but got: %t, %v", ok, err)
+	}
+}
@@ -2,6 +2,9 @@ package local
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 
 	"github.com/minamijoyo/tfmigrate/storage"
@@ -16,7 +19,7 @@ type Storage struct {
 	config *Config
 }
 
-var _ storage.Storage = (*Storage)(nil)
+var _ storage.VersionedStorage = (*Storage)(nil)
 
 // NewStorage returns a new instance of Storage.
 func NewStorage(config *Config) (*Storage, error) {
@@ -45,3 +48,50 @@ func (s *Storage) Read(_ context.Context) ([]byte, error) {
 	}
 	return os.ReadFile(s.config.Path)
 }
+
+// ReadWithVersion reads migration history data from storage with a version
+// token, which consists of a modification time and a hash of the file.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+func (s *Storage) ReadWithVersion(_ context.Context) ([]byte, string, error) {
+	fi, err := os.Stat(s.config.Path)
+	if os.IsNotExist(err) {
+		// If the key does not exist
+		return []byte{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	b, err := os.ReadFile(s.config.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, fileVersion(fi, b), nil
+}
+
+// WriteWithVersion writes migration history data to storage only if the
+// current version token matches a given one.
+// Note that checking the version and writing are not atomic for local file.
+// It only detects a conflict with a write after the history was read.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	_, current, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current != version {
+		return "", storage.ErrVersionConflict
+	}
+
+	if err := s.Write(ctx, b); err != nil {
+		return "", err
+	}
+
+	_, newVersion, err := s.ReadWithVersion(ctx)
+	return newVersion, err
+}
+
+// fileVersion returns a version token of a given file.
+func fileVersion(fi os.FileInfo, b []byte) string {
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("%d-%s", fi.ModTime().UnixNano(), hex.EncodeToString(sum[:]))
+}
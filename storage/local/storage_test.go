@@ -2,9 +2,12 @@ package local
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
 )
 
 func TestStorageWrite(t *testing.T) {
@@ -125,3 +128,56 @@ func TestStorageRead(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	ctx := context.Background()
+	localDir := t.TempDir()
+	config := &Config{
+		Path: filepath.Join(localDir, "history.json"),
+	}
+	s, err := NewStorage(config)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	b, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(b) != 0 || version != "" {
+		t.Fatalf("expected an empty version for a file which doesn't exist, got: %s", version)
+	}
+
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("failed to create a file: %s", err)
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for an existing file, but got: %v", err)
+	}
+
+	// simulate a concurrent update by someone else
+	if err := os.WriteFile(config.Path, []byte("baz"), 0600); err != nil {
+		t.Fatalf("failed to write contents: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for a stale version, but got: %v", err)
+	}
+
+	_, v2, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v2); err != nil {
+		t.Fatalf("failed to write with the latest version: %s", err)
+	}
+
+	got, err := os.ReadFile(config.Path)
+	if err != nil {
+		t.Fatalf("failed to read contents: %s", err)
+	}
+	if string(got) != "bar" {
+		t.Errorf("got: %s, want: bar", string(got))
+	}
+}
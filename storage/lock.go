@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"time"
+)
+
+// DefaultLockTTL is a default time-to-live of lock.
+// A lock older than its TTL is considered stale and can be taken over.
+const DefaultLockTTL = 1 * time.Hour
+
+// LockSuffix is a suffix of lock object name.
+// A lock object is stored next to the history key.
+const LockSuffix = ".lock"
+
+// Locker is an optional interface for Storage which supports locking.
+// It is used for preventing concurrent updates of migration history.
+type Locker interface {
+	// Lock acquires a lock with a given lock info.
+	// If the lock is held by someone else and not expired, returns a *LockError.
+	// An expired lock is taken over.
+	Lock(ctx context.Context, info *LockInfo) error
+	// Unlock releases a lock with a given lock ID.
+	// It returns an error if the lock is not held or held with a different ID.
+	Unlock(ctx context.Context, id string) error
+}
+
+// LockInfo represents a holder of lock.
+type LockInfo struct {
+	// ID is a unique identifier of the lock.
+	ID string
+	// Who is an identity of the lock holder such as user@hostname.
+	Who string
+	// Operation is a name of operation which holds the lock.
+	Operation string
+	// Created is a timestamp when the lock was acquired.
+	Created time.Time
+	// TTL is a time-to-live of the lock.
+	TTL time.Duration
+}
+
+// lockInfoJSON is a data structure for persistence of LockInfo.
+type lockInfoJSON struct {
+	ID        string    `json:"id"`
+	Who       string    `json:"who"`
+	Operation string    `json:"operation"`
+	Created   time.Time `json:"created"`
+	TTL       string    `json:"ttl"`
+}
+
+// NewLockInfo returns a new LockInfo instance with a random ID.
+func NewLockInfo(operation string, ttl time.Duration) (*LockInfo, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock ID: %w", err)
+	}
+
+	return &LockInfo{
+		ID:        hex.EncodeToString(b),
+		Who:       lockHolder(),
+		Operation: operation,
+		Created:   time.Now().UTC(),
+		TTL:       ttl,
+	}, nil
+}
+
+// lockHolder returns an identity of the current process such as user@hostname.
+func lockHolder() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return name
+	}
+	return name + "@" + host
+}
+
+// Expired returns true if the lock is older than its TTL at a given time.
+// A lock without TTL never expires.
+func (i *LockInfo) Expired(now time.Time) bool {
+	if i.TTL <= 0 {
+		return false
+	}
+	return now.After(i.Created.Add(i.TTL))
+}
+
+// String returns a human readable representation of the lock.
+func (i *LockInfo) String() string {
+	return fmt.Sprintf("ID: %s, Who: %s, Operation: %s, Created: %s, TTL: %s",
+		i.ID, i.Who, i.Operation, i.Created.Format(time.RFC3339), i.TTL)
+}
+
+// Marshal encodes a LockInfo instance to bytes.
+func (i *LockInfo) Marshal() ([]byte, error) {
+	return json.MarshalIndent(lockInfoJSON{
+		ID:        i.ID,
+		Who:       i.Who,
+		Operation: i.Operation,
+		Created:   i.Created,
+		TTL:       i.TTL.String(),
+	}, "", "    ")
+}
+
+// ParseLockInfo parses bytes and returns a LockInfo instance.
+func ParseLockInfo(b []byte) (*LockInfo, error) {
+	var j lockInfoJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return nil, fmt.Errorf("failed to parse lock info: %w", err)
+	}
+
+	ttl, err := time.ParseDuration(j.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lock info: %w", err)
+	}
+
+	return &LockInfo{
+		ID:        j.ID,
+		Who:       j.Who,
+		Operation: j.Operation,
+		Created:   j.Created,
+		TTL:       ttl,
+	}, nil
+}
+
+// LockError is an error which indicates a lock is held by someone else.
+type LockError struct {
+	// Info is a lock info of the current holder.
+	Info *LockInfo
+}
+
+// Error returns a string representation of the error.
+func (e *LockError) Error() string {
+	return fmt.Sprintf("history is locked by someone else: %s", e.Info)
+}
+
+// UnlockIDMismatchError returns an error for unlocking with a wrong lock ID.
+func UnlockIDMismatchError(id string, info *LockInfo) error {
+	return fmt.Errorf("lock ID mismatch: %s is given, but the lock is held by %s", id, info)
+}
+
+// ErrNotLocked is an error which indicates no lock is held.
+var ErrNotLocked = errors.New("history is not locked")
+
+// LockObject is a minimal set of operations on a lock object.
+// It allows each storage to implement the Locker interface with
+// AcquireLock and ReleaseLock by sharing a locking protocol.
+type LockObject interface {
+	// Create creates a lock object with a given content only if it doesn't exist.
+	// It returns false with no error if the lock object already exists.
+	Create(ctx context.Context, b []byte) (bool, error)
+	// Read reads a content of the lock object with a version token which
+	// changes whenever the lock object is replaced.
+	// If the lock object doesn't exist, returns nil with no error.
+	Read(ctx context.Context) ([]byte, string, error)
+	// Replace replaces the lock object with a given content only if its
+	// current version matches a given version token returned by Read.
+	// It returns false with no error if the lock object has been replaced or
+	// deleted by someone else.
+	Replace(ctx context.Context, b []byte, version string) (bool, error)
+	// Delete deletes the lock object.
+	Delete(ctx context.Context) error
+}
+
+// AcquireLock acquires a lock by creating a given lock object.
+// If the lock object already exists and is expired, it is taken over.
+// Otherwise, returns a *LockError.
+// The takeover is conditional on the version of the expired lock we read,
+// so that only one of contenders which see the same expired lock wins.
+func AcquireLock(ctx context.Context, o LockObject, info *LockInfo) error {
+	b, err := info.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// Try twice at most. The second attempt is only for taking over an expired lock.
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := o.Create(ctx, b)
+		if err != nil {
+			return err
+		}
+		if created {
+			return nil
+		}
+
+		current, version, err := readLockInfo(ctx, o)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			// The lock was released just now. Try again.
+			continue
+		}
+		if attempt > 0 || !current.Expired(time.Now()) {
+			return &LockError{Info: current}
+		}
+
+		log.Printf("[WARN] [storage] take over an expired lock: %s\n", current)
+		replaced, err := o.Replace(ctx, b, version)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			return nil
+		}
+		// Someone else has taken over or released the expired lock just now.
+		// Try again.
+	}
+
+	current, _, err := readLockInfo(ctx, o)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("failed to acquire lock")
+	}
+	return &LockError{Info: current}
+}
+
+// ReleaseLock releases a lock by deleting a given lock object.
+// It returns an error if the lock is not held or held with a different ID.
+func ReleaseLock(ctx context.Context, o LockObject, id string) error {
+	current, _, err := readLockInfo(ctx, o)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrNotLocked
+	}
+	if current.ID != id {
+		return UnlockIDMismatchError(id, current)
+	}
+
+	return o.Delete(ctx)
+}
+
+// readLockInfo reads a lock info with its version from a given lock object.
+// If the lock object doesn't exist, returns nil with no error.
+func readLockInfo(ctx context.Context, o LockObject) (*LockInfo, string, error) {
+	b, version, err := o.Read(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if b == nil {
+		return nil, "", nil
+	}
+	info, err := ParseLockInfo(b)
+	if err != nil {
+		return nil, "", err
+	}
+	return info, version, nil
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// memLockObject is an in-memory LockObject implementation for testing.
+type memLockObject struct {
+	data    []byte
+	version int
+}
+
+func (o *memLockObject) Create(_ context.Context, b []byte) (bool, error) {
+	if o.data != nil {
+		return false, nil
+	}
+	o.data = b
+	o.version++
+	return true, nil
+}
+
+func (o *memLockObject) Read(_ context.Context) ([]byte, string, error) {
+	return o.data, strconv.Itoa(o.version), nil
+}
+
+func (o *memLockObject) Replace(_ context.Context, b []byte, version string) (bool, error) {
+	if o.data == nil || version != strconv.Itoa(o.version) {
+		return false, nil
+	}
+	o.data = b
+	o.version++
+	return true, nil
+}
+
+func (o *memLockObject) Delete(_ context.Context) error {
+	o.data = nil
+	return nil
+}
+
+func TestLockInfoMarshal(t *testing.T) {
+	info := &LockInfo{
+		ID:        "0123456789abcdef",
+		Who:       "foo@example",
+		Operation: "apply",
+		Created:   time.Date(2020, 10, 12, 1, 2, 3, 0, time.UTC),
+		TTL:       1 * time.Hour,
+	}
+
+	b, err := info.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := `{
+    "id": "0123456789abcdef",
+    "who": "foo@example",
+    "operation": "apply",
+    "created": "2020-10-12T01:02:03Z",
+    "ttl": "1h0m0s"
+}`
+	if string(b) != want {
+		t.Errorf("got: %s, want: %s", string(b), want)
+	}
+
+	got, err := ParseLockInfo(b)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if diff := cmp.Diff(got, info); diff != "" {
+		t.Errorf("got: %#v, want: %#v, diff: %s", got, info, diff)
+	}
+}
+
+func TestParseLockInfo(t *testing.T) {
+	cases := []struct {
+		desc   string
+		source string
+		ok     bool
+	}{
+		{
+			desc:   "simple",
+			source: `{"id": "foo", "who": "bar", "operation": "apply", "created": "2020-10-12T01:02:03Z", "ttl": "1h"}`,
+			ok:     true,
+		},
+		{
+			desc:   "invalid json",
+			source: `{`,
+			ok:     false,
+		},
+		{
+			desc:   "invalid ttl",
+			source: `{"id": "foo", "who": "bar", "operation": "apply", "created": "2020-10-12T01:02:03Z", "ttl": "foo"}`,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseLockInfo([]byte(tc.source))
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+		})
+	}
+}
+
+func TestLockInfoExpired(t *testing.T) {
+	created := time.Date(2020, 10, 12, 1, 2, 3, 0, time.UTC)
+	cases := []struct {
+		desc string
+		ttl  time.Duration
+		now  time.Time
+		want bool
+	}{
+		{
+			desc: "not expired",
+			ttl:  1 * time.Hour,
+			now:  created.Add(30 * time.Minute),
+			want: false,
+		},
+		{
+			desc: "expired",
+			ttl:  1 * time.Hour,
+			now:  created.Add(2 * time.Hour),
+			want: true,
+		},
+		{
+			desc: "no ttl",
+			ttl:  0,
+			now:  created.Add(24 * time.Hour),
+			want: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			info := &LockInfo{ID: "foo", Created: created, TTL: tc.ttl}
+			got := info.Expired(tc.now)
+			if got != tc.want {
+				t.Errorf("got: %t, want: %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	ctx := context.Background()
+	o := &memLockObject{}
+
+	info1, err := NewLockInfo("apply", DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := AcquireLock(ctx, o, info1); err != nil {
+		t.Fatalf("failed to acquire lock: %s", err)
+	}
+
+	info2, err := NewLockInfo("apply", DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	err = AcquireLock(ctx, o, info2)
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+	if lockErr.Info.ID != info1.ID {
+		t.Errorf("got lock holder: %s, want: %s", lockErr.Info.ID, info1.ID)
+	}
+
+	if err := ReleaseLock(ctx, o, info2.ID); err == nil {
+		t.Fatal("expected to fail to release a lock with a wrong ID, but no error")
+	}
+
+	if err := ReleaseLock(ctx, o, info1.ID); err != nil {
+		t.Fatalf("failed to release lock: %s", err)
+	}
+
+	if err := ReleaseLock(ctx, o, info1.ID); !errors.Is(err, ErrNotLocked) {
+		t.Fatalf("expected to return ErrNotLocked, but got: %v", err)
+	}
+}
+
+func TestAcquireLockTakeOverExpired(t *testing.T) {
+	ctx := context.Background()
+	expired := &LockInfo{
+		ID:        "expired",
+		Who:       "foo@example",
+		Operation: "apply",
+		Created:   time.Now().Add(-2 * time.Hour),
+		TTL:       1 * time.Hour,
+	}
+	b, err := expired.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal lock info: %s", err)
+	}
+	o := &memLockObject{data: b}
+
+	info, err := NewLockInfo("apply", DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := AcquireLock(ctx, o, info); err != nil {
+		t.Fatalf("failed to take over an expired lock: %s", err)
+	}
+
+	got, err := ParseLockInfo(o.data)
+	if err != nil {
+		t.Fatalf("failed to parse lock info: %s", err)
+	}
+	if got.ID != info.ID {
+		t.Errorf("got lock holder: %s, want: %s", got.ID, info.ID)
+	}
+}
@@ -13,6 +13,14 @@ type Config struct {
 
 	// A reference to an instance of mock storage for testing.
 	s *Storage
+	// lock stores a serialized lock info.
+	// It is stored in config to share the lock across storage instances.
+	lock []byte
+	// lockVersion is a counter of lock updates.
+	lockVersion int
+	// version is a counter of versioned writes.
+	// It is stored in config to share the version across storage instances.
+	version int
 }
 
 // Config implements a storage.Config.
@@ -31,3 +39,15 @@ func (c *Config) NewStorage() (storage.Storage, error) {
 func (c *Config) Storage() *Storage {
 	return c.s
 }
+
+// LockData returns a raw lock info in mock storage for testing.
+// It returns nil if not locked.
+func (c *Config) LockData() []byte {
+	return c.lock
+}
+
+// SetLockData sets a raw lock info in mock storage for testing.
+func (c *Config) SetLockData(b []byte) {
+	c.lock = b
+	c.lockVersion++
+}
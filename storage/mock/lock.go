@@ -0,0 +1,60 @@
+package mock
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock in memory.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, &lockObject{config: s.config}, info)
+}
+
+// Unlock releases a lock in memory.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, &lockObject{config: s.config}, id)
+}
+
+// lockObject is a storage.LockObject implementation for mock.
+type lockObject struct {
+	// config is a storage config for mock which holds a lock.
+	config *Config
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create creates a lock object only if it doesn't exist.
+func (l *lockObject) Create(_ context.Context, b []byte) (bool, error) {
+	if l.config.lock != nil {
+		return false, nil
+	}
+	l.config.lock = b
+	l.config.lockVersion++
+	return true, nil
+}
+
+// Read reads a content of the lock object with a counter of lock updates as
+// a version token.
+func (l *lockObject) Read(_ context.Context) ([]byte, string, error) {
+	return l.config.lock, strconv.Itoa(l.config.lockVersion), nil
+}
+
+// Replace replaces the lock object only if the version matches.
+func (l *lockObject) Replace(_ context.Context, b []byte, version string) (bool, error) {
+	if l.config.lock == nil || version != strconv.Itoa(l.config.lockVersion) {
+		return false, nil
+	}
+	l.config.lock = b
+	l.config.lockVersion++
+	return true, nil
+}
+
+// Delete deletes the lock object.
+func (l *lockObject) Delete(_ context.Context) error {
+	l.config.lock = nil
+	return nil
+}
@@ -0,0 +1,130 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{}
+
+	s1, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	s2, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	l1 := s1.(storage.Locker)
+	l2 := s2.(storage.Locker)
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := l1.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	if config.LockData() == nil {
+		t.Fatal("expected to hold a lock in config, but not locked")
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := l2.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := l1.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if config.LockData() != nil {
+		t.Fatalf("expected to release a lock, but got: %s", string(config.LockData()))
+	}
+}
+
+// racyLockObject is a lock object which runs a given function once right
+// after reading the lock, so that we can interleave another contender.
+type racyLockObject struct {
+	*lockObject
+	afterRead func()
+}
+
+func (l *racyLockObject) Read(ctx context.Context) ([]byte, string, error) {
+	b, version, err := l.lockObject.Read(ctx)
+	if l.afterRead != nil {
+		f := l.afterRead
+		l.afterRead = nil
+		f()
+	}
+	return b, version, err
+}
+
+func TestStorageLockTakeOverExpiredByTwoContenders(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{}
+	expired := &storage.LockInfo{
+		ID:        "expired",
+		Who:       "foo@example",
+		Operation: "apply",
+		Created:   time.Now().Add(-2 * time.Hour),
+		TTL:       1 * time.Hour,
+	}
+	b, err := expired.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal lock info: %s", err)
+	}
+	config.SetLockData(b)
+
+	s, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	infoA, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	infoB, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+
+	// Both contenders see the same expired lock.
+	// A takes over it after B reads it, but before B takes over it.
+	var errA error
+	o := &racyLockObject{
+		lockObject: &lockObject{config: config},
+		afterRead: func() {
+			errA = s.(storage.Locker).Lock(ctx, infoA)
+		},
+	}
+	errB := storage.AcquireLock(ctx, o, infoB)
+
+	if errA != nil {
+		t.Fatalf("failed to take over an expired lock: %s", errA)
+	}
+	var lockErr *storage.LockError
+	if !errors.As(errB, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", errB)
+	}
+	if lockErr.Info.ID != infoA.ID {
+		t.Errorf("got lock holder: %s, want: %s", lockErr.Info.ID, infoA.ID)
+	}
+
+	got, err := storage.ParseLockInfo(config.LockData())
+	if err != nil {
+		t.Fatalf("failed to parse lock info: %s", err)
+	}
+	if got.ID != infoA.ID {
+		t.Errorf("got lock holder: %s, want: %s", got.ID, infoA.ID)
+	}
+}
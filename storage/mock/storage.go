@@ -3,6 +3,7 @@ package mock
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/minamijoyo/tfmigrate/storage"
 )
@@ -16,7 +17,7 @@ type Storage struct {
 	data string
 }
 
-var _ storage.Storage = (*Storage)(nil)
+var _ storage.VersionedStorage = (*Storage)(nil)
 
 // NewStorage returns a new instance of Storage.
 func NewStorage(config *Config) (*Storage, error) {
@@ -48,3 +49,37 @@ func (s *Storage) Read(_ context.Context) ([]byte, error) {
 	}
 	return []byte(s.data), nil
 }
+
+// ReadWithVersion reads migration history data with a version token.
+// Unlike Read, it reads data shared across storage instances via config,
+// so that we can emulate concurrent updates.
+func (s *Storage) ReadWithVersion(_ context.Context) ([]byte, string, error) {
+	if s.config.ReadError {
+		return nil, "", fmt.Errorf("failed to read mock storage: readError = %t", s.config.ReadError)
+	}
+	return []byte(s.config.Data), s.currentVersion(), nil
+}
+
+// WriteWithVersion writes migration history data only if the current version
+// matches a given one. Unlike Write, it also updates data in config.
+func (s *Storage) WriteWithVersion(_ context.Context, b []byte, version string) (string, error) {
+	if s.config.WriteError {
+		return "", fmt.Errorf("failed to write mock storage: writeError = %t", s.config.WriteError)
+	}
+	if version != s.currentVersion() {
+		return "", storage.ErrVersionConflict
+	}
+	s.config.version++
+	s.config.Data = string(b)
+	s.data = string(b)
+	return s.currentVersion(), nil
+}
+
+// currentVersion returns a current version token.
+// An empty data is treated as uninitialized.
+func (s *Storage) currentVersion() string {
+	if len(s.config.Data) == 0 {
+		return ""
+	}
+	return strconv.Itoa(s.config.version)
+}
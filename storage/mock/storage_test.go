@@ -2,7 +2,10 @@ package mock
 
 import (
 	"context"
+	"errors"
 	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
 )
 
 func TestStorageWrite(t *testing.T) {
@@ -112,3 +115,46 @@ func TestStorageRead(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{}
+	s1, err := NewStorage(config)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	s2, err := NewStorage(config)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	_, v1, err := s1.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	_, v2, err := s2.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if _, err := s1.WriteWithVersion(ctx, []byte("foo"), v1); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, err := s2.WriteWithVersion(ctx, []byte("bar"), v2); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict, but got: %v", err)
+	}
+
+	got, v3, err := s2.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "foo" {
+		t.Errorf("got: %s, want: foo", string(got))
+	}
+	if _, err := s2.WriteWithVersion(ctx, []byte("bar"), v3); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if config.Data != "bar" {
+		t.Errorf("got: %s, want: bar", config.Data)
+	}
+}
@@ -0,0 +1,45 @@
+package pg
+
+import "github.com/minamijoyo/tfmigrate/storage"
+
+// Config is a config for PostgreSQL storage.
+// This is expected to have almost the same options as Terraform pg backend.
+// https://developer.hashicorp.com/terraform/language/settings/backends/pg
+// However, it has many minor options and it's a pain to test all options from
+// first, so we added only options we need for now.
+type Config struct {
+	// Postgres connection string; a postgres:// URL.
+	// It can also be set via PG_CONN_STR environment variable.
+	ConnStr string `hcl:"conn_str,optional"`
+	// Name of the schema to store the history table.
+	// Default to tfmigrate.
+	SchemaName string `hcl:"schema_name,optional"`
+	// Name of the table to store the migration history.
+	// Default to history.
+	TableName string `hcl:"table_name,optional"`
+	// Name of the row of the migration history in the table.
+	// It allows us to share the table with multiple histories.
+	// Default to default.
+	Name string `hcl:"name,optional"`
+	// Skip creating the schema if it doesn't exist.
+	SkipSchemaCreation bool `hcl:"skip_schema_creation,optional"`
+	// Skip creating the table if it doesn't exist.
+	SkipTableCreation bool `hcl:"skip_table_creation,optional"`
+}
+
+const (
+	// defaultSchemaName is a default name of the schema.
+	defaultSchemaName = "tfmigrate"
+	// defaultTableName is a default name of the table.
+	defaultTableName = "history"
+	// defaultName is a default name of the row.
+	defaultName = "default"
+)
+
+// Config implements a storage.Config.
+var _ storage.Config = (*Config)(nil)
+
+// NewStorage returns a new instance of storage.Storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return NewStorage(c, nil)
+}
@@ -0,0 +1,80 @@
+package pg
+
+import "testing"
+
+func TestConfigNewStorage(t *testing.T) {
+	cases := []struct {
+		desc   string
+		env    map[string]string
+		config *Config
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			config: &Config{
+				ConnStr: "postgres://localhost/tfmigrate?sslmode=disable",
+			},
+			ok: true,
+		},
+		{
+			desc: "conn_str from env",
+			env: map[string]string{
+				"PG_CONN_STR": "postgres://localhost/tfmigrate?sslmode=disable",
+			},
+			config: &Config{},
+			ok:     true,
+		},
+		{
+			desc:   "missing conn_str",
+			config: &Config{},
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("PG_CONN_STR", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			got, err := tc.config.NewStorage()
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				_ = got.(*Storage)
+			}
+		})
+	}
+}
+
+func TestConfigNewStorageReusesDB(t *testing.T) {
+	config := &Config{
+		ConnStr: "postgres://localhost/tfmigrate?sslmode=disable",
+	}
+	s1, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	s2, err := config.NewStorage()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if s1.(*Storage).db != s2.(*Storage).db {
+		t.Error("expected to reuse a database handle for the same conn_str")
+	}
+
+	other := &Config{
+		ConnStr: "postgres://localhost/other?sslmode=disable",
+	}
+	s3, err := other.NewStorage()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if s1.(*Storage).db == s3.(*Storage).db {
+		t.Error("expected to open another database handle for a different conn_str")
+	}
+}
@@ -0,0 +1,79 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a transactional advisory lock.
+// The lock is held by a transaction until Unlock is called. Since PostgreSQL
+// releases it automatically when the session ends, a lock is never left
+// even if the process crashes.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	if s.tx != nil {
+		return &storage.LockError{Info: s.lockInfo}
+	}
+
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var locked bool
+	err = tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", s.lockID()).Scan(&locked)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !locked {
+		_ = tx.Rollback()
+		// An advisory lock has no information about its holder.
+		return &storage.LockError{Info: &storage.LockInfo{
+			Who:       "another session",
+			Operation: "unknown",
+		}}
+	}
+
+	s.tx = tx
+	s.lockInfo = info
+	return nil
+}
+
+// Unlock releases a transactional advisory lock by committing the transaction.
+// Note that a lock held by another session cannot be released, so that
+// force-unlock doesn't work for pg. It is released automatically when the
+// session ends, or can be released by terminating the session.
+func (s *Storage) Unlock(_ context.Context, id string) error {
+	if s.tx == nil {
+		return fmt.Errorf("%w in this session: an advisory lock of pg is tied to the transaction of its holder and cannot be released by force-unlock. "+
+			"It is released automatically when the session holding it ends. "+
+			"If the holder hangs, terminate its session with pg_terminate_backend", storage.ErrNotLocked)
+	}
+	if s.lockInfo.ID != id {
+		return storage.UnlockIDMismatchError(id, s.lockInfo)
+	}
+
+	err := s.tx.Commit()
+	s.tx = nil
+	s.lockInfo = nil
+	if err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
+
+// lockID returns a key of the advisory lock derived from the table and name.
+func (s *Storage) lockID() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s.table() + "/" + s.name()))
+	return int64(h.Sum64())
+}
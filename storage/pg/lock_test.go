@@ -0,0 +1,87 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	ctx := context.Background()
+	s, mock := newMockStorage(t, &Config{})
+	expectInit(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).
+		WithArgs(s.lockID()).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+	mock.ExpectCommit()
+
+	info, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	// the lock is not reentrant
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := s.Unlock(ctx, "wrong-id"); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+	if err := s.Unlock(ctx, info.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if err := s.Unlock(ctx, info.ID); !errors.Is(err, storage.ErrNotLocked) {
+		t.Fatalf("expected to return ErrNotLocked, but got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStorageLockHeldByAnotherSession(t *testing.T) {
+	ctx := context.Background()
+	s, mock := newMockStorage(t, &Config{})
+	expectInit(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).
+		WithArgs(s.lockID()).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+	mock.ExpectRollback()
+
+	info, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStorageLockID(t *testing.T) {
+	s1, _ := newMockStorage(t, &Config{Name: "foo"})
+	s2, _ := newMockStorage(t, &Config{Name: "foo"})
+	s3, _ := newMockStorage(t, &Config{Name: "bar"})
+
+	if s1.lockID() != s2.lockID() {
+		t.Errorf("expected the same lock ID for the same name: %d, %d", s1.lockID(), s2.lockID())
+	}
+	if s1.lockID() == s3.lockID() {
+		t.Errorf("expected a different lock ID for a different name: %d", s1.lockID())
+	}
+}
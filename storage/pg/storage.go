@@ -0,0 +1,216 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	// The pq package also registers the postgres driver for database/sql.
+	"github.com/lib/pq"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// Storage is a storage.Storage implementation for PostgreSQL.
+// It stores the migration history as a row of a table.
+type Storage struct {
+	// config is a storage config for pg.
+	config *Config
+	// db is a handle of database.
+	// It is intended to be replaced with a mock for testing.
+	db *sql.DB
+	// initialized is true if the schema and table have been set up.
+	initialized bool
+	// tx is a transaction which holds an advisory lock.
+	// It is nil if not locked.
+	tx *sql.Tx
+	// lockInfo is a lock info of the lock held by this storage.
+	lockInfo *storage.LockInfo
+}
+
+var _ storage.VersionedStorage = (*Storage)(nil)
+
+// NewStorage returns a new instance of Storage.
+func NewStorage(config *Config, db *sql.DB) (*Storage, error) {
+	if db == nil {
+		connStr := config.ConnStr
+		if connStr == "" {
+			connStr = os.Getenv("PG_CONN_STR")
+		}
+		if connStr == "" {
+			return nil, fmt.Errorf("conn_str is required for pg storage")
+		}
+
+		var err error
+		db, err = openDB(connStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Storage{
+		config: config,
+		db:     db,
+	}
+	return s, nil
+}
+
+var (
+	// dbsMu guards dbs.
+	dbsMu sync.Mutex
+	// dbs is a cache of database handles keyed by a connection string.
+	dbs = make(map[string]*sql.DB)
+)
+
+// openDB returns a database handle for a given connection string.
+// A sql.DB is a pool of connections which is intended to be long-lived and
+// shared. Since a new storage is created for each operation, the handle is
+// cached instead of opening a new pool which is never closed.
+func openDB(connStr string) (*sql.DB, error) {
+	dbsMu.Lock()
+	defer dbsMu.Unlock()
+
+	if db, ok := dbs[connStr]; ok {
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	dbs[connStr] = db
+	return db, nil
+}
+
+// schemaName returns a name of the schema.
+func (s *Storage) schemaName() string {
+	if s.config.SchemaName == "" {
+		return defaultSchemaName
+	}
+	return s.config.SchemaName
+}
+
+// table returns a quoted name of the table qualified with the schema.
+func (s *Storage) table() string {
+	tableName := s.config.TableName
+	if tableName == "" {
+		tableName = defaultTableName
+	}
+	return pq.QuoteIdentifier(s.schemaName()) + "." + pq.QuoteIdentifier(tableName)
+}
+
+// name returns a name of the row.
+func (s *Storage) name() string {
+	if s.config.Name == "" {
+		return defaultName
+	}
+	return s.config.Name
+}
+
+// init creates the schema and table if they don't exist.
+func (s *Storage) init(ctx context.Context) error {
+	if s.initialized {
+		return nil
+	}
+
+	if !s.config.SkipSchemaCreation {
+		query := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(s.schemaName()))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	if !s.config.SkipTableCreation {
+		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (name TEXT PRIMARY KEY, data TEXT NOT NULL, version BIGINT NOT NULL)", s.table())
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	s.initialized = true
+	return nil
+}
+
+// Write writes migration history data to storage.
+func (s *Storage) Write(ctx context.Context, b []byte) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %[1]s (name, data, version) VALUES ($1, $2, 1) ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, version = %[1]s.version + 1", s.table())
+	_, err := s.db.ExecContext(ctx, query, s.name(), string(b))
+	return err
+}
+
+// Read reads migration history data from storage.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array instead of an error.
+func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, _, err := s.ReadWithVersion(ctx)
+	return b, err
+}
+
+// ReadWithVersion reads migration history data from storage with a version
+// column as a version token.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
+	if err := s.init(ctx); err != nil {
+		return nil, "", err
+	}
+
+	query := fmt.Sprintf("SELECT data, version FROM %s WHERE name = $1", s.table())
+	var data string
+	var version int64
+	err := s.db.QueryRowContext(ctx, query, s.name()).Scan(&data, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		// If the key does not exist
+		return []byte{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(data), strconv.FormatInt(version, 10), nil
+}
+
+// WriteWithVersion writes migration history data to storage only if the
+// version column matches a given version.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	if err := s.init(ctx); err != nil {
+		return "", err
+	}
+
+	var query string
+	var args []interface{}
+	var newVersion int64
+	if version == "" {
+		query = fmt.Sprintf("INSERT INTO %s (name, data, version) VALUES ($1, $2, 1) ON CONFLICT (name) DO NOTHING", s.table())
+		args = []interface{}{s.name(), string(b)}
+		newVersion = 1
+	} else {
+		current, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version for pg: %s", version)
+		}
+		query = fmt.Sprintf("UPDATE %s SET data = $1, version = version + 1 WHERE name = $2 AND version = $3", s.table())
+		args = []interface{}{string(b), s.name(), current}
+		newVersion = current + 1
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", storage.ErrVersionConflict
+	}
+
+	return strconv.FormatInt(newVersion, 10), nil
+}
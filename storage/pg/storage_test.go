@@ -0,0 +1,333 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+// newMockStorage returns a new Storage with a mocked database.
+// The schema and table creation are expected.
+func newMockStorage(t *testing.T, config *Config) (*Storage, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewStorage(config, db)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	return s, mock
+}
+
+// expectInit sets expectations for creating the schema and table.
+func expectInit(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE SCHEMA IF NOT EXISTS "tfmigrate"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS "tfmigrate"."history"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestStorageInit(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *Config
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			desc:   "default",
+			config: &Config{},
+			expect: expectInit,
+		},
+		{
+			desc: "custom schema and table",
+			config: &Config{
+				SchemaName: "foo",
+				TableName:  "bar",
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`CREATE SCHEMA IF NOT EXISTS "foo"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS "foo"."bar"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			desc: "skip creation",
+			config: &Config{
+				SkipSchemaCreation: true,
+				SkipTableCreation:  true,
+			},
+			expect: func(_ sqlmock.Sqlmock) {},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, mock := newMockStorage(t, tc.config)
+			tc.expect(mock)
+
+			if err := s.init(context.Background()); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			// init is called only once
+			if err := s.init(context.Background()); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorageRead(t *testing.T) {
+	cases := []struct {
+		desc        string
+		rows        *sqlmock.Rows
+		err         error
+		contents    []byte
+		wantVersion string
+		ok          bool
+	}{
+		{
+			desc:        "simple",
+			rows:        sqlmock.NewRows([]string{"data", "version"}).AddRow("foo", 3),
+			contents:    []byte("foo"),
+			wantVersion: "3",
+			ok:          true,
+		},
+		{
+			desc:        "row does not exist",
+			rows:        sqlmock.NewRows([]string{"data", "version"}),
+			contents:    []byte{},
+			wantVersion: "",
+			ok:          true,
+		},
+		{
+			desc: "query error",
+			err:  errors.New("connection refused"),
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, mock := newMockStorage(t, &Config{})
+			expectInit(mock)
+			q := mock.ExpectQuery(regexp.QuoteMeta(`SELECT data, version FROM "tfmigrate"."history" WHERE name = $1`)).
+				WithArgs("default")
+			if tc.err != nil {
+				q.WillReturnError(tc.err)
+			} else {
+				q.WillReturnRows(tc.rows)
+			}
+
+			got, version, err := s.ReadWithVersion(context.Background())
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatal("expected to return an error, but no error")
+			}
+
+			if tc.ok {
+				if string(got) != string(tc.contents) {
+					t.Errorf("got: %s, want: %s", string(got), string(tc.contents))
+				}
+				if version != tc.wantVersion {
+					t.Errorf("got version: %s, want: %s", version, tc.wantVersion)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorageWrite(t *testing.T) {
+	s, mock := newMockStorage(t, &Config{Name: "foo"})
+	expectInit(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "tfmigrate"."history" (name, data, version) VALUES ($1, $2, 1) ON CONFLICT (name) DO UPDATE`)).
+		WithArgs("foo", "bar").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := s.Write(context.Background(), []byte("bar")); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	cases := []struct {
+		desc        string
+		version     string
+		expect      func(mock sqlmock.Sqlmock)
+		wantVersion string
+		ok          bool
+		err         error
+	}{
+		{
+			desc:    "insert",
+			version: "",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "tfmigrate"."history" (name, data, version) VALUES ($1, $2, 1) ON CONFLICT (name) DO NOTHING`)).
+					WithArgs("default", "foo").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantVersion: "1",
+			ok:          true,
+		},
+		{
+			desc:    "insert conflict",
+			version: "",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "tfmigrate"."history"`)).
+					WithArgs("default", "foo").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			ok:  false,
+			err: storage.ErrVersionConflict,
+		},
+		{
+			desc:    "update",
+			version: "3",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tfmigrate"."history" SET data = $1, version = version + 1 WHERE name = $2 AND version = $3`)).
+					WithArgs("foo", "default", int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantVersion: "4",
+			ok:          true,
+		},
+		{
+			desc:    "update conflict",
+			version: "3",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "tfmigrate"."history"`)).
+					WithArgs("foo", "default", int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			ok:  false,
+			err: storage.ErrVersionConflict,
+		},
+		{
+			desc:    "invalid version",
+			version: "foo",
+			expect:  func(_ sqlmock.Sqlmock) {},
+			ok:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, mock := newMockStorage(t, &Config{})
+			expectInit(mock)
+			tc.expect(mock)
+
+			got, err := s.WriteWithVersion(context.Background(), []byte("foo"), tc.version)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok {
+				if err == nil {
+					t.Fatal("expected to return an error, but no error")
+				}
+				if tc.err != nil && !errors.Is(err, tc.err) {
+					t.Fatalf("got err: %v, want: %v", err, tc.err)
+				}
+			}
+			if tc.ok && got != tc.wantVersion {
+				t.Errorf("got version: %s, want: %s", got, tc.wantVersion)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+// TestAccStorage runs tests against a real PostgreSQL.
+// It requires PG_CONN_STR to be set.
+func TestAccStorage(t *testing.T) {
+	if os.Getenv("TEST_ACC") != "1" {
+		t.Skip("skip acceptance tests")
+	}
+	if os.Getenv("PG_CONN_STR") == "" {
+		t.Skip("skip acceptance tests for pg because PG_CONN_STR is not set")
+	}
+
+	ctx := context.Background()
+	config := &Config{
+		Name: fmt.Sprintf("%s-%d", t.Name(), os.Getpid()),
+	}
+	s1, err := NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+	s2, err := NewStorage(config, nil)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	// compare-and-swap writes
+	_, v0, err := s1.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	v1, err := s1.WriteWithVersion(ctx, []byte("foo"), v0)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, err := s2.WriteWithVersion(ctx, []byte("bar"), v0); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict, but got: %v", err)
+	}
+	if _, err := s2.WriteWithVersion(ctx, []byte("bar"), v1); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	got, err := s1.Read(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "bar" {
+		t.Errorf("got: %s, want: bar", string(got))
+	}
+
+	// advisory lock across sessions
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s1.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s2.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+	if err := s1.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if err := s2.Lock(ctx, info2); err != nil {
+		t.Fatalf("failed to lock after unlock: %s", err)
+	}
+	if err := s2.Unlock(ctx, info2.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+}
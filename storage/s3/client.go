@@ -3,6 +3,8 @@ package s3
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
@@ -17,6 +19,8 @@ type Client interface {
 	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
 	// GetObject gets a file from S3.
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	// DeleteObject deletes a file from S3.
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
 }
 
 // client is a real implementation of the Client.
@@ -27,24 +31,9 @@ type client struct {
 
 // newClient returns a new instance of Client.
 func newClient(config *Config) (Client, error) {
-	cfg := &awsbase.Config{
-		AccessKey:           config.AccessKey,
-		Profile:             config.Profile,
-		Region:              config.Region,
-		SecretKey:           config.SecretKey,
-		SkipCredsValidation: config.SkipCredentialsValidation,
-	}
-
-	if config.RoleARN != "" {
-		cfg.AssumeRole = &awsbase.AssumeRole{
-			RoleARN: config.RoleARN,
-		}
-	}
-
-	if config.SkipMetadataAPICheck {
-		cfg.EC2MetadataServiceEnableState = imds.ClientDisabled
-	} else {
-		cfg.EC2MetadataServiceEnableState = imds.ClientEnabled
+	cfg, err := newAwsbaseConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
 	ctx := context.Background()
@@ -53,12 +42,17 @@ func newClient(config *Config) (Client, error) {
 		return nil, fmt.Errorf("failed to load aws config: %#v", awsDiags)
 	}
 
+	endpoint := config.Endpoint
+	if config.Endpoints != nil && config.Endpoints.S3 != "" {
+		endpoint = config.Endpoints.S3
+	}
+
 	s3Client := s3.NewFromConfig(awsConfig, func(options *s3.Options) {
-		if config.Endpoint != "" {
-			options.BaseEndpoint = aws.String(config.Endpoint)
+		if endpoint != "" {
+			options.BaseEndpoint = aws.String(endpoint)
 		}
-		if config.ForcePathStyle {
-			options.UsePathStyle = config.ForcePathStyle
+		if config.ForcePathStyle || config.UsePathStyle {
+			options.UsePathStyle = true
 		}
 	})
 
@@ -68,6 +62,107 @@ func newClient(config *Config) (Client, error) {
 	}, nil
 }
 
+// newAwsbaseConfig converts a storage config to a config for awsbase.
+func newAwsbaseConfig(config *Config) (*awsbase.Config, error) {
+	cfg := &awsbase.Config{
+		AccessKey:               config.AccessKey,
+		CustomCABundle:          config.CustomCABundle,
+		Insecure:                config.Insecure,
+		MaxRetries:              config.MaxRetries,
+		Profile:                 config.Profile,
+		Region:                  config.Region,
+		SecretKey:               config.SecretKey,
+		SharedConfigFiles:       config.SharedConfigFiles,
+		SharedCredentialsFiles:  config.SharedCredentialsFiles,
+		SkipCredsValidation:     config.SkipCredentialsValidation,
+		SkipRequestingAccountId: config.SkipRequestingAccountID,
+		StsRegion:               config.StsRegion,
+		Token:                   config.Token,
+		UseDualStackEndpoint:    config.UseDualStackEndpoint,
+		UseFIPSEndpoint:         config.UseFIPSEndpoint,
+	}
+
+	if config.Endpoints != nil {
+		cfg.IamEndpoint = config.Endpoints.IAM
+		cfg.SsoEndpoint = config.Endpoints.SSO
+		cfg.StsEndpoint = config.Endpoints.STS
+	}
+
+	if config.RoleARN != "" && config.AssumeRole != nil {
+		return nil, fmt.Errorf("role_arn conflicts with assume_role block, set role_arn in the assume_role block instead")
+	}
+
+	if config.RoleARN != "" {
+		cfg.AssumeRole = &awsbase.AssumeRole{
+			RoleARN: config.RoleARN,
+		}
+	}
+
+	if r := config.AssumeRole; r != nil {
+		duration, err := parseDuration(r.Duration)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AssumeRole = &awsbase.AssumeRole{
+			RoleARN:           r.RoleARN,
+			Duration:          duration,
+			ExternalID:        r.ExternalID,
+			Policy:            r.Policy,
+			PolicyARNs:        r.PolicyARNs,
+			SessionName:       r.SessionName,
+			SourceIdentity:    r.SourceIdentity,
+			Tags:              r.Tags,
+			TransitiveTagKeys: r.TransitiveTagKeys,
+		}
+	}
+
+	if r := config.AssumeRoleWithWebIdentity; r != nil {
+		duration, err := parseDuration(r.Duration)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AssumeRoleWithWebIdentity = &awsbase.AssumeRoleWithWebIdentity{
+			RoleARN:              withEnv(r.RoleARN, "AWS_ROLE_ARN"),
+			Duration:             duration,
+			Policy:               r.Policy,
+			PolicyARNs:           r.PolicyARNs,
+			SessionName:          withEnv(r.SessionName, "AWS_ROLE_SESSION_NAME"),
+			WebIdentityToken:     r.WebIdentityToken,
+			WebIdentityTokenFile: withEnv(r.WebIdentityTokenFile, "AWS_WEB_IDENTITY_TOKEN_FILE"),
+		}
+	}
+
+	if config.SkipMetadataAPICheck {
+		cfg.EC2MetadataServiceEnableState = imds.ClientDisabled
+	} else {
+		cfg.EC2MetadataServiceEnableState = imds.ClientEnabled
+	}
+
+	return cfg, nil
+}
+
+// parseDuration parses a duration string such as 1h.
+// An empty string means zero, which is a default of the SDK.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration %s: %w", s, err)
+	}
+	return d, nil
+}
+
+// withEnv returns a given value if not empty, otherwise returns a value of a
+// given environment variable.
+func withEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
 // PutObject puts a file to S3.
 func (c *client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
 	return c.s3Client.PutObject(ctx, params, optFns...)
@@ -77,3 +172,8 @@ func (c *client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFn
 func (c *client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
 	return c.s3Client.GetObject(ctx, params, optFns...)
 }
+
+// DeleteObject deletes a file from S3.
+func (c *client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return c.s3Client.DeleteObject(ctx, params, optFns...)
+}
@@ -24,6 +24,7 @@ type Config struct {
 	// Name of AWS profile in AWS shared credentials file.
 	Profile string `hcl:"profile,optional"`
 	// Amazon Resource Name (ARN) of the IAM Role to assume.
+	// It conflicts with the assume_role block.
 	RoleARN string `hcl:"role_arn,optional"`
 	// Skip credentials validation via the STS API.
 	SkipCredentialsValidation bool `hcl:"skip_credentials_validation,optional"`
@@ -34,6 +35,96 @@ type Config struct {
 	ForcePathStyle bool `hcl:"force_path_style,optional"`
 	// SSE KMS Key Id for optional server-side encryption enablement
 	KmsKeyID string `hcl:"kms_key_id,optional"`
+
+	// AWS session token.
+	Token string `hcl:"token,optional"`
+	// List of paths to AWS shared config files.
+	SharedConfigFiles []string `hcl:"shared_config_files,optional"`
+	// List of paths to AWS shared credentials files.
+	SharedCredentialsFiles []string `hcl:"shared_credentials_files,optional"`
+	// Configuration of the IAM Role to assume.
+	AssumeRole *AssumeRole `hcl:"assume_role,block"`
+	// Configuration of the IAM Role to assume with web identity such as OIDC.
+	AssumeRoleWithWebIdentity *AssumeRoleWithWebIdentity `hcl:"assume_role_with_web_identity,block"`
+	// Custom endpoints for the AWS API.
+	Endpoints *Endpoints `hcl:"endpoints,block"`
+	// AWS region for STS. Default to region.
+	StsRegion string `hcl:"sts_region,optional"`
+	// Use FIPS endpoints.
+	UseFIPSEndpoint bool `hcl:"use_fips_endpoint,optional"`
+	// Use dual-stack endpoints.
+	UseDualStackEndpoint bool `hcl:"use_dualstack_endpoint,optional"`
+	// Enable path-style S3 URLs. Same as force_path_style.
+	UsePathStyle bool `hcl:"use_path_style,optional"`
+	// Skip TLS verification. It's not recommended except for testing.
+	Insecure bool `hcl:"insecure,optional"`
+	// Path to a custom CA bundle file.
+	CustomCABundle string `hcl:"custom_ca_bundle,optional"`
+	// Maximum number of times an AWS API request is retried.
+	MaxRetries int `hcl:"max_retries,optional"`
+	// Skip requesting the account ID.
+	SkipRequestingAccountID bool `hcl:"skip_requesting_account_id,optional"`
+	// Use a lock file for locking.
+	// It's a no-op accepted only for compatibility with Terraform s3 backend,
+	// because we always use a lock file with conditional writes of S3 for
+	// locking regardless of its value.
+	UseLockfile bool `hcl:"use_lockfile,optional"`
+}
+
+// AssumeRole is a configuration of the IAM Role to assume.
+type AssumeRole struct {
+	// Amazon Resource Name (ARN) of the IAM Role to assume.
+	RoleARN string `hcl:"role_arn"`
+	// Duration of the session such as 1h. Default to 15m.
+	Duration string `hcl:"duration,optional"`
+	// External identifier to use when assuming the role.
+	ExternalID string `hcl:"external_id,optional"`
+	// IAM Policy JSON to further restrict permissions of the session.
+	Policy string `hcl:"policy,optional"`
+	// List of ARNs of IAM Policies to further restrict permissions of the session.
+	PolicyARNs []string `hcl:"policy_arns,optional"`
+	// Session name to use when assuming the role.
+	SessionName string `hcl:"session_name,optional"`
+	// Source identity specified by the principal assuming the role.
+	SourceIdentity string `hcl:"source_identity,optional"`
+	// Map of assume role session tags.
+	Tags map[string]string `hcl:"tags,optional"`
+	// List of keys of session tags to pass to subsequent sessions.
+	TransitiveTagKeys []string `hcl:"transitive_tag_keys,optional"`
+}
+
+// AssumeRoleWithWebIdentity is a configuration of the IAM Role to assume
+// with web identity.
+type AssumeRoleWithWebIdentity struct {
+	// Amazon Resource Name (ARN) of the IAM Role to assume.
+	// It can also be set via AWS_ROLE_ARN environment variable.
+	RoleARN string `hcl:"role_arn,optional"`
+	// Duration of the session such as 1h. Default to 15m.
+	Duration string `hcl:"duration,optional"`
+	// IAM Policy JSON to further restrict permissions of the session.
+	Policy string `hcl:"policy,optional"`
+	// List of ARNs of IAM Policies to further restrict permissions of the session.
+	PolicyARNs []string `hcl:"policy_arns,optional"`
+	// Session name to use when assuming the role.
+	// It can also be set via AWS_ROLE_SESSION_NAME environment variable.
+	SessionName string `hcl:"session_name,optional"`
+	// Value of a web identity token from an OpenID Connect (OIDC) or OAuth provider.
+	WebIdentityToken string `hcl:"web_identity_token,optional"`
+	// Path to a file containing a web identity token.
+	// It can also be set via AWS_WEB_IDENTITY_TOKEN_FILE environment variable.
+	WebIdentityTokenFile string `hcl:"web_identity_token_file,optional"`
+}
+
+// Endpoints is a configuration of custom endpoints for the AWS API.
+type Endpoints struct {
+	// Custom endpoint for the AWS S3 API. It takes precedence over endpoint.
+	S3 string `hcl:"s3,optional"`
+	// Custom endpoint for the AWS STS API.
+	STS string `hcl:"sts,optional"`
+	// Custom endpoint for the AWS IAM API.
+	IAM string `hcl:"iam,optional"`
+	// Custom endpoint for the AWS IAM Identity Center (SSO) API.
+	SSO string `hcl:"sso,optional"`
 }
 
 // Config implements a storage.Config.
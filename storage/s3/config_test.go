@@ -1,6 +1,13 @@
 package s3
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+)
 
 func TestConfigNewStorage(t *testing.T) {
 	cases := []struct {
@@ -43,3 +50,135 @@ func TestConfigNewStorage(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAwsbaseConfig(t *testing.T) {
+	cases := []struct {
+		desc   string
+		env    map[string]string
+		config *Config
+		want   *awsbase.Config
+		ok     bool
+	}{
+		{
+			desc: "assume role",
+			config: &Config{
+				Region:                  "ap-northeast-1",
+				Token:                   "token",
+				SharedConfigFiles:       []string{"/path/to/config"},
+				SharedCredentialsFiles:  []string{"/path/to/credentials"},
+				StsRegion:               "us-east-1",
+				UseFIPSEndpoint:         true,
+				UseDualStackEndpoint:    true,
+				MaxRetries:              3,
+				SkipRequestingAccountID: true,
+				AssumeRole: &AssumeRole{
+					RoleARN:           "arn:aws:iam::123456789012:role/tfmigrate",
+					Duration:          "1h",
+					ExternalID:        "foo",
+					Policy:            "{}",
+					PolicyARNs:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+					SessionName:       "bar",
+					SourceIdentity:    "baz",
+					Tags:              map[string]string{"key": "value"},
+					TransitiveTagKeys: []string{"key"},
+				},
+				Endpoints: &Endpoints{
+					STS: "https://sts.example.com",
+					IAM: "https://iam.example.com",
+					SSO: "https://sso.example.com",
+				},
+			},
+			want: &awsbase.Config{
+				Region:                  "ap-northeast-1",
+				Token:                   "token",
+				SharedConfigFiles:       []string{"/path/to/config"},
+				SharedCredentialsFiles:  []string{"/path/to/credentials"},
+				StsRegion:               "us-east-1",
+				UseFIPSEndpoint:         true,
+				UseDualStackEndpoint:    true,
+				MaxRetries:              3,
+				SkipRequestingAccountId: true,
+				AssumeRole: &awsbase.AssumeRole{
+					RoleARN:           "arn:aws:iam::123456789012:role/tfmigrate",
+					Duration:          time.Hour,
+					ExternalID:        "foo",
+					Policy:            "{}",
+					PolicyARNs:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+					SessionName:       "bar",
+					SourceIdentity:    "baz",
+					Tags:              map[string]string{"key": "value"},
+					TransitiveTagKeys: []string{"key"},
+				},
+				StsEndpoint:                   "https://sts.example.com",
+				IamEndpoint:                   "https://iam.example.com",
+				SsoEndpoint:                   "https://sso.example.com",
+				EC2MetadataServiceEnableState: imds.ClientEnabled,
+			},
+			ok: true,
+		},
+		{
+			desc: "assume role with web identity from env",
+			env: map[string]string{
+				"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/tfmigrate",
+				"AWS_ROLE_SESSION_NAME":       "foo",
+				"AWS_WEB_IDENTITY_TOKEN_FILE": "/path/to/token",
+			},
+			config: &Config{
+				SkipMetadataAPICheck:      true,
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{},
+			},
+			want: &awsbase.Config{
+				AssumeRoleWithWebIdentity: &awsbase.AssumeRoleWithWebIdentity{
+					RoleARN:              "arn:aws:iam::123456789012:role/tfmigrate",
+					SessionName:          "foo",
+					WebIdentityTokenFile: "/path/to/token",
+				},
+				EC2MetadataServiceEnableState: imds.ClientDisabled,
+			},
+			ok: true,
+		},
+		{
+			desc: "role_arn conflicts with assume_role block",
+			config: &Config{
+				RoleARN: "arn:aws:iam::123456789012:role/foo",
+				AssumeRole: &AssumeRole{
+					RoleARN: "arn:aws:iam::123456789012:role/bar",
+				},
+			},
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "invalid duration",
+			config: &Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:  "arn:aws:iam::123456789012:role/tfmigrate",
+					Duration: "foo",
+				},
+			},
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("AWS_ROLE_ARN", "")
+			t.Setenv("AWS_ROLE_SESSION_NAME", "")
+			t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			got, err := newAwsbaseConfig(tc.config)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
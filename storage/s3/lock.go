@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+var _ storage.Locker = (*Storage)(nil)
+
+// Lock acquires a lock by creating a lock object next to the history key.
+// It relies on the conditional write of S3 to create the lock object atomically.
+func (s *Storage) Lock(ctx context.Context, info *storage.LockInfo) error {
+	return storage.AcquireLock(ctx, s.lockObject(), info)
+}
+
+// Unlock releases a lock by deleting the lock object.
+func (s *Storage) Unlock(ctx context.Context, id string) error {
+	return storage.ReleaseLock(ctx, s.lockObject(), id)
+}
+
+// lockObject returns a storage.LockObject for the history key.
+func (s *Storage) lockObject() *lockObject {
+	return &lockObject{
+		config: s.config,
+		client: s.client,
+		key:    s.config.Key + storage.LockSuffix,
+	}
+}
+
+// lockObject is a storage.LockObject implementation for AWS S3.
+type lockObject struct {
+	// config is a storage config for s3.
+	config *Config
+	// client is an instance of S3Client interface to call API.
+	client Client
+	// key is a key of the lock object.
+	key string
+}
+
+var _ storage.LockObject = (*lockObject)(nil)
+
+// Create creates a lock object only if it doesn't exist.
+func (l *lockObject) Create(ctx context.Context, b []byte) (bool, error) {
+	input := l.putObjectInput(b)
+	input.IfNoneMatch = aws.String("*")
+
+	_, err := l.client.PutObject(ctx, input)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			// The lock object already exists
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Replace overwrites the lock object only if its ETag matches a given version.
+func (l *lockObject) Replace(ctx context.Context, b []byte, version string) (bool, error) {
+	_, err := l.client.PutObject(ctx, l.putObjectInput(b), withIfMatch(version))
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if isPreconditionFailed(err) || errors.As(err, &nsk) {
+			// The lock object has been replaced or deleted
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// putObjectInput returns an input of PutObject for the lock object.
+func (l *lockObject) putObjectInput(b []byte) *s3.PutObjectInput {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(l.config.Bucket),
+		Key:    aws.String(l.key),
+		Body:   bytes.NewReader(b),
+	}
+	if l.config.KmsKeyID != "" {
+		input.SSEKMSKeyId = &l.config.KmsKeyID
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+	}
+	return input
+}
+
+// Read reads a content of the lock object with its ETag as a version token.
+func (l *lockObject) Read(ctx context.Context) ([]byte, string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(l.config.Bucket),
+		Key:    aws.String(l.key),
+	}
+
+	output, err := l.client.GetObject(ctx, input)
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			// If the lock object does not exist
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	defer output.Body.Close()
+
+	buf := bytes.NewBuffer(nil)
+	_, err = buf.ReadFrom(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), aws.ToString(output.ETag), nil
+}
+
+// Delete deletes the lock object.
+func (l *lockObject) Delete(ctx context.Context) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(l.config.Bucket),
+		Key:    aws.String(l.key),
+	}
+
+	_, err := l.client.DeleteObject(ctx, input)
+	return err
+}
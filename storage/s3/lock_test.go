@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/storage"
+)
+
+func TestStorageLock(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{
+		Bucket: "tfmigrate-test",
+		Key:    "tfmigrate/history.json",
+	}
+	client := newMemClient()
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	info1, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	if err := s.Lock(ctx, info1); err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	if _, ok := client.objects["tfmigrate/history.json.lock"]; !ok {
+		t.Fatal("expected to create a lock object, but not found")
+	}
+
+	info2, err := storage.NewLockInfo("apply", storage.DefaultLockTTL)
+	if err != nil {
+		t.Fatalf("failed to create lock info: %s", err)
+	}
+	var lockErr *storage.LockError
+	if err := s.Lock(ctx, info2); !errors.As(err, &lockErr) {
+		t.Fatalf("expected to return a LockError, but got: %v", err)
+	}
+
+	if err := s.Unlock(ctx, info2.ID); err == nil {
+		t.Fatal("expected to fail to unlock with a wrong ID, but no error")
+	}
+
+	if err := s.Unlock(ctx, info1.ID); err != nil {
+		t.Fatalf("failed to unlock: %s", err)
+	}
+	if _, ok := client.objects["tfmigrate/history.json.lock"]; ok {
+		t.Fatal("expected to delete a lock object, but found")
+	}
+}
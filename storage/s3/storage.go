@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"log"
+	"net/http"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/minamijoyo/tfmigrate/storage"
 )
 
@@ -20,7 +24,7 @@ type Storage struct {
 	client Client
 }
 
-var _ storage.Storage = (*Storage)(nil)
+var _ storage.VersionedStorage = (*Storage)(nil)
 
 // NewStorage returns a new instance of Storage.
 func NewStorage(config *Config, client Client) (*Storage, error) {
@@ -42,6 +46,56 @@ func NewStorage(config *Config, client Client) (*Storage, error) {
 
 // Write writes migration history data to storage.
 func (s *Storage) Write(ctx context.Context, b []byte) error {
+	_, err := s.client.PutObject(ctx, s.putObjectInput(b))
+
+	return err
+}
+
+// WriteWithVersion writes migration history data to storage only if the ETag
+// of the current object matches a given version.
+// It relies on the conditional write of S3. If the endpoint doesn't support
+// it, it logs a warning and writes data without a version check.
+func (s *Storage) WriteWithVersion(ctx context.Context, b []byte, version string) (string, error) {
+	input := s.putObjectInput(b)
+	var optFns []func(*s3.Options)
+	if version == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		optFns = append(optFns, withIfMatch(version))
+	}
+
+	output, err := s.client.PutObject(ctx, input, optFns...)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return "", storage.ErrVersionConflict
+		}
+		if !isNotImplemented(err) {
+			return "", err
+		}
+
+		// Some S3-compatible endpoints don't support conditional writes.
+		// Fall back to a plain write rather than failing every save.
+		log.Printf("[WARN] [storage] conditional writes are not supported by the s3 endpoint, write without a version check: %s\n", err)
+		output, err = s.client.PutObject(ctx, s.putObjectInput(b))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return aws.ToString(output.ETag), nil
+}
+
+// withIfMatch returns an option of PutObject which sets the If-Match header.
+// The SDK version we use doesn't have an IfMatch field in PutObjectInput yet.
+// So we set the If-Match header via a middleware.
+func withIfMatch(etag string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, smithyhttp.AddHeaderValue("If-Match", etag))
+	}
+}
+
+// putObjectInput returns an input of PutObject for the history key.
+func (s *Storage) putObjectInput(b []byte) *s3.PutObjectInput {
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(s.config.Bucket),
 		Key:    aws.String(s.config.Key),
@@ -51,16 +105,46 @@ func (s *Storage) Write(ctx context.Context, b []byte) error {
 		input.SSEKMSKeyId = &s.config.KmsKeyID
 		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
 	}
+	return input
+}
 
-	_, err := s.client.PutObject(ctx, input)
+// isPreconditionFailed returns true if a given error is caused by a failure of
+// conditional write.
+func isPreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return true
+		}
+	}
+	return false
+}
 
-	return err
+// isNotImplemented returns true if a given error is caused by an endpoint
+// which doesn't support a requested feature such as conditional writes.
+func isNotImplemented(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NotImplemented" {
+		return true
+	}
+	var respErr *smithyhttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotImplemented
 }
 
 // Read reads migration history data from storage.
 // If the key does not exist, it is assumed to be uninitialized and returns
 // an empty array instead of an error.
 func (s *Storage) Read(ctx context.Context) ([]byte, error) {
+	b, _, err := s.ReadWithVersion(ctx)
+	return b, err
+}
+
+// ReadWithVersion reads migration history data from storage with an ETag as
+// a version token.
+// If the key does not exist, it is assumed to be uninitialized and returns
+// an empty array and an empty version instead of an error.
+func (s *Storage) ReadWithVersion(ctx context.Context) ([]byte, string, error) {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.config.Bucket),
 		Key:    aws.String(s.config.Key),
@@ -71,10 +155,10 @@ func (s *Storage) Read(ctx context.Context) ([]byte, error) {
 		var nsk *types.NoSuchKey
 		if errors.As(err, &nsk) {
 			// If the key does not exist
-			return []byte{}, nil
+			return []byte{}, "", nil
 		}
 		// unexpected error
-		return nil, err
+		return nil, "", err
 	}
 
 	defer output.Body.Close()
@@ -82,8 +166,8 @@ func (s *Storage) Read(ctx context.Context) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	_, err = buf.ReadFrom(output.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return buf.Bytes(), nil
+	return buf.Bytes(), aws.ToString(output.ETag), nil
 }
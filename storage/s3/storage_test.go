@@ -1,7 +1,10 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -9,6 +12,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/minamijoyo/tfmigrate/storage"
 )
 
 // mockClient is a mock implementation for testing.
@@ -28,6 +35,97 @@ func (c *mockClient) GetObject(_ context.Context, _ *s3.GetObjectInput, _ ...fun
 	return c.getOutput, c.err
 }
 
+// DeleteObject returns a mocked response.
+func (c *mockClient) DeleteObject(_ context.Context, _ *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return &s3.DeleteObjectOutput{}, c.err
+}
+
+// memClient is an in-memory implementation of Client which emulates
+// conditional writes for testing.
+type memClient struct {
+	objects map[string][]byte
+	etags   map[string]string
+	seq     int
+}
+
+func newMemClient() *memClient {
+	return &memClient{
+		objects: map[string][]byte{},
+		etags:   map[string]string{},
+	}
+}
+
+func (c *memClient) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	key := aws.ToString(input.Key)
+	_, exists := c.objects[key]
+	if aws.ToString(input.IfNoneMatch) == "*" && exists {
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+	}
+	ifMatch, err := ifMatchHeader(ctx, optFns)
+	if err != nil {
+		return nil, err
+	}
+	if ifMatch != "" && ifMatch != c.etags[key] {
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+	}
+
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	c.objects[key] = b
+	c.etags[key] = fmt.Sprintf("\"%d\"", c.seq)
+	return &s3.PutObjectOutput{ETag: aws.String(c.etags[key])}, nil
+}
+
+func (c *memClient) GetObject(_ context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	key := aws.ToString(input.Key)
+	b, ok := c.objects[key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader(b)),
+		ETag: aws.String(c.etags[key]),
+	}, nil
+}
+
+func (c *memClient) DeleteObject(_ context.Context, input *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	key := aws.ToString(input.Key)
+	delete(c.objects, key)
+	delete(c.etags, key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// ifMatchHeader returns a value of the If-Match header set by given options.
+// It builds a request by running the API options through a middleware stack.
+func ifMatchHeader(ctx context.Context, optFns []func(*s3.Options)) (string, error) {
+	o := s3.Options{}
+	for _, fn := range optFns {
+		fn(&o)
+	}
+
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	for _, fn := range o.APIOptions {
+		if err := fn(stack); err != nil {
+			return "", err
+		}
+	}
+
+	var header string
+	handler := middleware.HandlerFunc(func(_ context.Context, in interface{}) (interface{}, middleware.Metadata, error) {
+		if req, ok := in.(*smithyhttp.Request); ok {
+			header = req.Header.Get("If-Match")
+		}
+		return nil, middleware.Metadata{}, nil
+	})
+	if _, _, err := stack.HandleMiddleware(ctx, struct{}{}, handler); err != nil {
+		return "", err
+	}
+	return header, nil
+}
+
 func TestStorageWrite(t *testing.T) {
 	cases := []struct {
 		desc     string
@@ -154,3 +252,100 @@ func TestStorageRead(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageWriteWithVersion(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{
+		Bucket: "tfmigrate-test",
+		Key:    "tfmigrate/history.json",
+	}
+	client := newMemClient()
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	b, version, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(b) != 0 || version != "" {
+		t.Fatalf("expected an empty version for a key which doesn't exist, got: %s", version)
+	}
+
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("failed to create an object: %s", err)
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), ""); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for an existing object, but got: %v", err)
+	}
+
+	// simulate a concurrent update by someone else
+	if err := s.Write(ctx, []byte("baz")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("expected to return ErrVersionConflict for a stale version, but got: %v", err)
+	}
+
+	got, v2, err := s.ReadWithVersion(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if string(got) != "baz" {
+		t.Errorf("got: %s, want: baz", string(got))
+	}
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v2); err != nil {
+		t.Fatalf("failed to write with the latest version: %s", err)
+	}
+	if string(client.objects["tfmigrate/history.json"]) != "bar" {
+		t.Errorf("got: %s, want: bar", string(client.objects["tfmigrate/history.json"]))
+	}
+}
+
+// noConditionalWriteClient is a memClient which emulates an S3-compatible
+// endpoint which doesn't support conditional writes.
+type noConditionalWriteClient struct {
+	*memClient
+}
+
+func (c *noConditionalWriteClient) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	ifMatch, err := ifMatchHeader(ctx, optFns)
+	if err != nil {
+		return nil, err
+	}
+	if input.IfNoneMatch != nil || ifMatch != "" {
+		return nil, &smithy.GenericAPIError{Code: "NotImplemented"}
+	}
+	return c.memClient.PutObject(ctx, input, optFns...)
+}
+
+func TestStorageWriteWithVersionNotImplemented(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{
+		Bucket: "tfmigrate-test",
+		Key:    "tfmigrate/history.json",
+	}
+	client := &noConditionalWriteClient{memClient: newMemClient()}
+	s, err := NewStorage(config, client)
+	if err != nil {
+		t.Fatalf("failed to NewStorage: %s", err)
+	}
+
+	v1, err := s.WriteWithVersion(ctx, []byte("foo"), "")
+	if err != nil {
+		t.Fatalf("failed to fall back to a plain write: %s", err)
+	}
+	if v1 == "" {
+		t.Error("expected to return a version, but got empty")
+	}
+
+	if _, err := s.WriteWithVersion(ctx, []byte("bar"), v1); err != nil {
+		t.Fatalf("failed to fall back to a plain write: %s", err)
+	}
+	if string(client.objects["tfmigrate/history.json"]) != "bar" {
+		t.Errorf("got: %s, want: bar", string(client.objects["tfmigrate/history.json"]))
+	}
+}
@@ -1,6 +1,9 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // Storage is an abstraction layer for migration history data store.
 // As you know, this is the equivalent of Terraform's backend, but we have
@@ -15,3 +18,25 @@ type Storage interface {
 	// an empty array instead of an error.
 	Read(ctx context.Context) ([]byte, error)
 }
+
+// VersionedStorage is an optional interface for Storage which supports
+// compare-and-swap writes. A version token is an opaque string such as an
+// ETag of AWS S3 or a generation of GCS, which changes whenever the data is
+// updated. It allows us to detect that someone else updated the history
+// between read and write instead of silently overwriting it.
+type VersionedStorage interface {
+	Storage
+	// ReadWithVersion reads migration history data with a version token.
+	// If the key does not exist, it returns an empty array and an empty
+	// version token instead of an error.
+	ReadWithVersion(ctx context.Context) ([]byte, string, error)
+	// WriteWithVersion writes migration history data only if the current
+	// version token matches a given one, and returns a new version token.
+	// An empty version token means that the key must not exist.
+	// If the version token doesn't match, it returns ErrVersionConflict.
+	WriteWithVersion(ctx context.Context, b []byte, version string) (string, error)
+}
+
+// ErrVersionConflict is an error which indicates the data has been updated
+// by someone else since it was read.
+var ErrVersionConflict = errors.New("history has been updated by someone else")
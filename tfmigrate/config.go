@@ -15,6 +15,10 @@ type MigrationConfig struct {
 type MigratorConfig interface {
 	// NewMigrator returns a new instance of Migrator.
 	NewMigrator(o *MigratorOption) (Migrator, error)
+
+	// NewRollbackMigrator returns a new instance of Migrator which reverts
+	// the migration. It returns an error if the migration cannot be reverted.
+	NewRollbackMigrator(o *MigratorOption) (Migrator, error)
 }
 
 // MigratorOption customizes a behavior of Migrator.
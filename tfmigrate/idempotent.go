@@ -0,0 +1,173 @@
+package tfmigrate
+
+import "strings"
+
+// wildcardActionApplied returns true if a given action with wildcards matches
+// no address in a list of addresses. On a re-run of an idempotent migration,
+// such an action is treated as already applied instead of failing on its
+// expansion, because the matched resources have already been moved or removed.
+func wildcardActionApplied(action interface{}, stateList []string) bool {
+	switch a := action.(type) {
+	case *StateRmvAction:
+		return !rmvSourceMatches(a.source, stateList)
+	case *MultiStateRmvAction:
+		return !rmvSourceMatches(a.source, stateList)
+	case *StateXrmAction:
+		// An invalid pattern is not treated as applied, so that the expansion
+		// reports the error.
+		addresses, err := a.match(stateList)
+		return err == nil && len(addresses) == 0 && a.expectCount != 0
+	}
+	return false
+}
+
+// rmvSourceMatches returns true if a source pattern of rmv actions matches any
+// address in a list of addresses. An invalid pattern is reported as matched,
+// so that the expansion reports the error.
+func rmvSourceMatches(source string, stateList []string) bool {
+	re, err := compileRmvSource(source)
+	if err != nil {
+		return true
+	}
+	for _, s := range stateList {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressContains returns true if a given address is the parent address or
+// within it. An address of a module or a resource without an index contains
+// resources within it.
+func addressContains(parent string, address string) bool {
+	return address == parent || strings.HasPrefix(address, parent+".") || strings.HasPrefix(address, parent+"[")
+}
+
+// stateAddressExists returns true if a given address exists in a list of
+// addresses returned by terraform state list. An address of a module or a
+// resource without an index also matches resources within it.
+func stateAddressExists(stateList []string, address string) bool {
+	for _, a := range stateList {
+		if addressContains(address, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveStateList splits a list of addresses into ones not contained in a
+// given source and ones moved from the source to a given destination.
+func moveStateList(stateList []string, source string, destination string) (remaining []string, moved []string) {
+	remaining = []string{}
+	moved = []string{}
+	for _, a := range stateList {
+		if addressContains(source, a) {
+			moved = append(moved, destination+a[len(source):])
+		} else {
+			remaining = append(remaining, a)
+		}
+	}
+	return remaining, moved
+}
+
+// updateStateList returns a list of addresses in state after applying a given
+// concrete action, so that we don't need to run terraform state list for each
+// action. It returns false if the effect of the action cannot be computed from
+// a list of addresses.
+func updateStateList(action StateAction, stateList []string) ([]string, bool) {
+	switch a := action.(type) {
+	case *StateMvAction:
+		remaining, moved := moveStateList(stateList, a.source, a.destination)
+		return append(remaining, moved...), true
+
+	case *StateRmAction:
+		remaining := stateList
+		for _, addr := range a.addresses {
+			remaining, _ = moveStateList(remaining, addr, "")
+		}
+		return remaining, true
+
+	case *StateImportAction:
+		return append(append([]string{}, stateList...), a.address), true
+
+	case *StateReplaceProviderAction:
+		return stateList, true
+	}
+
+	if isAssertAction(action) {
+		return stateList, true
+	}
+	return nil, false
+}
+
+// pendingStateAction checks whether a given concrete action has already been
+// applied to a state and splits it into a pending part and an already
+// applied part. Either of them may be nil.
+//   - mv is applied if the source is absent and the destination is present.
+//   - rm is applied for addresses which are absent. An rm action is narrowed
+//     down to the remaining addresses.
+//   - import is applied if the address is present.
+//
+// Other actions are always pending because their effect cannot be detected
+// from a list of addresses.
+func pendingStateAction(action StateAction, stateList []string) (pending StateAction, applied StateAction) {
+	switch a := action.(type) {
+	case *StateMvAction:
+		if !stateAddressExists(stateList, a.source) && stateAddressExists(stateList, a.destination) {
+			return nil, action
+		}
+
+	case *StateRmAction:
+		remaining := []string{}
+		removed := []string{}
+		for _, addr := range a.addresses {
+			if stateAddressExists(stateList, addr) {
+				remaining = append(remaining, addr)
+			} else {
+				removed = append(removed, addr)
+			}
+		}
+		if len(removed) == 0 {
+			return action, nil
+		}
+		if len(remaining) == 0 {
+			return nil, action
+		}
+		return NewStateRmAction(remaining), NewStateRmAction(removed)
+
+	case *StateImportAction:
+		if stateAddressExists(stateList, a.address) {
+			return nil, action
+		}
+	}
+
+	return action, nil
+}
+
+// updateMultiStateList returns lists of addresses in the from and to states
+// after applying a given concrete multi state action. It returns false if the
+// effect of the action cannot be computed from lists of addresses.
+func updateMultiStateList(action MultiStateAction, fromStateList []string, toStateList []string) ([]string, []string, bool) {
+	if isAssertAction(action) {
+		return fromStateList, toStateList, true
+	}
+
+	a, ok := action.(*MultiStateMvAction)
+	if !ok {
+		return nil, nil, false
+	}
+	remaining, moved := moveStateList(fromStateList, a.source, a.destination)
+	return remaining, append(append([]string{}, toStateList...), moved...), true
+}
+
+// multiStateActionApplied returns true if a given concrete multi state action
+// has already been applied, that is, the source is absent in the from state
+// and the destination is present in the to state.
+func multiStateActionApplied(action MultiStateAction, fromStateList []string, toStateList []string) bool {
+	a, ok := action.(*MultiStateMvAction)
+	if !ok {
+		return false
+	}
+	return !stateAddressExists(fromStateList, a.source) && stateAddressExists(toStateList, a.destination)
+}
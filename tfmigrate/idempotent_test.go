@@ -0,0 +1,372 @@
+package tfmigrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateAddressExists(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"null_resource.bar[0]",
+		"module.baz.null_resource.qux",
+	}
+
+	cases := []struct {
+		desc    string
+		address string
+		want    bool
+	}{
+		{
+			desc:    "exact match",
+			address: "null_resource.foo",
+			want:    true,
+		},
+		{
+			desc:    "resource without an index",
+			address: "null_resource.bar",
+			want:    true,
+		},
+		{
+			desc:    "resource with an index",
+			address: "null_resource.bar[0]",
+			want:    true,
+		},
+		{
+			desc:    "resource with another index",
+			address: "null_resource.bar[1]",
+			want:    false,
+		},
+		{
+			desc:    "module",
+			address: "module.baz",
+			want:    true,
+		},
+		{
+			desc:    "prefix of a name",
+			address: "null_resource.fo",
+			want:    false,
+		},
+		{
+			desc:    "not found",
+			address: "null_resource.foo2",
+			want:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := stateAddressExists(stateList, tc.address)
+			if got != tc.want {
+				t.Errorf("got: %t, want: %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPendingStateAction(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"null_resource.bar2",
+		"time_static.qux",
+	}
+
+	cases := []struct {
+		desc        string
+		action      StateAction
+		wantPending StateAction
+		wantApplied StateAction
+	}{
+		{
+			desc:        "mv pending",
+			action:      NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			wantPending: NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			wantApplied: nil,
+		},
+		{
+			desc:        "mv applied",
+			action:      NewStateMvAction("null_resource.bar", "null_resource.bar2"),
+			wantPending: nil,
+			wantApplied: NewStateMvAction("null_resource.bar", "null_resource.bar2"),
+		},
+		{
+			desc:        "mv both absent",
+			action:      NewStateMvAction("null_resource.baz", "null_resource.baz2"),
+			wantPending: NewStateMvAction("null_resource.baz", "null_resource.baz2"),
+			wantApplied: nil,
+		},
+		{
+			desc:        "rm pending",
+			action:      NewStateRmAction([]string{"null_resource.foo"}),
+			wantPending: NewStateRmAction([]string{"null_resource.foo"}),
+			wantApplied: nil,
+		},
+		{
+			desc:        "rm applied",
+			action:      NewStateRmAction([]string{"null_resource.bar", "null_resource.baz"}),
+			wantPending: nil,
+			wantApplied: NewStateRmAction([]string{"null_resource.bar", "null_resource.baz"}),
+		},
+		{
+			desc:        "rm partially applied",
+			action:      NewStateRmAction([]string{"null_resource.foo", "null_resource.baz"}),
+			wantPending: NewStateRmAction([]string{"null_resource.foo"}),
+			wantApplied: NewStateRmAction([]string{"null_resource.baz"}),
+		},
+		{
+			desc:        "import pending",
+			action:      NewStateImportAction("time_static.quux", "2006-01-02T15:04:05Z"),
+			wantPending: NewStateImportAction("time_static.quux", "2006-01-02T15:04:05Z"),
+			wantApplied: nil,
+		},
+		{
+			desc:        "import applied",
+			action:      NewStateImportAction("time_static.qux", "2006-01-02T15:04:05Z"),
+			wantPending: nil,
+			wantApplied: NewStateImportAction("time_static.qux", "2006-01-02T15:04:05Z"),
+		},
+		{
+			desc:        "replace-provider always pending",
+			action:      NewStateReplaceProviderAction("registry.terraform.io/-/null", "registry.terraform.io/hashicorp/null"),
+			wantPending: NewStateReplaceProviderAction("registry.terraform.io/-/null", "registry.terraform.io/hashicorp/null"),
+			wantApplied: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			pending, applied := pendingStateAction(tc.action, stateList)
+			if !reflect.DeepEqual(pending, tc.wantPending) {
+				t.Errorf("got pending: %#v, want: %#v", pending, tc.wantPending)
+			}
+			if !reflect.DeepEqual(applied, tc.wantApplied) {
+				t.Errorf("got applied: %#v, want: %#v", applied, tc.wantApplied)
+			}
+		})
+	}
+}
+
+func TestUpdateStateList(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"null_resource.bar[0]",
+		"null_resource.bar[1]",
+		"module.baz.null_resource.qux",
+	}
+
+	cases := []struct {
+		desc   string
+		action StateAction
+		want   []string
+		ok     bool
+	}{
+		{
+			desc:   "mv",
+			action: NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want: []string{
+				"null_resource.bar[0]",
+				"null_resource.bar[1]",
+				"module.baz.null_resource.qux",
+				"null_resource.foo2",
+			},
+			ok: true,
+		},
+		{
+			desc:   "mv a resource with instances",
+			action: NewStateMvAction("null_resource.bar", "null_resource.bar2"),
+			want: []string{
+				"null_resource.foo",
+				"module.baz.null_resource.qux",
+				"null_resource.bar2[0]",
+				"null_resource.bar2[1]",
+			},
+			ok: true,
+		},
+		{
+			desc:   "mv a module",
+			action: NewStateMvAction("module.baz", "module.baz2"),
+			want: []string{
+				"null_resource.foo",
+				"null_resource.bar[0]",
+				"null_resource.bar[1]",
+				"module.baz2.null_resource.qux",
+			},
+			ok: true,
+		},
+		{
+			desc:   "rm",
+			action: NewStateRmAction([]string{"null_resource.foo", "null_resource.bar[0]"}),
+			want: []string{
+				"null_resource.bar[1]",
+				"module.baz.null_resource.qux",
+			},
+			ok: true,
+		},
+		{
+			desc:   "import",
+			action: NewStateImportAction("time_static.quux", "2006-01-02T15:04:05Z"),
+			want: []string{
+				"null_resource.foo",
+				"null_resource.bar[0]",
+				"null_resource.bar[1]",
+				"module.baz.null_resource.qux",
+				"time_static.quux",
+			},
+			ok: true,
+		},
+		{
+			desc:   "assertion",
+			action: NewStateAssertExistsAction("null_resource.foo"),
+			want:   stateList,
+			ok:     true,
+		},
+		{
+			desc:   "unknown effect",
+			action: NewStateXmvAction("null_resource.*", "null_resource.${1}2"),
+			want:   nil,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, ok := updateStateList(tc.action, stateList)
+			if ok != tc.ok {
+				t.Fatalf("got ok: %t, want: %t", ok, tc.ok)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMultiStateList(t *testing.T) {
+	fromStateList := []string{"null_resource.foo", "null_resource.bar"}
+	toStateList := []string{"null_resource.baz"}
+
+	gotFrom, gotTo, ok := updateMultiStateList(NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"), fromStateList, toStateList)
+	if !ok {
+		t.Fatal("expected to update state lists, but not")
+	}
+	wantFrom := []string{"null_resource.bar"}
+	if !reflect.DeepEqual(gotFrom, wantFrom) {
+		t.Errorf("got from: %#v, want: %#v", gotFrom, wantFrom)
+	}
+	wantTo := []string{"null_resource.baz", "null_resource.foo2"}
+	if !reflect.DeepEqual(gotTo, wantTo) {
+		t.Errorf("got to: %#v, want: %#v", gotTo, wantTo)
+	}
+	if !reflect.DeepEqual(toStateList, []string{"null_resource.baz"}) {
+		t.Errorf("expected not to modify the original list, but got: %#v", toStateList)
+	}
+}
+
+func TestMultiStateActionApplied(t *testing.T) {
+	cases := []struct {
+		desc          string
+		action        MultiStateAction
+		fromStateList []string
+		toStateList   []string
+		want          bool
+	}{
+		{
+			desc:          "pending",
+			action:        NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			fromStateList: []string{"null_resource.foo"},
+			toStateList:   []string{},
+			want:          false,
+		},
+		{
+			desc:          "applied",
+			action:        NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			fromStateList: []string{},
+			toStateList:   []string{"null_resource.foo2"},
+			want:          true,
+		},
+		{
+			desc:          "both absent",
+			action:        NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			fromStateList: []string{},
+			toStateList:   []string{},
+			want:          false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := multiStateActionApplied(tc.action, tc.fromStateList, tc.toStateList)
+			if got != tc.want {
+				t.Errorf("got: %t, want: %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWildcardActionApplied(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo2",
+		"null_resource.bar2",
+	}
+
+	cases := []struct {
+		desc   string
+		action interface{}
+		want   bool
+	}{
+		{
+			desc:   "rmv pending",
+			action: NewStateRmvAction(`null_resource\.(foo|bar)2`, "null_resource.${1}3", false),
+			want:   false,
+		},
+		{
+			desc:   "rmv applied",
+			action: NewStateRmvAction(`null_resource\.(foo|bar)`, "null_resource.${1}2", false),
+			want:   true,
+		},
+		{
+			desc:   "rmv invalid pattern",
+			action: NewStateRmvAction(`null_resource\.(foo`, "null_resource.${1}2", false),
+			want:   false,
+		},
+		{
+			desc:   "multi rmv applied",
+			action: NewMultiStateRmvAction(`null_resource\.(foo|bar)`, "null_resource.${1}2", false),
+			want:   true,
+		},
+		{
+			desc:   "xrm pending",
+			action: NewStateXrmAction([]string{"null_resource.*"}, 2),
+			want:   false,
+		},
+		{
+			desc:   "xrm applied",
+			action: NewStateXrmAction([]string{"time_static.*"}, 1),
+			want:   true,
+		},
+		{
+			desc:   "xrm expects nothing",
+			action: NewStateXrmAction([]string{"time_static.*"}, 0),
+			want:   false,
+		},
+		{
+			desc:   "xrm invalid pattern",
+			action: NewStateXrmAction([]string{"time_static.a**"}, 1),
+			want:   false,
+		},
+		{
+			desc:   "not a wildcard action",
+			action: NewStateRmAction([]string{"null_resource.baz"}),
+			want:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := wildcardActionApplied(tc.action, stateList)
+			if got != tc.want {
+				t.Errorf("got: %t, want: %t", got, tc.want)
+			}
+		})
+	}
+}
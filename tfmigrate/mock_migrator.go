@@ -14,6 +14,8 @@ type MockMigratorConfig struct {
 	PlanError bool `hcl:"plan_error"`
 	// ApplyError is a flag to return an error on Apply().
 	ApplyError bool `hcl:"apply_error"`
+	// RollbackError is a flag to return an error on NewRollbackMigrator().
+	RollbackError bool `hcl:"rollback_error,optional"`
 }
 
 // MockMigratorConfig implements a MigratorConfig.
@@ -24,6 +26,15 @@ func (c *MockMigratorConfig) NewMigrator(_ *MigratorOption) (Migrator, error) {
 	return NewMockMigrator(c.PlanError, c.ApplyError), nil
 }
 
+// NewRollbackMigrator returns a new instance of MockMigrator which reverts the
+// migration.
+func (c *MockMigratorConfig) NewRollbackMigrator(_ *MigratorOption) (Migrator, error) {
+	if c.RollbackError {
+		return nil, fmt.Errorf("failed to NewRollbackMigrator mock migrator: rollbackError = %t", c.RollbackError)
+	}
+	return NewMockMigrator(c.PlanError, c.ApplyError), nil
+}
+
 // MockMigrator implements the Migrator interface for testing.
 // It does nothing, but can return an error.
 type MockMigrator struct {
@@ -41,6 +41,51 @@ func TestMockMigratorConfigNewMigrator(t *testing.T) {
 	}
 }
 
+func TestMockMigratorConfigNewRollbackMigrator(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *MockMigratorConfig
+		o      *MigratorOption
+		ok     bool
+	}{
+		{
+			desc: "valid",
+			config: &MockMigratorConfig{
+				PlanError:     false,
+				ApplyError:    false,
+				RollbackError: false,
+			},
+			o:  nil,
+			ok: true,
+		},
+		{
+			desc: "rollback error",
+			config: &MockMigratorConfig{
+				PlanError:     false,
+				ApplyError:    false,
+				RollbackError: true,
+			},
+			o:  nil,
+			ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewRollbackMigrator(tc.o)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				_ = got.(*MockMigrator)
+			}
+		})
+	}
+}
+
 func TestMockMigratorPlan(t *testing.T) {
 	cases := []struct {
 		desc string
@@ -20,7 +20,11 @@ type MultiStateAction interface {
 // This method is useful to build an action from terraform state command.
 // Valid formats are the following.
 // "mv <source> <destination>"
-// "xmv <source> <destination>"
+// "xmv [-address-glob] <source> <destination>"
+// "rmv [-allow-empty] <source> <destination>"
+// "assert_exists [-state=from|to] <address>"
+// "assert_absent [-state=from|to] <address>"
+// "assert_count [-state=from|to] <pattern> <n>"
 func NewMultiStateActionFromString(cmdStr string) (MultiStateAction, error) {
 	args, err := splitStateAction(cmdStr)
 	if err != nil {
@@ -44,12 +48,35 @@ func NewMultiStateActionFromString(cmdStr string) (MultiStateAction, error) {
 		action = NewMultiStateMvAction(src, dst)
 
 	case "xmv":
-		if len(args) != 3 {
-			return nil, fmt.Errorf("multi state xmv action is invalid: %s", cmdStr)
+		src, dst, addressGlob, err := parseXmvArgs(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("multi state xmv action is invalid: %s, err: %s", cmdStr, err)
 		}
-		src := args[1]
-		dst := args[2]
-		action = NewMultiStateXmvAction(src, dst)
+		xmv := NewMultiStateXmvAction(src, dst)
+		xmv.addressGlob = addressGlob
+		action = xmv
+
+	case "rmv":
+		src, dst, allowEmpty, err := parseRmvArgs(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("multi state rmv action is invalid: %s, err: %s", cmdStr, err)
+		}
+		action = NewMultiStateRmvAction(src, dst, allowEmpty)
+
+	case "assert_exists", "assert_absent", "assert_count":
+		fs := newActionFlagSet(actionType)
+		side := fs.String("state", "from", "a state to be checked, from or to")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, fmt.Errorf("multi state %s action is invalid: %s, err: %s", actionType, cmdStr, err)
+		}
+		if *side != "from" && *side != "to" {
+			return nil, fmt.Errorf("multi state %s action is invalid: %s, err: -state must be from or to", actionType, cmdStr)
+		}
+		assert, err := newStateAssertActionFromArgs(append([]string{actionType}, fs.Args()...))
+		if err != nil {
+			return nil, fmt.Errorf("multi state %s action is invalid: %s, err: %s", actionType, cmdStr, err)
+		}
+		action = NewMultiStateAssertAction(assert, *side == "to")
 
 	default:
 		return nil, fmt.Errorf("unknown multi state action type: %s", cmdStr)
@@ -57,3 +84,49 @@ func NewMultiStateActionFromString(cmdStr string) (MultiStateAction, error) {
 
 	return action, nil
 }
+
+// invertMultiStateAction returns a new MultiStateAction which reverts a given
+// action. Note that the returned action is expected to be applied with the
+// from and to directories swapped. That is, it moves a resource from the
+// original to_dir back to the original from_dir.
+func invertMultiStateAction(action MultiStateAction) (MultiStateAction, error) {
+	switch a := action.(type) {
+	case *MultiStateMvAction:
+		return NewMultiStateMvAction(a.destination, a.source), nil
+
+	case *MultiStateXmvAction:
+		// The destination of xmv can contain placeholders, so we cannot build a
+		// reverse pattern. Only an xmv without wildcards is invertible.
+		if newXmvExpander(NewStateXmvAction(a.source, a.destination)).nrOfWildcards() != 0 {
+			return nil, fmt.Errorf("multi state xmv action with wildcards cannot be inverted automatically, define down actions explicitly: %s", a)
+		}
+		return NewMultiStateMvAction(a.destination, a.source), nil
+
+	case *MultiStateRmvAction:
+		// The destination of rmv is a template, so we cannot build a reverse pattern.
+		return nil, fmt.Errorf("multi state rmv action cannot be inverted automatically, define down actions explicitly: %s", a)
+
+	case *MultiStateAssertAction:
+		// An assertion checks the state at the same point of the reverted
+		// migration. The inverted actions are applied with the from and to
+		// directories swapped, so the side to be checked is also swapped.
+		return NewMultiStateAssertAction(a.assert, !a.to), nil
+
+	default:
+		return nil, fmt.Errorf("multi state action cannot be inverted automatically, define down actions explicitly: %#v", action)
+	}
+}
+
+// invertMultiStateActions returns a list of MultiStateAction which reverts
+// given actions. The order of actions is also reversed.
+func invertMultiStateActions(actions []MultiStateAction) ([]MultiStateAction, error) {
+	inverted := make([]MultiStateAction, 0, len(actions))
+	for i := len(actions) - 1; i >= 0; i-- {
+		action, err := invertMultiStateAction(actions[i])
+		if err != nil {
+			return nil, err
+		}
+		inverted = append(inverted, action)
+	}
+	return inverted, nil
+}
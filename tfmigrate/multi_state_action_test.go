@@ -48,6 +48,22 @@ func TestNewMultiStateActionFromString(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			desc:   "xmv action (address-glob)",
+			cmdStr: "xmv -address-glob **.null_resource.* ${1}null_resource.${2}2",
+			want: &MultiStateXmvAction{
+				source:      "**.null_resource.*",
+				destination: "${1}null_resource.${2}2",
+				addressGlob: true,
+			},
+			ok: true,
+		},
+		{
+			desc:   "xmv action (address-glob with an invalid pattern)",
+			cmdStr: "xmv -address-glob null_**.foo null_resource.$1",
+			want:   nil,
+			ok:     false,
+		},
 		{
 			desc:   "xmv action (no args)",
 			cmdStr: "xmv",
@@ -66,6 +82,95 @@ func TestNewMultiStateActionFromString(t *testing.T) {
 			want:   nil,
 			ok:     false,
 		},
+		{
+			desc:   "rmv action (valid)",
+			cmdStr: `rmv 'null_resource\.(?P<name>\w+)' 'module.foo.null_resource.${name}'`,
+			want: &MultiStateRmvAction{
+				source:      `null_resource\.(?P<name>\w+)`,
+				destination: "module.foo.null_resource.${name}",
+			},
+			ok: true,
+		},
+		{
+			desc:   "rmv action (allow-empty)",
+			cmdStr: `rmv -allow-empty 'null_resource\.(\w+)' 'null_resource.${1}2'`,
+			want: &MultiStateRmvAction{
+				source:      `null_resource\.(\w+)`,
+				destination: "null_resource.${1}2",
+				allowEmpty:  true,
+			},
+			ok: true,
+		},
+		{
+			desc:   "rmv action (1 arg)",
+			cmdStr: "rmv null_resource.foo",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (unknown flag)",
+			cmdStr: "rmv -foo null_resource.foo null_resource.foo2",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (invalid regex)",
+			cmdStr: "rmv 'null_resource.(foo' null_resource.foo2",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (undefined capture group)",
+			cmdStr: `rmv 'null_resource\.(?P<name>\w+)' 'null_resource.${nmae}'`,
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_exists action (default from)",
+			cmdStr: "assert_exists null_resource.foo",
+			want: &MultiStateAssertAction{
+				assert: &StateAssertExistsAction{
+					address: "null_resource.foo",
+				},
+				to: false,
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_absent action (to)",
+			cmdStr: "assert_absent -state=to null_resource.foo",
+			want: &MultiStateAssertAction{
+				assert: &StateAssertAbsentAction{
+					address: "null_resource.foo",
+				},
+				to: true,
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_count action (from)",
+			cmdStr: "assert_count -state=from null_resource.* 2",
+			want: &MultiStateAssertAction{
+				assert: &StateAssertCountAction{
+					pattern: "null_resource.*",
+					count:   2,
+				},
+				to: false,
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_exists action (invalid state)",
+			cmdStr: "assert_exists -state=foo null_resource.foo",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_exists action (no args)",
+			cmdStr: "assert_exists -state=to",
+			want:   nil,
+			ok:     false,
+		},
 		{
 			desc:   "duplicated white spaces",
 			cmdStr: " mv  null_resource.foo    null_resource.foo2 ",
@@ -98,3 +203,86 @@ func TestNewMultiStateActionFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestInvertMultiStateAction(t *testing.T) {
+	cases := []struct {
+		desc   string
+		action MultiStateAction
+		want   MultiStateAction
+		ok     bool
+	}{
+		{
+			desc:   "mv action",
+			action: NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   NewMultiStateMvAction("null_resource.foo2", "null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "xmv action without wildcards",
+			action: NewMultiStateXmvAction("null_resource.foo", "null_resource.foo2"),
+			want:   NewMultiStateMvAction("null_resource.foo2", "null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "xmv action with wildcards",
+			action: NewMultiStateXmvAction("null_resource.*", "null_resource.${1}2"),
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert action",
+			action: NewMultiStateAssertAction(NewStateAssertExistsAction("null_resource.foo"), false),
+			want:   NewMultiStateAssertAction(NewStateAssertExistsAction("null_resource.foo"), true),
+			ok:     true,
+		},
+		{
+			desc:   "rmv action",
+			action: NewMultiStateRmvAction(`null_resource\.(\w+)`, "null_resource.${1}2", false),
+			want:   nil,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := invertMultiStateAction(tc.action)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMultiStateAssertActionString(t *testing.T) {
+	cases := []struct {
+		desc   string
+		action *MultiStateAssertAction
+		want   string
+	}{
+		{
+			desc:   "from",
+			action: NewMultiStateAssertAction(NewStateAssertExistsAction("null_resource.foo"), false),
+			want:   "assert_exists -state=from null_resource.foo",
+		},
+		{
+			desc:   "to",
+			action: NewMultiStateAssertAction(NewStateAssertCountAction("null_resource.*", 2), true),
+			want:   "assert_count -state=to null_resource.* 2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := tc.action.String()
+			if got != tc.want {
+				t.Errorf("got: %s, want: %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package tfmigrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// MultiStateAssertAction implements the MultiStateAction interface.
+// MultiStateAssertAction checks an assertion against either the from or the
+// to state. It shares the implementation of assertions with a single state
+// migration.
+type MultiStateAssertAction struct {
+	// assert is an assertion action for a single state.
+	assert StateAction
+	// to checks the to state instead of the from state.
+	to bool
+}
+
+var _ MultiStateAction = (*MultiStateAssertAction)(nil)
+
+// NewMultiStateAssertAction returns a new MultiStateAssertAction instance.
+// The assert must be an assertion action such as StateAssertExistsAction.
+func NewMultiStateAssertAction(assert StateAction, to bool) *MultiStateAssertAction {
+	return &MultiStateAssertAction{
+		assert: assert,
+		to:     to,
+	}
+}
+
+// MultiStateUpdate checks given two states and returns them as they are.
+func (a *MultiStateAssertAction) MultiStateUpdate(ctx context.Context, fromTf tfexec.TerraformCLI, toTf tfexec.TerraformCLI, fromState *tfexec.State, toState *tfexec.State) (*tfexec.State, *tfexec.State, error) {
+	var err error
+	if a.to {
+		_, err = a.assert.StateUpdate(ctx, toTf, toState)
+	} else {
+		_, err = a.assert.StateUpdate(ctx, fromTf, fromState)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return fromState, toState, nil
+}
+
+// String returns a string representation of the action.
+func (a *MultiStateAssertAction) String() string {
+	side := "from"
+	if a.to {
+		side = "to"
+	}
+	// insert the -state flag after the action type.
+	parts := strings.SplitN(fmt.Sprint(a.assert), " ", 2)
+	return parts[0] + " -state=" + side + " " + parts[1]
+}
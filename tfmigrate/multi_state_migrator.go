@@ -36,6 +36,15 @@ type MultiStateMigratorConfig struct {
 	Force bool `hcl:"force,optional"`
 	// FromTfTarget specifies the target parameter for the from_tf plan.
 	FromTfTarget string `hcl:"from_tf_target,optional"`
+	// Down is a list of multi state action to revert the migration.
+	// The format of action is the same as Actions, but note that down actions
+	// move resources from ToDir back to FromDir.
+	// If not set, the rollback actions are computed by inverting Actions in
+	// reverse order.
+	Down []string `hcl:"down,optional"`
+	// Idempotent controls whether or not to skip actions which have already
+	// been applied to the current states.
+	Idempotent bool `hcl:"idempotent,optional"`
 }
 
 // MultiStateMigratorConfig implements a MigratorConfig.
@@ -48,25 +57,86 @@ func (c *MultiStateMigratorConfig) NewMigrator(o *MigratorOption) (Migrator, err
 	}
 
 	// build actions from config.
-	actions := []MultiStateAction{}
-	for _, cmdStr := range c.Actions {
-		action, err := NewMultiStateActionFromString(cmdStr)
+	actions, err := newMultiStateActionsFromStrings(c.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setDefaultWorkspaces()
+
+	// Pass the FromTfTarget to the migrator instance
+	m := NewMultiStateMigrator(c.FromDir, c.ToDir, c.FromWorkspace, c.ToWorkspace, actions, o, c.Force, c.FromSkipPlan, c.ToSkipPlan, c.FromTfTarget)
+	m.idempotent = c.Idempotent
+	return m, nil
+}
+
+// NewRollbackMigrator returns a new instance of MultiStateMigrator which
+// reverts the migration. The returned migrator moves resources from ToDir back
+// to FromDir, so all settings for the from and to directories are swapped.
+func (c *MultiStateMigratorConfig) NewRollbackMigrator(o *MigratorOption) (Migrator, error) {
+	var actions []MultiStateAction
+	if len(c.Down) > 0 {
+		// use down actions explicitly defined.
+		down, err := newMultiStateActionsFromStrings(c.Down)
+		if err != nil {
+			return nil, err
+		}
+		actions = down
+	} else {
+		if len(c.Actions) == 0 {
+			return nil, fmt.Errorf("failed to NewRollbackMigrator with no actions")
+		}
+
+		up, err := newMultiStateActionsFromStrings(c.Actions)
+		if err != nil {
+			return nil, err
+		}
+
+		actions, err = invertMultiStateActions(up)
 		if err != nil {
 			return nil, err
 		}
-		actions = append(actions, action)
 	}
 
-	// use default workspace if not specified by user
+	c.setDefaultWorkspaces()
+
+	// swap exec paths for the source and destination directories.
+	if o != nil {
+		swapped := *o
+		swapped.SourceExecPath = o.DestinationExecPath
+		swapped.DestinationExecPath = o.SourceExecPath
+		o = &swapped
+	}
+
+	// The FromTfTarget is an address in the original FromDir, so we don't
+	// pass it to the rollback migrator.
+	m := NewMultiStateMigrator(c.ToDir, c.FromDir, c.ToWorkspace, c.FromWorkspace, actions, o, c.Force, c.ToSkipPlan, c.FromSkipPlan, "")
+	m.idempotent = c.Idempotent
+	return m, nil
+}
+
+// setDefaultWorkspaces sets default workspaces if not specified by user.
+func (c *MultiStateMigratorConfig) setDefaultWorkspaces() {
 	if len(c.FromWorkspace) == 0 {
 		c.FromWorkspace = "default"
 	}
 	if len(c.ToWorkspace) == 0 {
 		c.ToWorkspace = "default"
 	}
+}
 
-	// Pass the FromTfTarget to the migrator instance
-	return NewMultiStateMigrator(c.FromDir, c.ToDir, c.FromWorkspace, c.ToWorkspace, actions, o, c.Force, c.FromSkipPlan, c.ToSkipPlan, c.FromTfTarget), nil
+// newMultiStateActionsFromStrings builds a list of MultiStateAction from
+// given strings.
+func newMultiStateActionsFromStrings(cmdStrs []string) ([]MultiStateAction, error) {
+	actions := []MultiStateAction{}
+	for _, cmdStr := range cmdStrs {
+		action, err := NewMultiStateActionFromString(cmdStr)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
 }
 
 // MultiStateMigrator implements the Migrator interface.
@@ -92,9 +162,14 @@ type MultiStateMigrator struct {
 	force bool
 	// Add FromTfTarget to the MultiStateMigrator struct
 	fromTfTarget string
+	// idempotent skips actions which have already been applied.
+	idempotent bool
+	// report is a detailed result of the last plan or apply.
+	report *Report
 }
 
 var _ Migrator = (*MultiStateMigrator)(nil)
+var _ Reporter = (*MultiStateMigrator)(nil)
 
 // NewMultiStateMigrator returns a new MultiStateMigrator instance.
 func NewMultiStateMigrator(fromDir string, toDir string, fromWorkspace string, toWorkspace string,
@@ -140,7 +215,7 @@ func NewMultiStateMigrator(fromDir string, toDir string, fromWorkspace string, t
 // the Migrator interface between a single and multi state migrator.
 func (m *MultiStateMigrator) plan(ctx context.Context) (fromCurrentState *tfexec.State, toCurrentState *tfexec.State, err error) {
 	// setup fromDir.
-	fromCurrentState, fromSwitchBackToRemoteFunc, err := setupWorkDir(ctx, m.fromTf, m.fromWorkspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, false)
+	fromCurrentState, version, fromSwitchBackToRemoteFunc, err := setupWorkDir(ctx, m.fromTf, m.fromWorkspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -150,7 +225,7 @@ func (m *MultiStateMigrator) plan(ctx context.Context) (fromCurrentState *tfexec
 	}()
 
 	// setup toDir.
-	toCurrentState, toSwitchBackToRemoteFunc, err := setupWorkDir(ctx, m.toTf, m.toWorkspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, false)
+	toCurrentState, _, toSwitchBackToRemoteFunc, err := setupWorkDir(ctx, m.toTf, m.toWorkspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -159,17 +234,92 @@ func (m *MultiStateMigrator) plan(ctx context.Context) (fromCurrentState *tfexec
 		err = errors.Join(err, toSwitchBackToRemoteFunc())
 	}()
 
+	report := &Report{
+		TerraformVersion: version,
+		Actions:          []string{},
+		States: []StateReport{
+			{
+				Dir:          m.fromTf.Dir(),
+				Workspace:    m.fromWorkspace,
+				SerialBefore: stateSerial(fromCurrentState),
+			},
+			{
+				Dir:          m.toTf.Dir(),
+				Workspace:    m.toWorkspace,
+				SerialBefore: stateSerial(toCurrentState),
+			},
+		},
+	}
+
 	// computes new states by applying state migration operations to temporary states.
 	log.Printf("[INFO] [migrator] compute new states (%s => %s)\n", m.fromTf.Dir(), m.toTf.Dir())
 	var fromNewState, toNewState *tfexec.State
+	// fromStateList and toStateList are lists of addresses in the current
+	// states for an idempotent migration. They are updated in memory as
+	// actions apply, and fetched again only if they become stale.
+	var fromStateList, toStateList []string
+	stateListStale := true
+	refreshStateLists := func() error {
+		if !stateListStale {
+			return nil
+		}
+		var err error
+		fromStateList, toStateList, err = m.stateLists(ctx, fromCurrentState, toCurrentState)
+		if err != nil {
+			return err
+		}
+		stateListStale = false
+		return nil
+	}
 	for _, action := range m.actions {
-		fromNewState, toNewState, err = action.MultiStateUpdate(ctx, m.fromTf, m.toTf, fromCurrentState, toCurrentState)
+		if m.idempotent {
+			if err := refreshStateLists(); err != nil {
+				return nil, nil, err
+			}
+			if wildcardActionApplied(action, fromStateList) {
+				log.Printf("[INFO] [migrator] skip an action already applied, which matches nothing (%s => %s): %s\n", m.fromTf.Dir(), m.toTf.Dir(), action)
+				report.SkippedActions = append(report.SkippedActions, fmt.Sprint(action))
+				continue
+			}
+		}
+		// expand an action with wildcards to record concrete actions.
+		concreteActions, err := expandMultiStateAction(ctx, m.fromTf, fromCurrentState, action)
 		if err != nil {
 			return nil, nil, err
 		}
-		fromCurrentState = tfexec.NewState(fromNewState.Bytes())
-		toCurrentState = tfexec.NewState(toNewState.Bytes())
+		for _, concreteAction := range concreteActions {
+			if m.idempotent {
+				if err := refreshStateLists(); err != nil {
+					return nil, nil, err
+				}
+				if multiStateActionApplied(concreteAction, fromStateList, toStateList) {
+					log.Printf("[INFO] [migrator] skip an action already applied (%s => %s): %s\n", m.fromTf.Dir(), m.toTf.Dir(), concreteAction)
+					report.SkippedActions = append(report.SkippedActions, fmt.Sprint(concreteAction))
+					continue
+				}
+			}
+			fromNewState, toNewState, err = concreteAction.MultiStateUpdate(ctx, m.fromTf, m.toTf, fromCurrentState, toCurrentState)
+			if err != nil {
+				return nil, nil, err
+			}
+			fromCurrentState = tfexec.NewState(fromNewState.Bytes())
+			toCurrentState = tfexec.NewState(toNewState.Bytes())
+			if !isAssertAction(concreteAction) {
+				report.Actions = append(report.Actions, fmt.Sprint(concreteAction))
+			}
+			if m.idempotent && !stateListStale {
+				var ok bool
+				fromStateList, toStateList, ok = updateMultiStateList(concreteAction, fromStateList, toStateList)
+				stateListStale = !ok
+			}
+		}
 	}
+	if m.idempotent {
+		log.Printf("[INFO] [migrator] skipped %d actions already applied (%s => %s)\n", len(report.SkippedActions), m.fromTf.Dir(), m.toTf.Dir())
+	}
+	report.States[0].SerialAfter = stateSerial(fromCurrentState)
+	report.States[1].SerialAfter = stateSerial(toCurrentState)
+	m.report = report
 
 	// build base plan options
 	basePlanOpts := []string{"-input=false", "-no-color", "-detailed-exitcode"}
@@ -267,6 +417,19 @@ func checkPlan(plan *tfexec.Plan, tf tfexec.TerraformCLI, er error, allowCreate
 	return true, fmt.Sprintf("✅ ACCEPTED: %s state plan has no changes", stateType)
 }
 
+// stateLists returns lists of addresses in the current from and to states.
+func (m *MultiStateMigrator) stateLists(ctx context.Context, fromState *tfexec.State, toState *tfexec.State) ([]string, []string, error) {
+	fromStateList, err := m.fromTf.StateList(ctx, fromState, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	toStateList, err := m.toTf.StateList(ctx, toState, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fromStateList, toStateList, nil
+}
+
 // Plan computes new states by applying multi state migration operations to temporary states.
 // It will fail if terraform plan detects any diffs with at least one new state.
 func (m *MultiStateMigrator) Plan(ctx context.Context) error {
@@ -320,3 +483,8 @@ func (m *MultiStateMigrator) Apply(ctx context.Context) error {
 	log.Printf("[INFO] [migrator] multi state migrator apply success!\n")
 	return nil
 }
+
+// Report returns a detailed result of the last plan or apply.
+func (m *MultiStateMigrator) Report() *Report {
+	return m.report
+}
@@ -231,6 +231,111 @@ func TestMultiStateMigratorConfigNewMigrator(t *testing.T) {
 	}
 }
 
+func TestMultiStateMigratorConfigNewRollbackMigrator(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *MultiStateMigratorConfig
+		o      *MigratorOption
+		want   []MultiStateAction
+		ok     bool
+	}{
+		{
+			desc: "invert actions",
+			config: &MultiStateMigratorConfig{
+				FromDir:       "dir1",
+				ToDir:         "dir2",
+				FromWorkspace: "work1",
+				ToWorkspace:   "work2",
+				Actions: []string{
+					"mv null_resource.foo null_resource.foo2",
+					"mv null_resource.bar null_resource.bar2",
+				},
+			},
+			o: &MigratorOption{
+				SourceExecPath:      "terraform",
+				DestinationExecPath: "tofu",
+			},
+			want: []MultiStateAction{
+				NewMultiStateMvAction("null_resource.bar2", "null_resource.bar"),
+				NewMultiStateMvAction("null_resource.foo2", "null_resource.foo"),
+			},
+			ok: true,
+		},
+		{
+			desc: "not invertible",
+			config: &MultiStateMigratorConfig{
+				FromDir: "dir1",
+				ToDir:   "dir2",
+				Actions: []string{
+					"xmv null_resource.* null_resource.${1}2",
+				},
+			},
+			o:    nil,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "explicit down actions",
+			config: &MultiStateMigratorConfig{
+				FromDir: "dir1",
+				ToDir:   "dir2",
+				Actions: []string{
+					"xmv null_resource.* null_resource.${1}2",
+				},
+				Down: []string{
+					"xmv null_resource.*2 null_resource.$1",
+				},
+			},
+			o: nil,
+			want: []MultiStateAction{
+				NewMultiStateXmvAction("null_resource.*2", "null_resource.$1"),
+			},
+			ok: true,
+		},
+		{
+			desc: "no actions",
+			config: &MultiStateMigratorConfig{
+				FromDir: "dir1",
+				ToDir:   "dir2",
+				Actions: []string{},
+			},
+			o:    nil,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewRollbackMigrator(tc.o)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				m := got.(*MultiStateMigrator)
+				if !reflect.DeepEqual(m.actions, tc.want) {
+					t.Errorf("got: %#v, want: %#v", m.actions, tc.want)
+				}
+				// from and to are swapped.
+				if m.fromTf.Dir() != tc.config.ToDir || m.toTf.Dir() != tc.config.FromDir {
+					t.Errorf("unexpected dirs: from = %s, to = %s", m.fromTf.Dir(), m.toTf.Dir())
+				}
+				if m.fromWorkspace != tc.config.ToWorkspace || m.toWorkspace != tc.config.FromWorkspace {
+					t.Errorf("unexpected workspaces: from = %s, to = %s", m.fromWorkspace, m.toWorkspace)
+				}
+				if tc.o != nil {
+					if m.fromTf.ExecPath() != tc.o.DestinationExecPath || m.toTf.ExecPath() != tc.o.SourceExecPath {
+						t.Errorf("unexpected exec paths: from = %s, to = %s", m.fromTf.ExecPath(), m.toTf.ExecPath())
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestAccMultiStateMigratorApplySimple(t *testing.T) {
 	tfexec.SkipUnlessAcceptanceTestEnabled(t)
 	ctx := context.Background()
@@ -45,3 +45,8 @@ func (a *MultiStateMvAction) MultiStateUpdate(ctx context.Context, fromTf tfexec
 
 	return fromNewState, toNewState, nil
 }
+
+// String returns a string representation of the action.
+func (a *MultiStateMvAction) String() string {
+	return "mv " + a.source + " " + a.destination
+}
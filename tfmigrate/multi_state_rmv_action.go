@@ -0,0 +1,82 @@
+package tfmigrate
+
+import (
+	"context"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// MultiStateRmvAction implements the MultiStateAction interface.
+// MultiStateRmvAction is a regex version of MultiStateXmvAction.
+// It allows you to move multiple resources matching an anchored RE2 pattern
+// and build destinations with capture groups.
+type MultiStateRmvAction struct {
+	// source is a RE2 pattern which matches addresses of resources to be moved.
+	source string
+	// destination is a template of a new address which can refer to capture
+	// groups in the source such as $1 or ${name}.
+	destination string
+	// allowEmpty allows the source pattern to match nothing.
+	allowEmpty bool
+}
+
+var _ MultiStateAction = (*MultiStateRmvAction)(nil)
+
+// NewMultiStateRmvAction returns a new MultiStateRmvAction instance.
+func NewMultiStateRmvAction(source string, destination string, allowEmpty bool) *MultiStateRmvAction {
+	return &MultiStateRmvAction{
+		source:      source,
+		destination: destination,
+		allowEmpty:  allowEmpty,
+	}
+}
+
+// MultiStateUpdate updates given two states and returns new two states.
+// It moves resources from a dir to another.
+// It also can rename addresses of resources.
+func (a *MultiStateRmvAction) MultiStateUpdate(ctx context.Context, fromTf tfexec.TerraformCLI, toTf tfexec.TerraformCLI, fromState *tfexec.State, toState *tfexec.State) (*tfexec.State, *tfexec.State, error) {
+	multiStateMvActions, err := a.generateMvActions(ctx, fromTf, fromState)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, action := range multiStateMvActions {
+		fromState, toState, err = action.MultiStateUpdate(ctx, fromTf, toTf, fromState, toState)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return fromState, toState, nil
+}
+
+// generateMvActions uses an rmv and use the state to determine the corresponding mv actions.
+func (a *MultiStateRmvAction) generateMvActions(ctx context.Context, fromTf tfexec.TerraformCLI, fromState *tfexec.State) ([]*MultiStateMvAction, error) {
+	stateList, err := fromTf.StateList(ctx, fromState, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// share the logic with a single state rmv action.
+	stateRmv := NewStateRmvAction(a.source, a.destination, a.allowEmpty)
+	e := newRmvExpander(stateRmv)
+	stateMvActions, err := e.expand(stateList)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert StateMvAction to MultiStateMvAction.
+	multiStateMvActions := []*MultiStateMvAction{}
+	for _, action := range stateMvActions {
+		multiStateMvActions = append(multiStateMvActions, NewMultiStateMvAction(action.source, action.destination))
+	}
+
+	return multiStateMvActions, nil
+}
+
+// String returns a string representation of the action.
+func (a *MultiStateRmvAction) String() string {
+	if a.allowEmpty {
+		return "rmv -allow-empty " + a.source + " " + a.destination
+	}
+	return "rmv " + a.source + " " + a.destination
+}
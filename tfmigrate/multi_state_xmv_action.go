@@ -14,6 +14,9 @@ type MultiStateXmvAction struct {
 	source string
 	// destination is a new address of resource or module to move which can contain placeholders.
 	destination string
+	// addressGlob enables address-aware glob semantics for wildcards.
+	// See makeAddressGlobRegex for details.
+	addressGlob bool
 }
 
 var _ MultiStateAction = (*MultiStateXmvAction)(nil)
@@ -55,6 +58,7 @@ func (a *MultiStateXmvAction) generateMvActions(ctx context.Context, fromTf tfex
 	// It may look a bit strange as a type.
 	// This is only because sharing the logic while maintaining consistency.
 	stateXmv := NewStateXmvAction(a.source, a.destination)
+	stateXmv.addressGlob = a.addressGlob
 
 	e := newXmvExpander(stateXmv)
 	stateMvActions, err := e.expand(stateList)
@@ -70,3 +74,11 @@ func (a *MultiStateXmvAction) generateMvActions(ctx context.Context, fromTf tfex
 
 	return multiStateMvActions, nil
 }
+
+// String returns a string representation of the action.
+func (a *MultiStateXmvAction) String() string {
+	if a.addressGlob {
+		return "xmv -address-glob " + a.source + " " + a.destination
+	}
+	return "xmv " + a.source + " " + a.destination
+}
@@ -0,0 +1,158 @@
+package tfmigrate
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// Report is a detailed result of a migration.
+// It is recorded to history as an audit log.
+type Report struct {
+	// TerraformVersion is a version of terraform command used for the migration.
+	TerraformVersion string
+	// Actions is a list of concrete actions applied to states.
+	// Actions with wildcards such as xmv are expanded. Assertions and
+	// skipped actions are not included because they don't change states.
+	Actions []string
+	// SkippedActions is a list of concrete actions skipped because they have
+	// already been applied. It is only set for an idempotent migration.
+	SkippedActions []string
+	// States is a list of states touched by the migration.
+	States []StateReport
+}
+
+// StateReport is a detailed result of a state touched by a migration.
+type StateReport struct {
+	// Dir is a working directory of the state.
+	Dir string
+	// Workspace is a workspace of the state.
+	Workspace string
+	// SerialBefore is a serial number of the state before the migration.
+	SerialBefore int64
+	// SerialAfter is a serial number of the new state computed by the migration.
+	SerialAfter int64
+}
+
+// Reporter is an optional interface for Migrator which reports a detailed
+// result of the last plan or apply.
+type Reporter interface {
+	// Report returns a detailed result of the last plan or apply.
+	// It returns nil if neither has been run yet.
+	Report() *Report
+}
+
+// stateSerial returns a serial number of a given state.
+// We don't parse contents of tfstate in general, but the serial is a stable
+// field across state format versions. If it cannot be parsed, returns 0.
+func stateSerial(state *tfexec.State) int64 {
+	var s struct {
+		Serial int64 `json:"serial"`
+	}
+	if err := json.Unmarshal(state.Bytes(), &s); err != nil {
+		log.Printf("[DEBUG] [migrator] failed to parse a serial of state: %s\n", err)
+		return 0
+	}
+	return s.Serial
+}
+
+// isAssertAction returns true if a given action is an assertion, which
+// doesn't change states. Assertions are not recorded to the report as
+// applied actions.
+func isAssertAction(action interface{}) bool {
+	switch action.(type) {
+	case *StateAssertExistsAction, *StateAssertAbsentAction, *StateAssertCountAction, *MultiStateAssertAction:
+		return true
+	default:
+		return false
+	}
+}
+
+// logExpandedActions logs concrete actions expanded from an action with
+// wildcards, so that the plan shows exactly what will be applied.
+func logExpandedActions[T any](dir string, action interface{}, actions []T) {
+	log.Printf("[INFO] [migrator@%s] %s expands to %d actions\n", dir, action, len(actions))
+	for _, a := range actions {
+		log.Printf("[INFO] [migrator@%s]   %v\n", dir, a)
+	}
+}
+
+// expandStateAction expands a given action into a list of concrete actions
+// against a given state. An action with wildcards such as xmv and rmv is
+// expanded into mv actions, and xrm is expanded into an rm action. Other
+// actions are returned as they are.
+// The expansion is logged here once, so that each concrete action is applied
+// without expanding it again.
+func expandStateAction(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State, action StateAction) ([]StateAction, error) {
+	actions := []StateAction{}
+	switch a := action.(type) {
+	case *StateXmvAction:
+		mvActions, err := a.generateMvActions(ctx, tf, state)
+		if err != nil {
+			return nil, err
+		}
+		for _, mv := range mvActions {
+			actions = append(actions, mv)
+		}
+
+	case *StateRmvAction:
+		mvActions, err := a.generateMvActions(ctx, tf, state)
+		if err != nil {
+			return nil, err
+		}
+		for _, mv := range mvActions {
+			actions = append(actions, mv)
+		}
+
+	case *StateXrmAction:
+		rmActions, err := a.generateRmActions(ctx, tf, state)
+		if err != nil {
+			return nil, err
+		}
+		for _, rm := range rmActions {
+			actions = append(actions, rm)
+		}
+
+	default:
+		return []StateAction{action}, nil
+	}
+
+	logExpandedActions(tf.Dir(), action, actions)
+	return actions, nil
+}
+
+// expandMultiStateAction expands a given action into a list of concrete
+// actions against a given state. An action with wildcards such as xmv and rmv
+// is expanded into mv actions. Other actions are returned as they are.
+// The expansion is logged here once, so that each concrete action is applied
+// without expanding it again.
+func expandMultiStateAction(ctx context.Context, fromTf tfexec.TerraformCLI, fromState *tfexec.State, action MultiStateAction) ([]MultiStateAction, error) {
+	actions := []MultiStateAction{}
+	switch a := action.(type) {
+	case *MultiStateXmvAction:
+		mvActions, err := a.generateMvActions(ctx, fromTf, fromState)
+		if err != nil {
+			return nil, err
+		}
+		for _, mv := range mvActions {
+			actions = append(actions, mv)
+		}
+
+	case *MultiStateRmvAction:
+		mvActions, err := a.generateMvActions(ctx, fromTf, fromState)
+		if err != nil {
+			return nil, err
+		}
+		for _, mv := range mvActions {
+			actions = append(actions, mv)
+		}
+
+	default:
+		return []MultiStateAction{action}, nil
+	}
+
+	logExpandedActions(fromTf.Dir(), action, actions)
+	return actions, nil
+}
@@ -0,0 +1,142 @@
+package tfmigrate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+func TestStateSerial(t *testing.T) {
+	cases := []struct {
+		desc  string
+		state *tfexec.State
+		want  int64
+	}{
+		{
+			desc:  "simple",
+			state: tfexec.NewState([]byte(`{"version": 4, "serial": 12, "lineage": "foo"}`)),
+			want:  12,
+		},
+		{
+			desc:  "no serial",
+			state: tfexec.NewState([]byte(`{"version": 4}`)),
+			want:  0,
+		},
+		{
+			desc:  "empty",
+			state: tfexec.NewState([]byte(``)),
+			want:  0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := stateSerial(tc.state)
+			if got != tc.want {
+				t.Errorf("got = %d, want = %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestActionString(t *testing.T) {
+	cases := []struct {
+		desc   string
+		action interface{}
+		want   string
+	}{
+		{
+			desc:   "state mv",
+			action: NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   "mv null_resource.foo null_resource.foo2",
+		},
+		{
+			desc:   "state xmv",
+			action: NewStateXmvAction("null_resource.*", "module.foo.null_resource.$1"),
+			want:   "xmv null_resource.* module.foo.null_resource.$1",
+		},
+		{
+			desc:   "state rm",
+			action: NewStateRmAction([]string{"null_resource.foo", "null_resource.bar"}),
+			want:   "rm null_resource.foo null_resource.bar",
+		},
+		{
+			desc:   "state import",
+			action: NewStateImportAction("time_static.foo", "2006-01-02T15:04:05Z"),
+			want:   "import time_static.foo 2006-01-02T15:04:05Z",
+		},
+		{
+			desc:   "state replace-provider",
+			action: NewStateReplaceProviderAction("registry.terraform.io/-/null", "registry.terraform.io/hashicorp/null"),
+			want:   "replace-provider registry.terraform.io/-/null registry.terraform.io/hashicorp/null",
+		},
+		{
+			desc:   "multi state mv",
+			action: NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   "mv null_resource.foo null_resource.foo2",
+		},
+		{
+			desc:   "multi state xmv",
+			action: NewMultiStateXmvAction("null_resource.*", "null_resource.$1"),
+			want:   "xmv null_resource.* null_resource.$1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := fmt.Sprint(tc.action)
+			if got != tc.want {
+				t.Errorf("got = %s, want = %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsAssertAction(t *testing.T) {
+	cases := []struct {
+		desc   string
+		action interface{}
+		want   bool
+	}{
+		{
+			desc:   "state mv",
+			action: NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   false,
+		},
+		{
+			desc:   "state assert_exists",
+			action: NewStateAssertExistsAction("null_resource.foo"),
+			want:   true,
+		},
+		{
+			desc:   "state assert_absent",
+			action: NewStateAssertAbsentAction("null_resource.foo"),
+			want:   true,
+		},
+		{
+			desc:   "state assert_count",
+			action: NewStateAssertCountAction("null_resource.*", 1),
+			want:   true,
+		},
+		{
+			desc:   "multi state mv",
+			action: NewMultiStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   false,
+		},
+		{
+			desc:   "multi state assert",
+			action: NewMultiStateAssertAction(NewStateAssertExistsAction("null_resource.foo"), false),
+			want:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := isAssertAction(tc.action)
+			if got != tc.want {
+				t.Errorf("got = %t, want = %t", got, tc.want)
+			}
+		})
+	}
+}
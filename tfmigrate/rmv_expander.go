@@ -0,0 +1,109 @@
+package tfmigrate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// rmvExpander is a helper object for implementing regex expansion for rmv actions.
+type rmvExpander struct {
+	// rmv action to be expanded
+	action *StateRmvAction
+}
+
+// newRmvExpander returns a new rmvExpander instance.
+func newRmvExpander(action *StateRmvAction) *rmvExpander {
+	return &rmvExpander{
+		action: action,
+	}
+}
+
+// rmvTemplateRefRegex matches references to capture groups in a destination
+// template, that is, $name, ${name} and an escaped dollar sign $$.
+var rmvTemplateRefRegex = regexp.MustCompile(`\$\$|\$\{(\w+)\}|\$(\w+)`)
+
+// compileRmvSource returns a regex for a source pattern of rmv actions.
+// Unlike xmv, the pattern is a RE2 syntax as it is and anchored to match
+// a whole address, not a substring of it.
+func compileRmvSource(source string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(`^(?:` + source + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile rmv source pattern %s due to %s", source, err)
+	}
+	return re, nil
+}
+
+// validateRmvDestination checks all references to capture groups in a
+// destination template are defined in a given source regex.
+// Note that regexp.Expand silently replaces an undefined reference with an
+// empty string, which results in an unexpected address.
+func validateRmvDestination(re *regexp.Regexp, destination string) error {
+	for _, m := range rmvTemplateRefRegex.FindAllStringSubmatch(destination, -1) {
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		if name == "" {
+			// escaped dollar sign.
+			continue
+		}
+
+		if n, err := strconv.Atoi(name); err == nil {
+			if n > re.NumSubexp() {
+				return fmt.Errorf("rmv destination %s refers to an undefined capture group: %s", destination, name)
+			}
+			continue
+		}
+
+		if re.SubexpIndex(name) == -1 {
+			return fmt.Errorf("rmv destination %s refers to an undefined capture group: %s", destination, name)
+		}
+	}
+	return nil
+}
+
+// validate checks the source pattern and the destination template.
+func (e *rmvExpander) validate() (*regexp.Regexp, error) {
+	re, err := compileRmvSource(e.action.source)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRmvDestination(re, e.action.destination); err != nil {
+		return nil, err
+	}
+	return re, nil
+}
+
+// expand returns mv actions for addresses in the list of resources which
+// match the source pattern. The destination is built by expanding capture
+// groups in the destination template. It returns an error if nothing matches
+// unless allowEmpty is set, or if multiple sources are moved to the same
+// destination.
+func (e *rmvExpander) expand(stateList []string) ([]*StateMvAction, error) {
+	re, err := e.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingActions := []*StateMvAction{}
+	destinations := make(map[string]string)
+	for _, s := range stateList {
+		match := re.FindStringSubmatchIndex(s)
+		if match == nil {
+			continue
+		}
+		destination := string(re.ExpandString(nil, e.action.destination, s, match))
+		if other, ok := destinations[destination]; ok {
+			return nil, fmt.Errorf("rmv action moves multiple resources to the same destination %s: %s, %s", destination, other, s)
+		}
+		destinations[destination] = s
+		matchingActions = append(matchingActions, NewStateMvAction(s, destination))
+	}
+
+	if len(matchingActions) == 0 && !e.action.allowEmpty {
+		return nil, fmt.Errorf("rmv source pattern matches nothing, set -allow-empty if intended: %s", e.action.source)
+	}
+
+	return matchingActions, nil
+}
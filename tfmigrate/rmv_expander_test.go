@@ -0,0 +1,122 @@
+package tfmigrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRmvExpanderExpand(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"null_resource.bar",
+		"module.baz.null_resource.foo",
+		`aws_instance.web["a"]`,
+		`aws_instance.web["b"]`,
+	}
+
+	cases := []struct {
+		desc      string
+		action    *StateRmvAction
+		stateList []string
+		want      []*StateMvAction
+		ok        bool
+	}{
+		{
+			desc:      "named groups",
+			action:    NewStateRmvAction(`null_resource\.(?P<name>\w+)`, "module.qux.null_resource.${name}", false),
+			stateList: stateList,
+			want: []*StateMvAction{
+				NewStateMvAction("null_resource.foo", "module.qux.null_resource.foo"),
+				NewStateMvAction("null_resource.bar", "module.qux.null_resource.bar"),
+			},
+			ok: true,
+		},
+		{
+			desc:      "numbered groups",
+			action:    NewStateRmvAction(`module\.baz\.(.+)`, "$1", false),
+			stateList: []string{"module.baz.null_resource.foo"},
+			want: []*StateMvAction{
+				NewStateMvAction("module.baz.null_resource.foo", "null_resource.foo"),
+			},
+			ok: true,
+		},
+		{
+			desc:      "anchored",
+			action:    NewStateRmvAction(`null_resource\.foo`, "null_resource.foo2", false),
+			stateList: stateList,
+			want: []*StateMvAction{
+				NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			},
+			ok: true,
+		},
+		{
+			desc:      "instance keys",
+			action:    NewStateRmvAction(`aws_instance\.web\["(?P<key>\w+)"\]`, `aws_instance.app["${key}"]`, false),
+			stateList: stateList,
+			want: []*StateMvAction{
+				NewStateMvAction(`aws_instance.web["a"]`, `aws_instance.app["a"]`),
+				NewStateMvAction(`aws_instance.web["b"]`, `aws_instance.app["b"]`),
+			},
+			ok: true,
+		},
+		{
+			desc:      "matches nothing",
+			action:    NewStateRmvAction(`time_static\..+`, "time_static.foo", false),
+			stateList: stateList,
+			want:      nil,
+			ok:        false,
+		},
+		{
+			desc:      "matches nothing with allow-empty",
+			action:    NewStateRmvAction(`time_static\..+`, "time_static.foo", true),
+			stateList: stateList,
+			want:      []*StateMvAction{},
+			ok:        true,
+		},
+		{
+			desc:      "same destination",
+			action:    NewStateRmvAction(`null_resource\.\w+`, "null_resource.foo2", false),
+			stateList: stateList,
+			want:      nil,
+			ok:        false,
+		},
+		{
+			desc:      "undefined named group",
+			action:    NewStateRmvAction(`null_resource\.(?P<name>\w+)`, "null_resource.${nmae}", false),
+			stateList: stateList,
+			want:      nil,
+			ok:        false,
+		},
+		{
+			desc:      "undefined numbered group",
+			action:    NewStateRmvAction(`null_resource\.(\w+)`, "null_resource.${2}", false),
+			stateList: stateList,
+			want:      nil,
+			ok:        false,
+		},
+		{
+			desc:      "escaped dollar sign",
+			action:    NewStateRmvAction(`null_resource\.foo`, "null_resource.$$foo", false),
+			stateList: stateList,
+			want: []*StateMvAction{
+				NewStateMvAction("null_resource.foo", "null_resource.$foo"),
+			},
+			ok: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := newRmvExpander(tc.action).expand(tc.stateList)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
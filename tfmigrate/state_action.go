@@ -2,7 +2,10 @@ package tfmigrate
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/mattn/go-shellwords"
 	"github.com/minamijoyo/tfmigrate/tfexec"
@@ -22,7 +25,12 @@ type StateAction interface {
 // "mv <source> <destination>"
 // "rm <addresses>...
 // "import <address> <id>"
-// "xmv <source> <destination>"
+// "xmv [-address-glob] <source> <destination>"
+// "rmv [-allow-empty] <source> <destination>"
+// "xrm -expect-count=<n> <patterns>..."
+// "assert_exists <address>"
+// "assert_absent <address>"
+// "assert_count <pattern> <n>"
 func NewStateActionFromString(cmdStr string) (StateAction, error) {
 	args, err := splitStateAction(cmdStr)
 	if err != nil {
@@ -54,12 +62,20 @@ func NewStateActionFromString(cmdStr string) (StateAction, error) {
 		action = NewStateReplaceProviderAction(src, dst)
 
 	case "xmv":
-		if len(args) != 3 {
-			return nil, fmt.Errorf("state xmv action is invalid: %s", cmdStr)
+		src, dst, addressGlob, err := parseXmvArgs(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("state xmv action is invalid: %s, err: %s", cmdStr, err)
 		}
-		src := args[1]
-		dst := args[2]
-		action = NewStateXmvAction(src, dst)
+		xmv := NewStateXmvAction(src, dst)
+		xmv.addressGlob = addressGlob
+		action = xmv
+
+	case "rmv":
+		src, dst, allowEmpty, err := parseRmvArgs(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("state rmv action is invalid: %s, err: %s", cmdStr, err)
+		}
+		action = NewStateRmvAction(src, dst, allowEmpty)
 
 	case "rm":
 		if len(args) < 2 {
@@ -68,6 +84,13 @@ func NewStateActionFromString(cmdStr string) (StateAction, error) {
 		addrs := args[1:]
 		action = NewStateRmAction(addrs)
 
+	case "xrm":
+		patterns, expectCount, err := parseXrmArgs(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("state xrm action is invalid: %s, err: %s", cmdStr, err)
+		}
+		action = NewStateXrmAction(patterns, expectCount)
+
 	case "import":
 		if len(args) != 3 {
 			return nil, fmt.Errorf("state import action is invalid: %s", cmdStr)
@@ -76,6 +99,12 @@ func NewStateActionFromString(cmdStr string) (StateAction, error) {
 		id := args[2]
 		action = NewStateImportAction(addr, id)
 
+	case "assert_exists", "assert_absent", "assert_count":
+		action, err = newStateAssertActionFromArgs(args)
+		if err != nil {
+			return nil, fmt.Errorf("state %s action is invalid: %s, err: %s", actionType, cmdStr, err)
+		}
+
 	default:
 		return nil, fmt.Errorf("unknown state action type: %s", cmdStr)
 	}
@@ -88,3 +117,149 @@ func splitStateAction(cmdStr string) ([]string, error) {
 	// Note that we cannot simply split it by space because the address of resource can contain spaces.
 	return shellwords.Parse(cmdStr)
 }
+
+// newActionFlagSet returns a new flag.FlagSet for parsing options of an action.
+// Options must precede positional arguments like terraform state commands.
+func newActionFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseXmvArgs parses arguments of an xmv action.
+// It is shared by single and multi state xmv actions.
+func parseXmvArgs(args []string) (source string, destination string, addressGlob bool, err error) {
+	fs := newActionFlagSet("xmv")
+	fs.BoolVar(&addressGlob, "address-glob", false, "use address-aware glob semantics")
+	if err := fs.Parse(args); err != nil {
+		return "", "", false, err
+	}
+
+	if fs.NArg() != 2 {
+		return "", "", false, fmt.Errorf("expected 2 arguments, but got %d", fs.NArg())
+	}
+	source = fs.Arg(0)
+	destination = fs.Arg(1)
+
+	if addressGlob {
+		// validate the pattern early to fail before running any terraform command.
+		if _, err := makeAddressGlobRegex(source); err != nil {
+			return "", "", false, err
+		}
+	}
+
+	return source, destination, addressGlob, nil
+}
+
+// parseRmvArgs parses arguments of an rmv action and validates the source
+// pattern and the destination template.
+// It is shared by single and multi state rmv actions.
+func parseRmvArgs(args []string) (source string, destination string, allowEmpty bool, err error) {
+	fs := newActionFlagSet("rmv")
+	fs.BoolVar(&allowEmpty, "allow-empty", false, "allow the source pattern to match nothing")
+	if err := fs.Parse(args); err != nil {
+		return "", "", false, err
+	}
+
+	if fs.NArg() != 2 {
+		return "", "", false, fmt.Errorf("expected 2 arguments, but got %d", fs.NArg())
+	}
+	source = fs.Arg(0)
+	destination = fs.Arg(1)
+
+	// validate the pattern early to fail before running any terraform command.
+	re, err := compileRmvSource(source)
+	if err != nil {
+		return "", "", false, err
+	}
+	if err := validateRmvDestination(re, destination); err != nil {
+		return "", "", false, err
+	}
+
+	return source, destination, allowEmpty, nil
+}
+
+// parseXrmArgs parses arguments of an xrm action and validates the patterns.
+// The -expect-count flag is required to guard against over-broad patterns.
+func parseXrmArgs(args []string) (patterns []string, expectCount int, err error) {
+	fs := newActionFlagSet("xrm")
+	fs.IntVar(&expectCount, "expect-count", -1, "the expected number of addresses to be removed")
+	if err := fs.Parse(args); err != nil {
+		return nil, 0, err
+	}
+
+	if expectCount < 0 {
+		return nil, 0, fmt.Errorf("-expect-count is required and must be a non-negative number")
+	}
+	if fs.NArg() == 0 {
+		return nil, 0, fmt.Errorf("expected at least 1 pattern")
+	}
+	patterns = fs.Args()
+
+	// validate the patterns early to fail before running any terraform command.
+	for _, p := range patterns {
+		if _, err := makeAddressGlobRegex(p); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return patterns, expectCount, nil
+}
+
+// invertStateAction returns a new StateAction which reverts a given action.
+// Only actions which don't lose any information can be inverted automatically.
+// That is, rm and import actions cannot be inverted, because we don't know
+// the original resource to be restored. In this case, a migration file needs
+// to define down actions explicitly.
+func invertStateAction(action StateAction) (StateAction, error) {
+	switch a := action.(type) {
+	case *StateMvAction:
+		return NewStateMvAction(a.destination, a.source), nil
+
+	case *StateReplaceProviderAction:
+		return NewStateReplaceProviderAction(a.destination, a.source), nil
+
+	case *StateXmvAction:
+		// The destination of xmv can contain placeholders, so we cannot build a
+		// reverse pattern. Only an xmv without wildcards is invertible.
+		if newXmvExpander(a).nrOfWildcards() != 0 {
+			return nil, fmt.Errorf("state xmv action with wildcards cannot be inverted automatically, define down actions explicitly: %s", a)
+		}
+		return NewStateMvAction(a.destination, a.source), nil
+
+	case *StateRmvAction:
+		// The destination of rmv is a template, so we cannot build a reverse pattern.
+		return nil, fmt.Errorf("state rmv action cannot be inverted automatically, define down actions explicitly: %s", a)
+
+	case *StateRmAction:
+		return nil, fmt.Errorf("state rm action cannot be inverted automatically, define down actions explicitly: rm %s", strings.Join(a.addresses, " "))
+
+	case *StateXrmAction:
+		return nil, fmt.Errorf("state xrm action cannot be inverted automatically, define down actions explicitly: %s", a)
+
+	case *StateAssertExistsAction, *StateAssertAbsentAction, *StateAssertCountAction:
+		// An assertion checks the state at the same point of the reverted
+		// migration, so it is returned as it is.
+		return action, nil
+
+	case *StateImportAction:
+		return nil, fmt.Errorf("state import action cannot be inverted automatically, define down actions explicitly: import %s %s", a.address, a.id)
+
+	default:
+		return nil, fmt.Errorf("state action cannot be inverted automatically, define down actions explicitly: %#v", action)
+	}
+}
+
+// invertStateActions returns a list of StateAction which reverts given actions.
+// The order of actions is also reversed.
+func invertStateActions(actions []StateAction) ([]StateAction, error) {
+	inverted := make([]StateAction, 0, len(actions))
+	for i := len(actions) - 1; i >= 0; i-- {
+		action, err := invertStateAction(actions[i])
+		if err != nil {
+			return nil, err
+		}
+		inverted = append(inverted, action)
+	}
+	return inverted, nil
+}
@@ -75,6 +75,22 @@ func TestNewStateActionFromString(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			desc:   "xmv action (address-glob)",
+			cmdStr: "xmv -address-glob **.null_resource.* ${1}null_resource.${2}2",
+			want: &StateXmvAction{
+				source:      "**.null_resource.*",
+				destination: "${1}null_resource.${2}2",
+				addressGlob: true,
+			},
+			ok: true,
+		},
+		{
+			desc:   "xmv action (address-glob with an invalid pattern)",
+			cmdStr: "xmv -address-glob null_**.foo null_resource.$1",
+			want:   nil,
+			ok:     false,
+		},
 		{
 			desc:   "xmv action (no args)",
 			cmdStr: "xmv",
@@ -93,6 +109,140 @@ func TestNewStateActionFromString(t *testing.T) {
 			want:   nil,
 			ok:     false,
 		},
+		{
+			desc:   "rmv action (valid)",
+			cmdStr: `rmv 'null_resource\.(?P<name>\w+)' 'module.foo.null_resource.${name}'`,
+			want: &StateRmvAction{
+				source:      `null_resource\.(?P<name>\w+)`,
+				destination: "module.foo.null_resource.${name}",
+			},
+			ok: true,
+		},
+		{
+			desc:   "rmv action (allow-empty)",
+			cmdStr: `rmv -allow-empty 'null_resource\.(\w+)' 'null_resource.${1}2'`,
+			want: &StateRmvAction{
+				source:      `null_resource\.(\w+)`,
+				destination: "null_resource.${1}2",
+				allowEmpty:  true,
+			},
+			ok: true,
+		},
+		{
+			desc:   "rmv action (1 arg)",
+			cmdStr: "rmv null_resource.foo",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (unknown flag)",
+			cmdStr: "rmv -foo null_resource.foo null_resource.foo2",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (invalid regex)",
+			cmdStr: "rmv 'null_resource.(foo' null_resource.foo2",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action (undefined capture group)",
+			cmdStr: `rmv 'null_resource\.(?P<name>\w+)' 'null_resource.${nmae}'`,
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "xrm action (valid)",
+			cmdStr: "xrm -expect-count=2 module.legacy.kubernetes_manifest.* module.legacy.kubernetes_manifest.*[*]",
+			want: &StateXrmAction{
+				patterns:    []string{"module.legacy.kubernetes_manifest.*", "module.legacy.kubernetes_manifest.*[*]"},
+				expectCount: 2,
+			},
+			ok: true,
+		},
+		{
+			desc:   "xrm action (zero count)",
+			cmdStr: "xrm -expect-count=0 null_resource.*",
+			want: &StateXrmAction{
+				patterns:    []string{"null_resource.*"},
+				expectCount: 0,
+			},
+			ok: true,
+		},
+		{
+			desc:   "xrm action (without expect-count)",
+			cmdStr: "xrm null_resource.*",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "xrm action (no patterns)",
+			cmdStr: "xrm -expect-count=1",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "xrm action (invalid pattern)",
+			cmdStr: "xrm -expect-count=1 null_**.foo",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_exists action (valid)",
+			cmdStr: "assert_exists null_resource.foo",
+			want: &StateAssertExistsAction{
+				address: "null_resource.foo",
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_exists action (no args)",
+			cmdStr: "assert_exists",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_absent action (valid)",
+			cmdStr: "assert_absent null_resource.foo",
+			want: &StateAssertAbsentAction{
+				address: "null_resource.foo",
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_absent action (2 args)",
+			cmdStr: "assert_absent null_resource.foo null_resource.bar",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_count action (valid)",
+			cmdStr: "assert_count null_resource.* 3",
+			want: &StateAssertCountAction{
+				pattern: "null_resource.*",
+				count:   3,
+			},
+			ok: true,
+		},
+		{
+			desc:   "assert_count action (invalid count)",
+			cmdStr: "assert_count null_resource.* foo",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_count action (negative count)",
+			cmdStr: "assert_count null_resource.* -1",
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_count action (1 arg)",
+			cmdStr: "assert_count null_resource.*",
+			want:   nil,
+			ok:     false,
+		},
 		{
 			desc:   "rm action (valid)",
 			cmdStr: "rm time_static.foo",
@@ -232,3 +382,101 @@ func TestSplitStateAction(t *testing.T) {
 		})
 	}
 }
+
+func TestInvertStateAction(t *testing.T) {
+	cases := []struct {
+		desc   string
+		action StateAction
+		want   StateAction
+		ok     bool
+	}{
+		{
+			desc:   "mv action",
+			action: NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+			want:   NewStateMvAction("null_resource.foo2", "null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "replace-provider action",
+			action: NewStateReplaceProviderAction("registry.terraform.io/-/null", "registry.terraform.io/hashicorp/null"),
+			want:   NewStateReplaceProviderAction("registry.terraform.io/hashicorp/null", "registry.terraform.io/-/null"),
+			ok:     true,
+		},
+		{
+			desc:   "xmv action without wildcards",
+			action: NewStateXmvAction("null_resource.foo", "null_resource.foo2"),
+			want:   NewStateMvAction("null_resource.foo2", "null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "xmv action with wildcards",
+			action: NewStateXmvAction("null_resource.*", "null_resource.${1}2"),
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rmv action",
+			action: NewStateRmvAction(`null_resource\.(\w+)`, "null_resource.${1}2", false),
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "rm action",
+			action: NewStateRmAction([]string{"null_resource.foo"}),
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "xrm action",
+			action: NewStateXrmAction([]string{"null_resource.*"}, 1),
+			want:   nil,
+			ok:     false,
+		},
+		{
+			desc:   "assert_exists action",
+			action: NewStateAssertExistsAction("null_resource.foo"),
+			want:   NewStateAssertExistsAction("null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "import action",
+			action: NewStateImportAction("time_static.foo", "2006-01-02T15:04:05Z"),
+			want:   nil,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := invertStateAction(tc.action)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvertStateActions(t *testing.T) {
+	actions := []StateAction{
+		NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+		NewStateMvAction("null_resource.foo2", "null_resource.foo3"),
+	}
+	want := []StateAction{
+		NewStateMvAction("null_resource.foo3", "null_resource.foo2"),
+		NewStateMvAction("null_resource.foo2", "null_resource.foo"),
+	}
+
+	got, err := invertStateActions(actions)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %#v, want: %#v", got, want)
+	}
+}
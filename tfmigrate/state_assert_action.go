@@ -0,0 +1,200 @@
+package tfmigrate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// StateAssertExistsAction implements the StateAction interface.
+// StateAssertExistsAction asserts an address exists in the current state.
+// It doesn't change the state, but fails the migration before any terraform
+// plan if the assertion is not satisfied.
+type StateAssertExistsAction struct {
+	// address is an address of resource or module expected to exist.
+	address string
+}
+
+var _ StateAction = (*StateAssertExistsAction)(nil)
+
+// NewStateAssertExistsAction returns a new StateAssertExistsAction instance.
+func NewStateAssertExistsAction(address string) *StateAssertExistsAction {
+	return &StateAssertExistsAction{
+		address: address,
+	}
+}
+
+// StateUpdate checks a given state and returns it as it is.
+func (a *StateAssertExistsAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) (*tfexec.State, error) {
+	stateList, err := tf.StateList(ctx, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.check(stateList); err != nil {
+		return nil, fmt.Errorf("assertion failed in %s: %s", tf.Dir(), err)
+	}
+	return state, nil
+}
+
+// check checks the assertion against a given list of addresses in state.
+func (a *StateAssertExistsAction) check(stateList []string) error {
+	if !stateAddressExists(stateList, a.address) {
+		return fmt.Errorf("%s: expected to exist, but not found", a)
+	}
+	return nil
+}
+
+// String returns a string representation of the action.
+func (a *StateAssertExistsAction) String() string {
+	return "assert_exists " + a.address
+}
+
+// StateAssertAbsentAction implements the StateAction interface.
+// StateAssertAbsentAction asserts an address doesn't exist in the current state.
+// It doesn't change the state, but fails the migration before any terraform
+// plan if the assertion is not satisfied.
+type StateAssertAbsentAction struct {
+	// address is an address of resource or module expected to be absent.
+	address string
+}
+
+var _ StateAction = (*StateAssertAbsentAction)(nil)
+
+// NewStateAssertAbsentAction returns a new StateAssertAbsentAction instance.
+func NewStateAssertAbsentAction(address string) *StateAssertAbsentAction {
+	return &StateAssertAbsentAction{
+		address: address,
+	}
+}
+
+// StateUpdate checks a given state and returns it as it is.
+func (a *StateAssertAbsentAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) (*tfexec.State, error) {
+	stateList, err := tf.StateList(ctx, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.check(stateList); err != nil {
+		return nil, fmt.Errorf("assertion failed in %s: %s", tf.Dir(), err)
+	}
+	return state, nil
+}
+
+// check checks the assertion against a given list of addresses in state.
+func (a *StateAssertAbsentAction) check(stateList []string) error {
+	found := []string{}
+	for _, s := range stateList {
+		if stateAddressExists([]string{s}, a.address) {
+			found = append(found, s)
+		}
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("%s: expected to be absent, but found: %s", a, strings.Join(found, ", "))
+	}
+	return nil
+}
+
+// String returns a string representation of the action.
+func (a *StateAssertAbsentAction) String() string {
+	return "assert_absent " + a.address
+}
+
+// StateAssertCountAction implements the StateAction interface.
+// StateAssertCountAction asserts the number of addresses matching a pattern
+// in the current state. The pattern follows the address-aware glob semantics
+// of xmv -address-glob, where `*` doesn't match an instance key.
+// It doesn't change the state, but fails the migration before any terraform
+// plan if the assertion is not satisfied.
+type StateAssertCountAction struct {
+	// pattern is an address pattern which can contain wildcards.
+	pattern string
+	// count is the expected number of addresses matching the pattern.
+	count int
+}
+
+var _ StateAction = (*StateAssertCountAction)(nil)
+
+// NewStateAssertCountAction returns a new StateAssertCountAction instance.
+func NewStateAssertCountAction(pattern string, count int) *StateAssertCountAction {
+	return &StateAssertCountAction{
+		pattern: pattern,
+		count:   count,
+	}
+}
+
+// StateUpdate checks a given state and returns it as it is.
+func (a *StateAssertCountAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) (*tfexec.State, error) {
+	stateList, err := tf.StateList(ctx, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.check(stateList); err != nil {
+		return nil, fmt.Errorf("assertion failed in %s: %s", tf.Dir(), err)
+	}
+	return state, nil
+}
+
+// check checks the assertion against a given list of addresses in state.
+func (a *StateAssertCountAction) check(stateList []string) error {
+	re, err := makeAddressGlobRegex(a.pattern)
+	if err != nil {
+		return err
+	}
+
+	found := []string{}
+	for _, s := range stateList {
+		if re.MatchString(s) {
+			found = append(found, s)
+		}
+	}
+	if len(found) != a.count {
+		return fmt.Errorf("%s: expected %d addresses, but found %d: %s", a, a.count, len(found), strings.Join(found, ", "))
+	}
+	return nil
+}
+
+// String returns a string representation of the action.
+func (a *StateAssertCountAction) String() string {
+	return "assert_count " + a.pattern + " " + strconv.Itoa(a.count)
+}
+
+// newStateAssertActionFromArgs builds an assertion action from given
+// arguments split from a plain text. The first argument is the action type.
+// It is shared by single and multi state actions.
+func newStateAssertActionFromArgs(args []string) (StateAction, error) {
+	switch args[0] {
+	case "assert_exists":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected 1 argument, but got %d", len(args)-1)
+		}
+		return NewStateAssertExistsAction(args[1]), nil
+
+	case "assert_absent":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected 1 argument, but got %d", len(args)-1)
+		}
+		return NewStateAssertAbsentAction(args[1]), nil
+
+	case "assert_count":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("expected 2 arguments, but got %d", len(args)-1)
+		}
+		// validate the pattern early to fail before running any terraform command.
+		if _, err := makeAddressGlobRegex(args[1]); err != nil {
+			return nil, err
+		}
+		count, err := strconv.Atoi(args[2])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("count must be a non-negative number: %s", args[2])
+		}
+		return NewStateAssertCountAction(args[1], count), nil
+
+	default:
+		return nil, fmt.Errorf("unknown assertion type: %s", args[0])
+	}
+}
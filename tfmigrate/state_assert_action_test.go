@@ -0,0 +1,142 @@
+package tfmigrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+func TestStateAssertActionCheck(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"null_resource.bar[0]",
+		"null_resource.bar[1]",
+		"module.baz.null_resource.qux",
+	}
+
+	cases := []struct {
+		desc   string
+		action interface{ check([]string) error }
+		ok     bool
+	}{
+		{
+			desc:   "assert_exists (found)",
+			action: NewStateAssertExistsAction("null_resource.foo"),
+			ok:     true,
+		},
+		{
+			desc:   "assert_exists (resource with instances)",
+			action: NewStateAssertExistsAction("null_resource.bar"),
+			ok:     true,
+		},
+		{
+			desc:   "assert_exists (module)",
+			action: NewStateAssertExistsAction("module.baz"),
+			ok:     true,
+		},
+		{
+			desc:   "assert_exists (not found)",
+			action: NewStateAssertExistsAction("null_resource.foo2"),
+			ok:     false,
+		},
+		{
+			desc:   "assert_absent (not found)",
+			action: NewStateAssertAbsentAction("null_resource.foo2"),
+			ok:     true,
+		},
+		{
+			desc:   "assert_absent (found)",
+			action: NewStateAssertAbsentAction("null_resource.bar"),
+			ok:     false,
+		},
+		{
+			desc:   "assert_count (match)",
+			action: NewStateAssertCountAction("null_resource.bar[*]", 2),
+			ok:     true,
+		},
+		{
+			desc:   "assert_count (wildcard doesn't match instance keys)",
+			action: NewStateAssertCountAction("null_resource.*", 1),
+			ok:     true,
+		},
+		{
+			desc:   "assert_count (wildcard with instance keys)",
+			action: NewStateAssertCountAction("null_resource.*[*]", 2),
+			ok:     true,
+		},
+		{
+			desc:   "assert_count (across modules)",
+			action: NewStateAssertCountAction("**.null_resource.*", 2),
+			ok:     true,
+		},
+		{
+			desc:   "assert_count (zero)",
+			action: NewStateAssertCountAction("time_static.*", 0),
+			ok:     true,
+		},
+		{
+			desc:   "assert_count (mismatch)",
+			action: NewStateAssertCountAction("null_resource.*", 2),
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.action.check(stateList)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error")
+			}
+		})
+	}
+}
+
+func TestAccStateAssertAction(t *testing.T) {
+	tfexec.SkipUnlessAcceptanceTestEnabled(t)
+
+	backend := tfexec.GetTestAccBackendS3Config(t.Name())
+
+	source := `
+resource "null_resource" "foo" {}
+resource "null_resource" "bar" {}
+`
+
+	workspace := "default"
+	tf := tfexec.SetupTestAccWithApply(t, workspace, backend+source)
+	ctx := context.Background()
+
+	updatedSource := `
+resource "null_resource" "foo2" {}
+resource "null_resource" "bar" {}
+`
+	tfexec.UpdateTestAccSource(t, tf, backend+updatedSource)
+
+	actions := []StateAction{
+		NewStateAssertExistsAction("null_resource.foo"),
+		NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+		NewStateAssertAbsentAction("null_resource.foo"),
+		NewStateAssertCountAction("null_resource.*", 2),
+	}
+
+	m := NewStateMigrator(tf.Dir(), workspace, actions, &MigratorOption{}, false, false)
+	err := m.Plan(ctx)
+	if err != nil {
+		t.Fatalf("failed to run migrator plan: %s", err)
+	}
+
+	// an unsatisfied assertion fails before terraform plan.
+	actions = []StateAction{
+		NewStateMvAction("null_resource.foo", "null_resource.foo2"),
+		NewStateAssertExistsAction("null_resource.foo"),
+	}
+
+	m = NewStateMigrator(tf.Dir(), workspace, actions, &MigratorOption{}, false, false)
+	err = m.Plan(ctx)
+	if err == nil {
+		t.Fatalf("expected to return an error, but no error")
+	}
+}
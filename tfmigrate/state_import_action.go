@@ -36,3 +36,8 @@ func (a *StateImportAction) StateUpdate(ctx context.Context, tf tfexec.Terraform
 	// because we never restore state from the backup generated by each state action.
 	return tf.Import(ctx, state, a.address, a.id, "-input=false", "-no-color", "-backup=/dev/null")
 }
+
+// String returns a string representation of the action.
+func (a *StateImportAction) String() string {
+	return "import " + a.address + " " + a.id
+}
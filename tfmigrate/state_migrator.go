@@ -36,6 +36,16 @@ type StateMigratorConfig struct {
 	ToSkipPlan bool `hcl:"to_skip_plan,optional"`
 	// Workspace is the state workspace which the migration works with.
 	Workspace string `hcl:"workspace,optional"`
+	// Down is a list of state action to revert the migration.
+	// The format of action is the same as Actions.
+	// If not set, the rollback actions are computed by inverting Actions in
+	// reverse order. Note that rm and import actions cannot be inverted
+	// automatically, so you need to define down actions explicitly.
+	Down []string `hcl:"down,optional"`
+	// Idempotent controls whether or not to skip actions which have already
+	// been applied to the current state. It makes a partial re-run safe, for
+	// example, when history is lost or someone has run a state command by hand.
+	Idempotent bool `hcl:"idempotent,optional"`
 }
 
 // StateMigratorConfig implements a MigratorConfig.
@@ -54,15 +64,53 @@ func (c *StateMigratorConfig) NewMigrator(o *MigratorOption) (Migrator, error) {
 	}
 
 	// build actions from config.
-	actions := []StateAction{}
-	for _, cmdStr := range c.Actions {
-		action, err := NewStateActionFromString(cmdStr)
+	actions, err := newStateActionsFromStrings(c.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newStateMigrator(dir, actions, o), nil
+}
+
+// NewRollbackMigrator returns a new instance of StateMigrator which reverts
+// the migration.
+func (c *StateMigratorConfig) NewRollbackMigrator(o *MigratorOption) (Migrator, error) {
+	// default working directory
+	dir := "."
+	if len(c.Dir) > 0 {
+		dir = c.Dir
+	}
+
+	var actions []StateAction
+	if len(c.Down) > 0 {
+		// use down actions explicitly defined.
+		down, err := newStateActionsFromStrings(c.Down)
+		if err != nil {
+			return nil, err
+		}
+		actions = down
+	} else {
+		if len(c.Actions) == 0 {
+			return nil, fmt.Errorf("failed to NewRollbackMigrator with no actions")
+		}
+
+		up, err := newStateActionsFromStrings(c.Actions)
+		if err != nil {
+			return nil, err
+		}
+
+		actions, err = invertStateActions(up)
 		if err != nil {
 			return nil, err
 		}
-		actions = append(actions, action)
 	}
 
+	return c.newStateMigrator(dir, actions, o), nil
+}
+
+// newStateMigrator is a helper method which returns a new StateMigrator
+// instance with given actions and other settings in the config.
+func (c *StateMigratorConfig) newStateMigrator(dir string, actions []StateAction, o *MigratorOption) *StateMigrator {
 	//use default workspace if not specified by user
 	if len(c.Workspace) == 0 {
 		c.Workspace = "default"
@@ -74,7 +122,22 @@ func (c *StateMigratorConfig) NewMigrator(o *MigratorOption) (Migrator, error) {
 	if c.ToSkipPlan {
 		log.Printf("[WARN] [migrator@%s] `to_skip_plan` is deprecated. Use `skip_plan` instead.", dir)
 	}
-	return NewStateMigrator(dir, c.Workspace, actions, o, c.Force, skipPlan), nil
+	m := NewStateMigrator(dir, c.Workspace, actions, o, c.Force, skipPlan)
+	m.idempotent = c.Idempotent
+	return m
+}
+
+// newStateActionsFromStrings builds a list of StateAction from given strings.
+func newStateActionsFromStrings(cmdStrs []string) ([]StateAction, error) {
+	actions := []StateAction{}
+	for _, cmdStr := range cmdStrs {
+		action, err := NewStateActionFromString(cmdStr)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
 }
 
 // StateMigrator implements the Migrator interface.
@@ -92,9 +155,14 @@ type StateMigrator struct {
 	force bool
 	// workspace is the state workspace which the migration works with.
 	workspace string
+	// idempotent skips actions which have already been applied.
+	idempotent bool
+	// report is a detailed result of the last plan or apply.
+	report *Report
 }
 
 var _ Migrator = (*StateMigrator)(nil)
+var _ Reporter = (*StateMigrator)(nil)
 
 // NewStateMigrator returns a new StateMigrator instance.
 func NewStateMigrator(dir string, workspace string, actions []StateAction,
@@ -141,7 +209,7 @@ func (m *StateMigrator) plan(ctx context.Context) (currentState *tfexec.State, e
 	}
 
 	// setup work dir.
-	currentState, switchBackToRemoteFunc, err := setupWorkDir(ctx, m.tf, m.workspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, ignoreLegacyStateInitErr)
+	currentState, version, switchBackToRemoteFunc, err := setupWorkDir(ctx, m.tf, m.workspace, m.o.IsBackendTerraformCloud, m.o.BackendConfig, ignoreLegacyStateInitErr)
 	if err != nil {
 		return nil, err
 	}
@@ -151,16 +219,84 @@ func (m *StateMigrator) plan(ctx context.Context) (currentState *tfexec.State, e
 		err = errors.Join(err, switchBackToRemoteFunc())
 	}()
 
+	report := &Report{
+		TerraformVersion: version,
+		Actions:          []string{},
+		States: []StateReport{
+			{
+				Dir:          m.tf.Dir(),
+				Workspace:    m.workspace,
+				SerialBefore: stateSerial(currentState),
+			},
+		},
+	}
+
 	// computes a new state by applying state migration operations to a temporary state.
 	log.Printf("[INFO] [migrator@%s] compute a new state\n", m.tf.Dir())
 	var newState *tfexec.State
+	// stateList is a list of addresses in the current state for an idempotent
+	// migration. It is updated in memory as actions apply, and fetched again
+	// only if it becomes stale.
+	var stateList []string
+	stateListStale := true
+	refreshStateList := func() error {
+		if !stateListStale {
+			return nil
+		}
+		var err error
+		stateList, err = m.tf.StateList(ctx, currentState, nil)
+		if err != nil {
+			return err
+		}
+		stateListStale = false
+		return nil
+	}
 	for _, action := range m.actions {
-		newState, err = action.StateUpdate(ctx, m.tf, currentState)
+		if m.idempotent {
+			if err := refreshStateList(); err != nil {
+				return nil, err
+			}
+			if wildcardActionApplied(action, stateList) {
+				log.Printf("[INFO] [migrator@%s] skip an action already applied, which matches nothing: %s\n", m.tf.Dir(), action)
+				report.SkippedActions = append(report.SkippedActions, fmt.Sprint(action))
+				continue
+			}
+		}
+		// expand an action with wildcards to record concrete actions.
+		concreteActions, err := expandStateAction(ctx, m.tf, currentState, action)
 		if err != nil {
 			return nil, err
 		}
-		currentState = tfexec.NewState(newState.Bytes())
+		for _, concreteAction := range concreteActions {
+			if m.idempotent {
+				if err := refreshStateList(); err != nil {
+					return nil, err
+				}
+				concreteAction = m.skipAppliedAction(concreteAction, stateList, report)
+				if concreteAction == nil {
+					continue
+				}
+			}
+			newState, err = concreteAction.StateUpdate(ctx, m.tf, currentState)
+			if err != nil {
+				return nil, err
+			}
+			currentState = tfexec.NewState(newState.Bytes())
+			if !isAssertAction(concreteAction) {
+				report.Actions = append(report.Actions, fmt.Sprint(concreteAction))
+			}
+			if m.idempotent && !stateListStale {
+				var ok bool
+				stateList, ok = updateStateList(concreteAction, stateList)
+				stateListStale = !ok
+			}
+		}
+	}
+	if m.idempotent {
+		log.Printf("[INFO] [migrator@%s] skipped %d actions already applied\n", m.tf.Dir(), len(report.SkippedActions))
 	}
+	report.States[0].SerialAfter = stateSerial(currentState)
+	m.report = report
 
 	// build plan options
 	planOpts := []string{"-input=false", "-no-color", "-detailed-exitcode"}
@@ -191,6 +327,19 @@ func (m *StateMigrator) plan(ctx context.Context) (currentState *tfexec.State, e
 	return currentState, err
 }
 
+// skipAppliedAction checks whether a given concrete action has already been
+// applied to a list of addresses in the current state. The applied part is
+// recorded to the report as skipped, and the pending part is returned. It
+// returns nil if nothing is left to apply.
+func (m *StateMigrator) skipAppliedAction(action StateAction, stateList []string, report *Report) StateAction {
+	pending, applied := pendingStateAction(action, stateList)
+	if applied != nil {
+		log.Printf("[INFO] [migrator@%s] skip an action already applied: %s\n", m.tf.Dir(), applied)
+		report.SkippedActions = append(report.SkippedActions, fmt.Sprint(applied))
+	}
+	return pending
+}
+
 // Plan computes a new state by applying state migration operations to a temporary state.
 // It will fail if terraform plan detects any diffs with the new state.
 func (m *StateMigrator) Plan(ctx context.Context) error {
@@ -226,3 +375,8 @@ func (m *StateMigrator) Apply(ctx context.Context) error {
 	log.Printf("[INFO] [migrator] state migrator apply success!\n")
 	return nil
 }
+
+// Report returns a detailed result of the last plan or apply.
+func (m *StateMigrator) Report() *Report {
+	return m.report
+}
@@ -146,6 +146,109 @@ func TestStateMigratorConfigNewMigrator(t *testing.T) {
 	}
 }
 
+func TestStateMigratorConfigNewRollbackMigrator(t *testing.T) {
+	cases := []struct {
+		desc   string
+		config *StateMigratorConfig
+		o      *MigratorOption
+		want   []StateAction
+		ok     bool
+	}{
+		{
+			desc: "invert actions",
+			config: &StateMigratorConfig{
+				Dir: "dir1",
+				Actions: []string{
+					"mv null_resource.foo null_resource.foo2",
+					"replace-provider registry.terraform.io/-/null registry.terraform.io/hashicorp/null",
+				},
+			},
+			o: nil,
+			want: []StateAction{
+				NewStateReplaceProviderAction("registry.terraform.io/hashicorp/null", "registry.terraform.io/-/null"),
+				NewStateMvAction("null_resource.foo2", "null_resource.foo"),
+			},
+			ok: true,
+		},
+		{
+			desc: "not invertible",
+			config: &StateMigratorConfig{
+				Dir: "dir1",
+				Actions: []string{
+					"mv null_resource.foo null_resource.foo2",
+					"rm time_static.baz",
+				},
+			},
+			o:    nil,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "explicit down actions",
+			config: &StateMigratorConfig{
+				Dir: "dir1",
+				Actions: []string{
+					"mv null_resource.foo null_resource.foo2",
+					"rm time_static.baz",
+				},
+				Down: []string{
+					"import time_static.baz 2006-01-02T15:04:05Z",
+					"mv null_resource.foo2 null_resource.foo",
+				},
+			},
+			o: nil,
+			want: []StateAction{
+				NewStateImportAction("time_static.baz", "2006-01-02T15:04:05Z"),
+				NewStateMvAction("null_resource.foo2", "null_resource.foo"),
+			},
+			ok: true,
+		},
+		{
+			desc: "invalid down action",
+			config: &StateMigratorConfig{
+				Dir: "dir1",
+				Actions: []string{
+					"mv null_resource.foo null_resource.foo2",
+				},
+				Down: []string{
+					"mv null_resource.foo2",
+				},
+			},
+			o:    nil,
+			want: nil,
+			ok:   false,
+		},
+		{
+			desc: "no actions",
+			config: &StateMigratorConfig{
+				Dir:     "",
+				Actions: []string{},
+			},
+			o:    nil,
+			want: nil,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.config.NewRollbackMigrator(tc.o)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok {
+				m := got.(*StateMigrator)
+				if !reflect.DeepEqual(m.actions, tc.want) {
+					t.Errorf("got: %#v, want: %#v", m.actions, tc.want)
+				}
+			}
+		})
+	}
+}
+
 func TestAccStateMigratorApplySimple(t *testing.T) {
 	tfexec.SkipUnlessAcceptanceTestEnabled(t)
 
@@ -201,6 +304,19 @@ resource "time_static" "qux" { triggers = {} }
 		t.Fatalf("failed to run migrator apply: %s", err)
 	}
 
+	report := m.Report()
+	wantActions := []string{
+		"mv null_resource.foo null_resource.foo2",
+		"rm null_resource.bar",
+		"import time_static.qux 2006-01-02T15:04:05Z",
+	}
+	if !reflect.DeepEqual(report.Actions, wantActions) {
+		t.Errorf("got actions: %v, want actions: %v", report.Actions, wantActions)
+	}
+	if len(report.States) != 1 || report.States[0].SerialAfter <= report.States[0].SerialBefore {
+		t.Errorf("unexpected states in report: %#v", report.States)
+	}
+
 	got, err := tf.StateList(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to run terraform state list: %s", err)
@@ -226,6 +342,228 @@ resource "time_static" "qux" { triggers = {} }
 	}
 }
 
+func TestAccStateMigratorApplyWithIdempotent(t *testing.T) {
+	tfexec.SkipUnlessAcceptanceTestEnabled(t)
+
+	backend := tfexec.GetTestAccBackendS3Config(t.Name())
+
+	source := `
+resource "null_resource" "foo" {}
+resource "null_resource" "bar" {}
+resource "null_resource" "baz" {}
+`
+
+	workspace := "default"
+	tf := tfexec.SetupTestAccWithApply(t, workspace, backend+source)
+	ctx := context.Background()
+
+	updatedSource := `
+resource "null_resource" "foo2" {}
+resource "null_resource" "baz" {}
+`
+
+	tfexec.UpdateTestAccSource(t, tf, backend+updatedSource)
+
+	// simulate a partially applied migration.
+	_, _, err := tf.StateMv(ctx, nil, nil, "null_resource.foo", "null_resource.foo2")
+	if err != nil {
+		t.Fatalf("failed to run terraform state mv: %s", err)
+	}
+
+	config := &StateMigratorConfig{
+		Dir:       tf.Dir(),
+		Workspace: workspace,
+		Actions: []string{
+			"mv null_resource.foo null_resource.foo2",
+			"rm null_resource.bar",
+		},
+		Idempotent: true,
+	}
+	m, err := config.NewMigrator(&MigratorOption{})
+	if err != nil {
+		t.Fatalf("failed to new migrator: %s", err)
+	}
+
+	err = m.Apply(ctx)
+	if err != nil {
+		t.Fatalf("failed to run migrator apply: %s", err)
+	}
+
+	report := m.(*StateMigrator).Report()
+	wantActions := []string{
+		"rm null_resource.bar",
+	}
+	if !reflect.DeepEqual(report.Actions, wantActions) {
+		t.Errorf("got actions: %v, want actions: %v", report.Actions, wantActions)
+	}
+	wantSkippedActions := []string{
+		"mv null_resource.foo null_resource.foo2",
+	}
+	if !reflect.DeepEqual(report.SkippedActions, wantSkippedActions) {
+		t.Errorf("got skipped actions: %v, want skipped actions: %v", report.SkippedActions, wantSkippedActions)
+	}
+
+	got, err := tf.StateList(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to run terraform state list: %s", err)
+	}
+
+	want := []string{
+		"null_resource.foo2",
+		"null_resource.baz",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got state: %v, want state: %v", got, want)
+	}
+}
+
+func TestAccStateMigratorApplyWithIdempotentRmv(t *testing.T) {
+	tfexec.SkipUnlessAcceptanceTestEnabled(t)
+
+	backend := tfexec.GetTestAccBackendS3Config(t.Name())
+
+	source := `
+resource "null_resource" "foo" {}
+resource "null_resource" "bar" {}
+`
+
+	workspace := "default"
+	tf := tfexec.SetupTestAccWithApply(t, workspace, backend+source)
+	ctx := context.Background()
+
+	updatedSource := `
+resource "null_resource" "foo2" {}
+resource "null_resource" "bar2" {}
+`
+
+	tfexec.UpdateTestAccSource(t, tf, backend+updatedSource)
+
+	config := &StateMigratorConfig{
+		Dir:       tf.Dir(),
+		Workspace: workspace,
+		Actions: []string{
+			`rmv 'null_resource\.(foo|bar)' 'null_resource.${1}2'`,
+		},
+		Idempotent: true,
+	}
+
+	// apply the migration and then re-run it.
+	for i := 0; i < 2; i++ {
+		m, err := config.NewMigrator(&MigratorOption{})
+		if err != nil {
+			t.Fatalf("failed to new migrator: %s", err)
+		}
+
+		err = m.Apply(ctx)
+		if err != nil {
+			t.Fatalf("failed to run migrator apply: %s", err)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		report := m.(*StateMigrator).Report()
+		if len(report.Actions) != 0 {
+			t.Errorf("got actions: %v, want no actions", report.Actions)
+		}
+		wantSkippedActions := []string{
+			`rmv null_resource\.(foo|bar) null_resource.${1}2`,
+		}
+		if !reflect.DeepEqual(report.SkippedActions, wantSkippedActions) {
+			t.Errorf("got skipped actions: %v, want skipped actions: %v", report.SkippedActions, wantSkippedActions)
+		}
+	}
+
+	got, err := tf.StateList(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to run terraform state list: %s", err)
+	}
+
+	want := []string{
+		"null_resource.foo2",
+		"null_resource.bar2",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got state: %v, want state: %v", got, want)
+	}
+}
+
+func TestAccStateMigratorApplyWithIdempotentXrm(t *testing.T) {
+	tfexec.SkipUnlessAcceptanceTestEnabled(t)
+
+	backend := tfexec.GetTestAccBackendS3Config(t.Name())
+
+	source := `
+resource "null_resource" "foo" {}
+resource "null_resource" "bar" {}
+resource "time_static" "baz" {}
+`
+
+	workspace := "default"
+	tf := tfexec.SetupTestAccWithApply(t, workspace, backend+source)
+	ctx := context.Background()
+
+	updatedSource := `
+resource "time_static" "baz" {}
+`
+
+	tfexec.UpdateTestAccSource(t, tf, backend+updatedSource)
+
+	config := &StateMigratorConfig{
+		Dir:       tf.Dir(),
+		Workspace: workspace,
+		Actions: []string{
+			"xrm -expect-count=2 null_resource.*",
+		},
+		Idempotent: true,
+	}
+
+	// apply the migration and then re-run it.
+	for i := 0; i < 2; i++ {
+		m, err := config.NewMigrator(&MigratorOption{})
+		if err != nil {
+			t.Fatalf("failed to new migrator: %s", err)
+		}
+
+		err = m.Apply(ctx)
+		if err != nil {
+			t.Fatalf("failed to run migrator apply: %s", err)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		report := m.(*StateMigrator).Report()
+		if len(report.Actions) != 0 {
+			t.Errorf("got actions: %v, want no actions", report.Actions)
+		}
+		wantSkippedActions := []string{
+			"xrm -expect-count=2 null_resource.*",
+		}
+		if !reflect.DeepEqual(report.SkippedActions, wantSkippedActions) {
+			t.Errorf("got skipped actions: %v, want skipped actions: %v", report.SkippedActions, wantSkippedActions)
+		}
+	}
+
+	got, err := tf.StateList(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to run terraform state list: %s", err)
+	}
+
+	want := []string{
+		"time_static.baz",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got state: %v, want state: %v", got, want)
+	}
+}
+
 func TestAccStateMigratorApplyWithWorkspace(t *testing.T) {
 	tfexec.SkipUnlessAcceptanceTestEnabled(t)
 
@@ -35,3 +35,8 @@ func (a *StateMvAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI,
 	newState, _, err := tf.StateMv(ctx, state, nil, a.source, a.destination, "-backup=/dev/null")
 	return newState, err
 }
+
+// String returns a string representation of the action.
+func (a *StateMvAction) String() string {
+	return "mv " + a.source + " " + a.destination
+}
@@ -2,6 +2,7 @@ package tfmigrate
 
 import (
 	"context"
+	"strings"
 
 	"github.com/minamijoyo/tfmigrate/tfexec"
 )
@@ -30,3 +31,8 @@ func (a *StateRmAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI,
 	// The state rm command doesn't provide a way to disable it, so we backup to /dev/null.
 	return tf.StateRm(ctx, state, a.addresses, "-backup=/dev/null")
 }
+
+// String returns a string representation of the action.
+func (a *StateRmAction) String() string {
+	return "rm " + strings.Join(a.addresses, " ")
+}
@@ -0,0 +1,69 @@
+package tfmigrate
+
+import (
+	"context"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// StateRmvAction implements the StateAction interface.
+// StateRmvAction is a regex version of StateXmvAction.
+// It allows you to move multiple resources matching an anchored RE2 pattern
+// and build destinations with capture groups.
+type StateRmvAction struct {
+	// source is a RE2 pattern which matches addresses of resources to be moved.
+	source string
+	// destination is a template of a new address which can refer to capture
+	// groups in the source such as $1 or ${name}.
+	destination string
+	// allowEmpty allows the source pattern to match nothing.
+	allowEmpty bool
+}
+
+var _ StateAction = (*StateRmvAction)(nil)
+
+// NewStateRmvAction returns a new StateRmvAction instance.
+func NewStateRmvAction(source string, destination string, allowEmpty bool) *StateRmvAction {
+	return &StateRmvAction{
+		source:      source,
+		destination: destination,
+		allowEmpty:  allowEmpty,
+	}
+}
+
+// StateUpdate updates a given state and returns a new state.
+// Source resources are matched against the tf state with a regex.
+// Each match will generate a move command.
+func (a *StateRmvAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) (*tfexec.State, error) {
+	stateMvActions, err := a.generateMvActions(ctx, tf, state)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, action := range stateMvActions {
+		state, err = action.StateUpdate(ctx, tf, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, err
+}
+
+// generateMvActions uses an rmv and use the state to determine the corresponding mv actions.
+func (a *StateRmvAction) generateMvActions(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) ([]*StateMvAction, error) {
+	stateList, err := tf.StateList(ctx, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newRmvExpander(a)
+	return e.expand(stateList)
+}
+
+// String returns a string representation of the action.
+func (a *StateRmvAction) String() string {
+	if a.allowEmpty {
+		return "rmv -allow-empty " + a.source + " " + a.destination
+	}
+	return "rmv " + a.source + " " + a.destination
+}
@@ -14,6 +14,9 @@ type StateXmvAction struct {
 	source string
 	// destination is a new address of resource or module to move which can contain placeholders.
 	destination string
+	// addressGlob enables address-aware glob semantics for wildcards.
+	// See makeAddressGlobRegex for details.
+	addressGlob bool
 }
 
 var _ StateAction = (*StateXmvAction)(nil)
@@ -54,3 +57,11 @@ func (a *StateXmvAction) generateMvActions(ctx context.Context, tf tfexec.Terraf
 	e := newXmvExpander(a)
 	return e.expand(stateList)
 }
+
+// String returns a string representation of the action.
+func (a *StateXmvAction) String() string {
+	if a.addressGlob {
+		return "xmv -address-glob " + a.source + " " + a.destination
+	}
+	return "xmv " + a.source + " " + a.destination
+}
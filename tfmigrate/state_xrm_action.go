@@ -0,0 +1,116 @@
+package tfmigrate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+// StateXrmAction implements the StateAction interface.
+// StateXrmAction is an extended version of StateRmAction.
+// It allows you to remove multiple resources with wildcard matching.
+// Wildcards follow the address-aware glob semantics of xmv -address-glob.
+// Note that `*` doesn't match an instance key, so use `*[*]` to remove
+// resources with count or for_each.
+type StateXrmAction struct {
+	// patterns is a list of address patterns to be removed which can contain wildcards.
+	patterns []string
+	// expectCount is the expected number of addresses to be removed.
+	// It guards against over-broad patterns.
+	expectCount int
+}
+
+var _ StateAction = (*StateXrmAction)(nil)
+
+// NewStateXrmAction returns a new StateXrmAction instance.
+func NewStateXrmAction(patterns []string, expectCount int) *StateXrmAction {
+	return &StateXrmAction{
+		patterns:    patterns,
+		expectCount: expectCount,
+	}
+}
+
+// StateUpdate updates a given state and returns a new state.
+// Patterns have wildcards which should be matched against the tf state.
+// Matched addresses are removed from state.
+func (a *StateXrmAction) StateUpdate(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) (*tfexec.State, error) {
+	stateRmActions, err := a.generateRmActions(ctx, tf, state)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, action := range stateRmActions {
+		state, err = action.StateUpdate(ctx, tf, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, err
+}
+
+// generateRmActions uses an xrm and use the state to determine the corresponding rm actions.
+// It returns an empty list if nothing matches as expected.
+func (a *StateXrmAction) generateRmActions(ctx context.Context, tf tfexec.TerraformCLI, state *tfexec.State) ([]*StateRmAction, error) {
+	stateList, err := tf.StateList(ctx, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := a.expand(stateList)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 {
+		return []*StateRmAction{}, nil
+	}
+	return []*StateRmAction{NewStateRmAction(addresses)}, nil
+}
+
+// expand returns addresses in the list of resources which match any of the
+// patterns. It returns an error if the number of addresses differs from the
+// expected count.
+func (a *StateXrmAction) expand(stateList []string) ([]string, error) {
+	addresses, err := a.match(stateList)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) != a.expectCount {
+		return nil, fmt.Errorf("xrm action expects %d addresses, but patterns match %d addresses: %s", a.expectCount, len(addresses), strings.Join(addresses, ", "))
+	}
+	return addresses, nil
+}
+
+// match returns addresses in the list of resources which match any of the
+// patterns regardless of the expected count.
+func (a *StateXrmAction) match(stateList []string) ([]string, error) {
+	res := make([]*regexp.Regexp, 0, len(a.patterns))
+	for _, p := range a.patterns {
+		re, err := makeAddressGlobRegex(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+
+	addresses := []string{}
+	for _, s := range stateList {
+		for _, re := range res {
+			if re.MatchString(s) {
+				addresses = append(addresses, s)
+				break
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// String returns a string representation of the action.
+func (a *StateXrmAction) String() string {
+	return "xrm -expect-count=" + strconv.Itoa(a.expectCount) + " " + strings.Join(a.patterns, " ")
+}
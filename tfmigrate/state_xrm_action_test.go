@@ -0,0 +1,138 @@
+package tfmigrate
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/minamijoyo/tfmigrate/tfexec"
+)
+
+func TestStateXrmActionExpand(t *testing.T) {
+	stateList := []string{
+		"null_resource.foo",
+		"module.legacy.kubernetes_manifest.foo",
+		`module.legacy.kubernetes_manifest.bar["a"]`,
+		"module.legacy.module.app.kubernetes_manifest.baz",
+		"module.current.kubernetes_manifest.qux",
+	}
+
+	cases := []struct {
+		desc   string
+		action *StateXrmAction
+		want   []string
+		ok     bool
+	}{
+		{
+			desc:   "simple",
+			action: NewStateXrmAction([]string{"module.legacy.kubernetes_manifest.*"}, 1),
+			want: []string{
+				"module.legacy.kubernetes_manifest.foo",
+			},
+			ok: true,
+		},
+		{
+			desc:   "instance keys",
+			action: NewStateXrmAction([]string{"module.legacy.kubernetes_manifest.*[*]"}, 1),
+			want: []string{
+				`module.legacy.kubernetes_manifest.bar["a"]`,
+			},
+			ok: true,
+		},
+		{
+			desc: "multiple patterns",
+			action: NewStateXrmAction([]string{
+				"module.legacy.**.kubernetes_manifest.*",
+				"module.legacy.**.kubernetes_manifest.*[*]",
+			}, 3),
+			want: []string{
+				"module.legacy.kubernetes_manifest.foo",
+				`module.legacy.kubernetes_manifest.bar["a"]`,
+				"module.legacy.module.app.kubernetes_manifest.baz",
+			},
+			ok: true,
+		},
+		{
+			desc:   "overlapping patterns",
+			action: NewStateXrmAction([]string{"null_resource.*", "null_resource.foo"}, 1),
+			want: []string{
+				"null_resource.foo",
+			},
+			ok: true,
+		},
+		{
+			desc:   "matches nothing as expected",
+			action: NewStateXrmAction([]string{"time_static.*"}, 0),
+			want:   []string{},
+			ok:     true,
+		},
+		{
+			desc:   "count mismatch",
+			action: NewStateXrmAction([]string{"**.kubernetes_manifest.*"}, 2),
+			want:   nil,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.action.expand(stateList)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %#v, want: %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccStateXrmAction(t *testing.T) {
+	tfexec.SkipUnlessAcceptanceTestEnabled(t)
+
+	backend := tfexec.GetTestAccBackendS3Config(t.Name())
+
+	source := `
+resource "null_resource" "foo" {}
+resource "null_resource" "bar" {}
+resource "time_static" "baz" {}
+`
+
+	workspace := "default"
+	tf := tfexec.SetupTestAccWithApply(t, workspace, backend+source)
+	ctx := context.Background()
+
+	updatedSource := `
+resource "time_static" "baz" {}
+`
+
+	tfexec.UpdateTestAccSource(t, tf, backend+updatedSource)
+
+	changed, err := tf.PlanHasChange(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to run PlanHasChange: %s", err)
+	}
+	if !changed {
+		t.Fatalf("expect to have changes")
+	}
+
+	actions := []StateAction{
+		NewStateXrmAction([]string{"null_resource.*"}, 2),
+	}
+
+	m := NewStateMigrator(tf.Dir(), workspace, actions, &MigratorOption{}, false, false)
+	err = m.Plan(ctx)
+	if err != nil {
+		t.Fatalf("failed to run migrator plan: %s", err)
+	}
+
+	wantActions := []string{
+		"rm null_resource.foo null_resource.bar",
+	}
+	if got := m.Report().Actions; !reflect.DeepEqual(got, wantActions) {
+		t.Errorf("got actions: %v, want actions: %v", got, wantActions)
+	}
+}
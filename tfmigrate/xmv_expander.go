@@ -42,8 +42,73 @@ func makeSrcRegex(source string) (*regexp.Regexp, error) {
 	return regExpression, nil
 }
 
+// addressSegmentRegex matches a segment of address separated by dots, that
+// is, a name optionally followed by an instance key.
+const addressSegmentRegex = `[^.\[\]"]+(?:\[(?:"(?:[^"\\]|\\.)*"|[0-9]+)\])?`
+
+// addressKeyRegex matches an instance key with brackets and captures the key.
+const addressKeyRegex = `\[("(?:[^"\\]|\\.)*"|[0-9]+)\]`
+
+// addressNameRegex matches characters within a name of address segment.
+const addressNameRegex = `([^.\[\]"]*)`
+
+// makeAddressGlobRegex returns an anchored regex for an address-aware glob
+// pattern. Unlike the default wildcard, it respects address segments.
+//   - `*` matches characters within one address segment. It never matches an
+//     instance key, so that `aws_instance.*` doesn't match
+//     `aws_instance.foo[0]`. Use `*[*]` to match instances with keys.
+//   - `**` matches zero or more address segments. It must be a whole segment.
+//     When followed by a dot, the captured value includes the trailing dot.
+//   - `[*]` matches any instance key and captures the key with quotes.
+//
+// Each wildcard is captured and can be referenced as $1, $2, ... in the
+// destination.
+func makeAddressGlobRegex(source string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(source); {
+		rest := source[i:]
+		switch {
+		case strings.HasPrefix(rest, "[*]"):
+			b.WriteString(addressKeyRegex)
+			i += 3
+
+		case strings.HasPrefix(rest, "**"):
+			if (i > 0 && source[i-1] != '.') || (len(rest) > 2 && rest[2] != '.') {
+				return nil, fmt.Errorf("** must be a whole address segment: %s", source)
+			}
+			if len(rest) > 2 {
+				// consume the following dot.
+				b.WriteString(`((?:` + addressSegmentRegex + `\.)*)`)
+				i += 3
+			} else {
+				b.WriteString(`(` + addressSegmentRegex + `(?:\.` + addressSegmentRegex + `)*)`)
+				i += 2
+			}
+
+		case rest[0] == '*':
+			b.WriteString(addressNameRegex)
+			i++
+
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+			i++
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("could not make pattern out of %s (%s) due to %s", source, b.String(), err)
+	}
+	return re, nil
+}
+
 // expand returns actions matching wildcard move actions based on the list of resources.
 func (e *xmvExpander) expand(stateList []string) ([]*StateMvAction, error) {
+	if e.action.addressGlob {
+		return e.expandAddressGlob(stateList)
+	}
 	if e.nrOfWildcards() == 0 {
 		staticActionAsList := make([]*StateMvAction, 1)
 		staticActionAsList[0] = NewStateMvAction(e.action.source, e.action.destination)
@@ -64,6 +129,30 @@ func (e *xmvExpander) expand(stateList []string) ([]*StateMvAction, error) {
 	return matchingActions, nil
 }
 
+// expandAddressGlob returns actions matching an address-aware glob pattern
+// based on the list of resources.
+func (e *xmvExpander) expandAddressGlob(stateList []string) ([]*StateMvAction, error) {
+	if e.nrOfWildcards() == 0 {
+		return []*StateMvAction{NewStateMvAction(e.action.source, e.action.destination)}, nil
+	}
+
+	re, err := makeAddressGlobRegex(e.action.source)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingActions := []*StateMvAction{}
+	for _, s := range stateList {
+		match := re.FindStringSubmatchIndex(s)
+		if match == nil {
+			continue
+		}
+		destination := string(re.ExpandString(nil, e.action.destination, s, match))
+		matchingActions = append(matchingActions, NewStateMvAction(s, destination))
+	}
+	return matchingActions, nil
+}
+
 // nrOfWildcards counts a number of wildcard characters.
 func (e *xmvExpander) nrOfWildcards() int {
 	return strings.Count(e.action.source, wildcardChar)
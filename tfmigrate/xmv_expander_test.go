@@ -176,3 +176,135 @@ func TestXmvExpanderExpand(t *testing.T) {
 		})
 	}
 }
+
+func TestXmvExpanderExpandAddressGlob(t *testing.T) {
+	stateList := []string{
+		"aws_instance.foo",
+		"aws_instance.bar",
+		"aws_instance.web[0]",
+		`aws_instance.web["a.b"]`,
+		"module.foo.aws_instance.baz",
+		"module.foo.module.bar.aws_instance.qux",
+		`module.foo["x"].aws_instance.quux`,
+	}
+
+	cases := []struct {
+		desc   string
+		source string
+		dest   string
+		want   []*StateMvAction
+		ok     bool
+	}{
+		{
+			desc:   "single wildcard stays within a segment",
+			source: "aws_instance.*",
+			dest:   "aws_instance.${1}2",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.foo", "aws_instance.foo2"),
+				NewStateMvAction("aws_instance.bar", "aws_instance.bar2"),
+			},
+			ok: true,
+		},
+		{
+			desc:   "single wildcard doesn't match instance keys",
+			source: "aws_instance.w*",
+			dest:   "aws_instance.app$1",
+			want:   []*StateMvAction{},
+			ok:     true,
+		},
+		{
+			desc:   "single wildcard followed by instance keys",
+			source: "aws_instance.*[*]",
+			dest:   "aws_instance.${1}2[$2]",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.web[0]", "aws_instance.web2[0]"),
+				NewStateMvAction(`aws_instance.web["a.b"]`, `aws_instance.web2["a.b"]`),
+			},
+			ok: true,
+		},
+		{
+			desc:   "partial segment",
+			source: "aws_instance.f*",
+			dest:   "aws_instance.g$1",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.foo", "aws_instance.goo"),
+			},
+			ok: true,
+		},
+		{
+			desc:   "instance keys",
+			source: "aws_instance.web[*]",
+			dest:   "aws_instance.app[$1]",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.web[0]", "aws_instance.app[0]"),
+				NewStateMvAction(`aws_instance.web["a.b"]`, `aws_instance.app["a.b"]`),
+			},
+			ok: true,
+		},
+		{
+			desc:   "double wildcard spans modules",
+			source: "**.aws_instance.*",
+			dest:   "${1}aws_instance.${2}2",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.foo", "aws_instance.foo2"),
+				NewStateMvAction("aws_instance.bar", "aws_instance.bar2"),
+				NewStateMvAction("module.foo.aws_instance.baz", "module.foo.aws_instance.baz2"),
+				NewStateMvAction("module.foo.module.bar.aws_instance.qux", "module.foo.module.bar.aws_instance.qux2"),
+				NewStateMvAction(`module.foo["x"].aws_instance.quux`, `module.foo["x"].aws_instance.quux2`),
+			},
+			ok: true,
+		},
+		{
+			desc:   "double wildcard at the end",
+			source: "module.foo.**",
+			dest:   "module.new.$1",
+			want: []*StateMvAction{
+				NewStateMvAction("module.foo.aws_instance.baz", "module.new.aws_instance.baz"),
+				NewStateMvAction("module.foo.module.bar.aws_instance.qux", "module.new.module.bar.aws_instance.qux"),
+			},
+			ok: true,
+		},
+		{
+			desc:   "module keys",
+			source: "module.foo[*].aws_instance.*",
+			dest:   "module.bar[$1].aws_instance.$2",
+			want: []*StateMvAction{
+				NewStateMvAction(`module.foo["x"].aws_instance.quux`, `module.bar["x"].aws_instance.quux`),
+			},
+			ok: true,
+		},
+		{
+			desc:   "no wildcards",
+			source: "aws_instance.foo",
+			dest:   "aws_instance.foo2",
+			want: []*StateMvAction{
+				NewStateMvAction("aws_instance.foo", "aws_instance.foo2"),
+			},
+			ok: true,
+		},
+		{
+			desc:   "double wildcard within a segment",
+			source: "aws_**.foo",
+			dest:   "$1",
+			want:   nil,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			action := NewStateXmvAction(tc.source, tc.dest)
+			action.addressGlob = true
+			got, err := newXmvExpander(action).expand(stateList)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected to return an error, but no error, got: %#v", got)
+			}
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(StateMvAction{})); diff != "" {
+				t.Errorf("got: %s, want = %s, diff = %s", spew.Sdump(got), spew.Sdump(tc.want), diff)
+			}
+		})
+	}
+}